@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/config"
+	"api-auth-go/internal/infrastructure/server"
+)
+
+// runSeedCommand runs the bootstrap by hand. Without a configured admin it
+// issues a new setup token, replacing one that was lost.
+func runSeedCommand(cfg *config.Config) error {
+	useCases, err := newOperatorUseCases(cfg)
+	if err != nil {
+		return err
+	}
+	return runBootstrap(cfg, useCases.Setup, true)
+}
+
+// runBootstrap makes sure the first admin can be created, creating the
+// configured one or printing a setup token. Passwords are never logged.
+func runBootstrap(cfg *config.Config, setup *usecases.SetupUseCase, reissue bool) error {
+	admin, err := server.NewInitialAdmin(cfg)
+	if err != nil {
+		return err
+	}
+
+	output, err := setup.Bootstrap(context.Background(), admin, reissue)
+	if err != nil {
+		return err
+	}
+
+	switch output.Status {
+	case usecases.BootstrapAdminCreated:
+		log.Printf("✅ Initial admin %s created; the password must be changed on first login", output.AdminEmail)
+	case usecases.BootstrapTokenIssued:
+		log.Println("🔐 No admin exists yet. Create the first one with this one-time setup token:")
+		log.Printf("    %s", output.SetupToken)
+		log.Println("   POST /api/v1/setup {\"token\", \"name\", \"email\", \"password\"}")
+		log.Println("   The token is not shown again; run the seed command to issue a new one.")
+	case usecases.BootstrapTokenPending:
+		log.Println("🔐 No admin exists yet. Redeem the setup token at POST /api/v1/setup, or run the seed command to issue a new one.")
+	}
+	return nil
+}
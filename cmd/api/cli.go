@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/user"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/config"
+	"api-auth-go/internal/infrastructure/database"
+	"api-auth-go/internal/infrastructure/server"
+)
+
+// openOperatorDatabase connects like the server does, but only logs slow or
+// failed queries, to standard error, keeping standard output for results.
+func openOperatorDatabase(cfg *config.Config) (*gorm.DB, error) {
+	dbLogger := logger.New(log.New(os.Stderr, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+	})
+
+	return database.NewConnection(cfg.GetDatabaseURL(), dbLogger)
+}
+
+func newOperatorUseCases(cfg *config.Config) (*server.UseCases, error) {
+	db, err := openOperatorDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return server.NewUseCases(cfg, db)
+}
+
+// operatorContext identifies the changes made from the command line in the
+// audit log, by the system user running it.
+func operatorContext() context.Context {
+	name := "unknown"
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+
+	return entities.WithRequestInfo(context.Background(), entities.RequestInfo{
+		RequestID: uuid.NewString(),
+		UserAgent: "cli/" + name,
+	})
+}
+
+// parseFlags parses flags given before, between or after the positional
+// arguments, which are returned.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", "table", "output format: table or json")
+}
+
+func validateOutput(format string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+	return nil
+}
+
+// printResult writes value as indented JSON, or the rows as an aligned table.
+func printResult(format string, value any, headers []string, rows [][]string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// readPassword returns the flag value or, when empty, the first line of
+// standard input, so passwords can be piped instead of showing in the process
+// list.
+func readPassword(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	fmt.Fprintln(os.Stderr)
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is required")
+	}
+	return password, nil
+}
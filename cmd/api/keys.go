@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/config"
+)
+
+var keyHeaders = []string{"KID", "ALGORITHM", "STATUS", "CREATED_AT"}
+
+// runKeys manages the keys in JWT_KEYS_DIR. Running servers re-read the
+// directory periodically, so they pick up a rotation without a restart.
+func runKeys(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: keys list | rotate")
+	}
+
+	fs := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	output := outputFlag(fs)
+
+	switch args[0] {
+	case "list":
+		if _, err := parseFlags(fs, args[1:]); err != nil {
+			return err
+		}
+		if err := validateOutput(*output); err != nil {
+			return err
+		}
+
+		useCases, err := newOperatorUseCases(cfg)
+		if err != nil {
+			return err
+		}
+
+		list, err := useCases.Key.ListKeys()
+		if err != nil {
+			return err
+		}
+		return printKeys(*output, list, list.Keys)
+
+	case "rotate":
+		algorithm := fs.String("algorithm", "", "algorithm of the new key; defaults to JWT_SIGNING_ALGORITHM")
+		if _, err := parseFlags(fs, args[1:]); err != nil {
+			return err
+		}
+		if err := validateOutput(*output); err != nil {
+			return err
+		}
+
+		useCases, err := newOperatorUseCases(cfg)
+		if err != nil {
+			return err
+		}
+
+		key, err := useCases.Key.RotateKey(usecases.GenerateKeyInput{Algorithm: *algorithm})
+		if err != nil {
+			return err
+		}
+		return printKeys(*output, key, []usecases.KeyOutput{*key})
+
+	default:
+		return errors.New("unknown keys command: " + args[0])
+	}
+}
+
+func printKeys(format string, value any, keys []usecases.KeyOutput) error {
+	rows := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, []string{key.KID, key.Algorithm, key.Status, key.CreatedAt})
+	}
+	return printResult(format, value, keyHeaders, rows)
+}
@@ -1,67 +1,70 @@
 package main
 
 import (
-	"context"
-	"log"
+	"fmt"
+	"io"
+	"os"
 
-	"api-auth-go/internal/domain/entities"
 	"api-auth-go/internal/infrastructure/config"
-	"api-auth-go/internal/infrastructure/database"
-	"api-auth-go/internal/infrastructure/repositories"
-	"api-auth-go/internal/infrastructure/server"
-
-	"gorm.io/gorm"
 )
 
-func main() {
-	cfg := config.Load()
+const usage = `Usage: api <command> [arguments]
 
-	db, err := database.NewConnection(cfg.GetDatabaseURL())
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
+Commands:
+  serve                               start the API (default)
+  migrate up | down [n] | status      apply, revert or list migrations
+  migrate create <name>               create the files of a new migration
+  seed                                create the first admin or issue a new setup token
+  user create                         create a user (-name, -email, -role, -temporary)
+  user list                           list users (-name, -email, -role, -page, -limit)
+  user set-role <user> <role>         change the global role of a user
+  user disable <user>                 block a user from logging in
+  user enable <user>                  let a disabled user log in again
+  user reset-password <user>          set a new password for a user (-temporary)
+  keys list                           list the signing keys
+  keys rotate                         generate a signing key and make it active
+  tokens revoke <user>                revoke every session of a user
 
-	if err := runSeed(db); err != nil {
-		log.Printf("Warning: Failed to run seed: %v", err)
-	}
-
-	srv := server.NewServer(cfg, db)
-	if err := srv.Run(); err != nil {
-		log.Fatal("Failed to start server:", err)
-	}
-}
+Users are referenced by id or email. Passwords are read from standard input
+unless -password is given. Commands printing results accept -output table
+(default) or -output json.
+`
 
-func runSeed(db *gorm.DB) error {
-	userRepo := repositories.NewUserRepository(db)
-
-	ctx := context.Background()
-	adminEmail := "admin@example.com"
+func main() {
+	cfg := config.Load()
 
-	existingAdmin, err := userRepo.FindByEmail(ctx, adminEmail)
-	if err != nil {
-		return err
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	if existingAdmin != nil {
-		log.Println("Admin user already exists, skipping seed")
-		return nil
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(cfg)
+	case "migrate":
+		err = runMigrate(cfg, args)
+	case "seed":
+		err = runSeedCommand(cfg)
+	case "user":
+		err = runUser(cfg, args)
+	case "keys":
+		err = runKeys(cfg, args)
+	case "tokens":
+		err = runTokens(cfg, args)
+	case "help", "-h", "-help", "--help":
+		printUsage(os.Stdout)
+	default:
+		printUsage(os.Stderr)
+		os.Exit(2)
 	}
 
-	adminPassword := "admin123"
-	admin, err := entities.NewAdminUser("Admin User", adminEmail, adminPassword)
 	if err != nil {
-		return err
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
 	}
+}
 
-	if err := userRepo.Create(ctx, admin); err != nil {
-		return err
-	}
-
-	log.Println("✅ Admin user created successfully!")
-	log.Printf("📧 Email: %s", adminEmail)
-	log.Printf("🔑 Password: %s", adminPassword)
-	log.Printf("👑 Role: admin")
-	log.Println("💡 Use this admin to create other users via API")
-
-	return nil
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, usage)
 }
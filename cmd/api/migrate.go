@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm/logger"
+
+	"api-auth-go/internal/infrastructure/config"
+	"api-auth-go/internal/infrastructure/database"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status | create <name>"
+
+// runMigrate handles the migrate subcommand. Only create works without a
+// database, writing the files of a new migration in the source tree.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New("usage: migrate create <name>")
+		}
+		paths, err := database.CreateMigration(database.MigrationsDir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return nil
+	}
+
+	db, err := database.Open(cfg.GetDatabaseURL(), logger.Default.LogMode(logger.Info))
+	if err != nil {
+		return err
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return errors.New("steps must be a positive number")
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No migration to revert")
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
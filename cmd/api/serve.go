@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"gorm.io/gorm/logger"
+
+	"api-auth-go/internal/infrastructure/config"
+	"api-auth-go/internal/infrastructure/database"
+	"api-auth-go/internal/infrastructure/server"
+)
+
+func runServe(cfg *config.Config) error {
+	db, err := database.NewConnection(cfg.GetDatabaseURL(), logger.Default.LogMode(logger.Info))
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	useCases, err := server.NewUseCases(cfg, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize server: %w", err)
+	}
+
+	if err := runBootstrap(cfg, useCases.Setup, false); err != nil {
+		return fmt.Errorf("failed to bootstrap the first admin: %w", err)
+	}
+
+	srv, err := server.NewServer(cfg, db, useCases)
+	if err != nil {
+		return fmt.Errorf("failed to initialize server: %w", err)
+	}
+
+	if err := srv.Run(); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	return nil
+}
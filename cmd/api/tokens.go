@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/config"
+)
+
+func runTokens(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "revoke" {
+		return errors.New("usage: tokens revoke <user>")
+	}
+
+	fs := flag.NewFlagSet("tokens revoke", flag.ContinueOnError)
+	output := outputFlag(fs)
+	positional, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("usage: tokens revoke <user>")
+	}
+
+	// An in-memory store belongs to each server process, so only the refresh
+	// tokens, kept in the database, can be revoked from here.
+	if cfg.TokenRevocationStore == "memory" {
+		fmt.Fprintln(os.Stderr, "Warning: TOKEN_REVOCATION_STORE is memory; access tokens stay valid until they expire")
+	}
+
+	useCases, err := newOperatorUseCases(cfg)
+	if err != nil {
+		return err
+	}
+
+	user, err := useCases.User.RevokeUserSessions(operatorContext(), positional[0])
+	if err != nil {
+		return err
+	}
+	return printUsers(*output, user, []usecases.UserOutput{*user})
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/config"
+)
+
+var userHeaders = []string{"ID", "NAME", "EMAIL", "ROLE", "VERIFIED", "DISABLED_AT", "CREATED_AT"}
+
+func runUser(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: user create | list | set-role | disable | enable | reset-password")
+	}
+
+	fs := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	output := outputFlag(fs)
+
+	switch args[0] {
+	case "create":
+		name := fs.String("name", "", "name of the user")
+		email := fs.String("email", "", "email of the user")
+		password := fs.String("password", "", "password; read from standard input when empty")
+		role := fs.String("role", entities.RoleUser, "global role of the user")
+		temporary := fs.Bool("temporary", false, "require a new password on first login")
+		if _, err := parseFlags(fs, args[1:]); err != nil {
+			return err
+		}
+		if err := validateOutput(*output); err != nil {
+			return err
+		}
+
+		secret, err := readPassword(*password)
+		if err != nil {
+			return err
+		}
+
+		useCases, err := newOperatorUseCases(cfg)
+		if err != nil {
+			return err
+		}
+		ctx := operatorContext()
+
+		created, err := useCases.User.CreateUser(ctx, usecases.CreateUserInput{Name: *name, Email: *email, Password: secret, TemporaryPassword: *temporary})
+		if err != nil {
+			return err
+		}
+
+		var user *usecases.UserOutput
+		if *role == entities.RoleUser {
+			user, err = useCases.User.FindUser(ctx, created.ID)
+		} else {
+			user, err = useCases.User.SetUserRole(ctx, created.ID, *role)
+		}
+		if err != nil {
+			return err
+		}
+		return printUsers(*output, user, []usecases.UserOutput{*user})
+
+	case "list":
+		filters := &entities.UserFilters{}
+		fs.StringVar(&filters.Name, "name", "", "filter by name")
+		fs.StringVar(&filters.Email, "email", "", "filter by email")
+		fs.StringVar(&filters.Role, "role", "", "filter by role")
+		fs.IntVar(&filters.Page, "page", 1, "page number")
+		fs.IntVar(&filters.Limit, "limit", 10, "users per page, up to 100")
+		fs.StringVar(&filters.SortBy, "sort-by", "created_at", "name, email, role, created_at or updated_at")
+		fs.StringVar(&filters.SortOrder, "sort-order", "desc", "asc or desc")
+		if _, err := parseFlags(fs, args[1:]); err != nil {
+			return err
+		}
+		if err := validateOutput(*output); err != nil {
+			return err
+		}
+
+		useCases, err := newOperatorUseCases(cfg)
+		if err != nil {
+			return err
+		}
+
+		list, err := useCases.User.SearchUsers(operatorContext(), filters)
+		if err != nil {
+			return err
+		}
+		return printUsers(*output, list, list.Users)
+
+	case "set-role", "disable", "enable", "reset-password":
+		var password *string
+		var temporary *bool
+		if args[0] == "reset-password" {
+			password = fs.String("password", "", "new password; read from standard input when empty")
+			temporary = fs.Bool("temporary", false, "require a new password on next login")
+		}
+		positional, err := parseFlags(fs, args[1:])
+		if err != nil {
+			return err
+		}
+		if err := validateOutput(*output); err != nil {
+			return err
+		}
+
+		expected := 1
+		if args[0] == "set-role" {
+			expected = 2
+		}
+		if len(positional) != expected {
+			if expected == 2 {
+				return errors.New("usage: user set-role <user> <role>")
+			}
+			return errors.New("usage: user " + args[0] + " <user>")
+		}
+
+		var secret string
+		if args[0] == "reset-password" {
+			if secret, err = readPassword(*password); err != nil {
+				return err
+			}
+		}
+
+		useCases, err := newOperatorUseCases(cfg)
+		if err != nil {
+			return err
+		}
+		ctx := operatorContext()
+
+		var user *usecases.UserOutput
+		switch args[0] {
+		case "set-role":
+			user, err = useCases.User.SetUserRole(ctx, positional[0], positional[1])
+		case "disable":
+			user, err = useCases.User.DisableUser(ctx, positional[0])
+		case "enable":
+			user, err = useCases.User.EnableUser(ctx, positional[0])
+		case "reset-password":
+			user, err = useCases.User.SetUserPassword(ctx, positional[0], secret, *temporary)
+		}
+		if err != nil {
+			return err
+		}
+		return printUsers(*output, user, []usecases.UserOutput{*user})
+
+	default:
+		return errors.New("unknown user command: " + args[0])
+	}
+}
+
+func printUsers(format string, value any, users []usecases.UserOutput) error {
+	rows := make([][]string, 0, len(users))
+	for _, user := range users {
+		rows = append(rows, []string{user.ID, user.Name, user.Email, user.Role, strconv.FormatBool(user.EmailVerified), dashIfEmpty(user.DisabledAt), user.CreatedAt})
+	}
+	return printResult(format, value, userHeaders, rows)
+}
+
+func dashIfEmpty(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,59 @@
+package entities
+
+const (
+	UserFieldName  = "name"
+	UserFieldEmail = "email"
+	UserFieldRole  = "role"
+)
+
+// Actor is the authenticated user performing an operation, as identified by
+// the access token.
+type Actor struct {
+	UserID      string
+	Role        string
+	Permissions []string
+}
+
+func (a Actor) HasPermission(permission string) bool {
+	for _, granted := range a.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSuperAdmin reports whether the actor manages the whole platform. Super
+// admins keep their role when acting on an organization.
+func (a Actor) IsSuperAdmin() bool {
+	return a.Role == RoleSuperAdmin
+}
+
+// HasAllPermissions reports whether the actor holds every given permission.
+// It keeps actors from granting more than they have themselves.
+func (a Actor) HasAllPermissions(permissions []string) bool {
+	for _, permission := range permissions {
+		if !a.HasPermission(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanUpdateUserField reports whether the actor may change the given field of
+// the target user. Users may edit their own name and email; anything else
+// needs a permission.
+func (a Actor) CanUpdateUserField(target *User, field string) bool {
+	switch field {
+	case UserFieldName, UserFieldEmail:
+		return a.UserID == target.ID.String() || a.HasPermission(PermissionUsersWrite)
+	case UserFieldRole:
+		return a.HasPermission(PermissionRolesAssign)
+	default:
+		return false
+	}
+}
+
+func (a Actor) CanDeleteUser(target *User) bool {
+	return a.HasPermission(PermissionUsersDelete)
+}
@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestActorCanUpdateUserField(t *testing.T) {
+	target := &User{ID: uuid.New(), Role: RoleUser}
+	self := target.ID.String()
+	other := uuid.NewString()
+	every := (&Role{Permissions: DefaultPermissions()}).PermissionNames()
+
+	tests := []struct {
+		name        string
+		actor       Actor
+		field       string
+		wantAllowed bool
+	}{
+		{name: "own name", actor: Actor{UserID: self}, field: UserFieldName, wantAllowed: true},
+		{name: "own email", actor: Actor{UserID: self}, field: UserFieldEmail, wantAllowed: true},
+		{name: "own role", actor: Actor{UserID: self}, field: UserFieldRole},
+		{name: "name of another user", actor: Actor{UserID: other}, field: UserFieldName},
+		{name: "email of another user", actor: Actor{UserID: other}, field: UserFieldEmail},
+		{name: "name of another user with users:write", actor: Actor{UserID: other, Permissions: []string{PermissionUsersWrite}}, field: UserFieldName, wantAllowed: true},
+		{name: "role with users:write", actor: Actor{UserID: other, Permissions: []string{PermissionUsersWrite}}, field: UserFieldRole},
+		{name: "role with roles:assign", actor: Actor{UserID: other, Permissions: []string{PermissionRolesAssign}}, field: UserFieldRole, wantAllowed: true},
+		{name: "unknown field", actor: Actor{UserID: self, Permissions: every}, field: "password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.actor.CanUpdateUserField(target, tt.field); got != tt.wantAllowed {
+				t.Errorf("got allowed %v, want %v", got, tt.wantAllowed)
+			}
+		})
+	}
+}
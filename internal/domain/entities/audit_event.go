@@ -0,0 +1,200 @@
+package entities
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions. They are stored as is, so existing values must not change.
+const (
+	AuditActionUserCreated              = "user.created"
+	AuditActionUserRegistered           = "user.registered"
+	AuditActionUserUpdated              = "user.updated"
+	AuditActionUserDeleted              = "user.deleted"
+	AuditActionUserUnlocked             = "user.unlocked"
+	AuditActionUserDisabled             = "user.disabled"
+	AuditActionUserEnabled              = "user.enabled"
+	AuditActionEmailVerified            = "user.email_verified"
+	AuditActionEmailChangeRequested     = "user.email_change_requested"
+	AuditActionEmailChanged             = "user.email_changed"
+	AuditActionPhoneVerificationSent    = "user.phone_verification_sent"
+	AuditActionPhoneVerified            = "user.phone_verified"
+	AuditActionPhoneRemoved             = "user.phone_removed"
+	AuditActionLoginSucceeded           = "auth.login_succeeded"
+	AuditActionLoginFailed              = "auth.login_failed"
+	AuditActionLogoutAll                = "auth.logout_all"
+	AuditActionPasswordResetRequested   = "password_reset.requested"
+	AuditActionPasswordResetCompleted   = "password_reset.completed"
+	AuditActionPasswordChanged          = "password.changed"
+	AuditActionMFAEnabled               = "mfa.enabled"
+	AuditActionMFADisabled              = "mfa.disabled"
+	AuditActionMFAReset                 = "mfa.reset"
+	AuditActionMFARecoveryCodesReplaced = "mfa.recovery_codes_replaced"
+	AuditActionMemberAdded              = "organization.member_added"
+	AuditActionMemberRemoved            = "organization.member_removed"
+)
+
+const AuditTargetUser = "user"
+
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 200
+)
+
+// AuditChange is the value of a field before and after an action.
+type AuditChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// AuditEvent records a security relevant action. Events are append-only:
+// they are never updated or deleted. When hash chaining is on, each event
+// stores the hash of the previous one, so altering or removing an event
+// breaks every hash after it.
+type AuditEvent struct {
+	ID             uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Sequence       int64                  `json:"sequence" gorm:"autoIncrement;uniqueIndex;not null"`
+	Action         string                 `json:"action" gorm:"not null;index"`
+	ActorID        string                 `json:"actor_id" gorm:"index"`
+	TargetType     string                 `json:"target_type"`
+	TargetID       string                 `json:"target_id" gorm:"index"`
+	OrganizationID string                 `json:"organization_id" gorm:"index"`
+	Changes        map[string]AuditChange `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	Metadata       map[string]string      `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
+	IPAddress      string                 `json:"ip_address"`
+	UserAgent      string                 `json:"user_agent"`
+	RequestID      string                 `json:"request_id" gorm:"index"`
+	CreatedAt      time.Time              `json:"created_at" gorm:"not null;index"`
+	PrevHash       string                 `json:"prev_hash,omitempty"`
+	Hash           string                 `json:"hash,omitempty"`
+}
+
+// AuditEventFilter selects audit events, newest first. Cursor is the
+// sequence of the last event of the previous page.
+type AuditEventFilter struct {
+	ActorID  string
+	TargetID string
+	Action   string
+	From     *time.Time
+	To       *time.Time
+	Cursor   int64
+	Limit    int
+}
+
+// NewAuditEvent creates an event about a user. The time is truncated to the
+// precision kept by the database, so the hash can be recomputed from the
+// stored event.
+func NewAuditEvent(action, actorID, targetID string) *AuditEvent {
+	return &AuditEvent{
+		ID:         uuid.New(),
+		Action:     action,
+		ActorID:    actorID,
+		TargetType: AuditTargetUser,
+		TargetID:   targetID,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+// ComputeHash hashes the content of the event together with PrevHash.
+func (e *AuditEvent) ComputeHash() string {
+	content, _ := json.Marshal([]interface{}{
+		e.ID.String(),
+		e.Action,
+		e.ActorID,
+		e.TargetType,
+		e.TargetID,
+		e.OrganizationID,
+		e.Changes,
+		e.Metadata,
+		e.IPAddress,
+		e.UserAgent,
+		e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.PrevHash,
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// DiffUsers lists the fields that differ between two versions of a user. The
+// role compared is the role the user has where it was loaded.
+func DiffUsers(before, after *User) map[string]AuditChange {
+	changes := map[string]AuditChange{}
+	add := func(field, old, new string) {
+		if old != new {
+			changes[field] = AuditChange{Before: old, After: new}
+		}
+	}
+
+	add("name", before.Name, after.Name)
+	add("email", before.Email, after.Email)
+	add("role", before.EffectiveRole(), after.EffectiveRole())
+	add("email_verified", strconv.FormatBool(before.EmailVerified), strconv.FormatBool(after.EmailVerified))
+	add("phone", before.PhoneNumber(), after.PhoneNumber())
+	add("locale", before.Locale, after.Locale)
+	add("mfa_enabled", strconv.FormatBool(before.MFAEnabled), strconv.FormatBool(after.MFAEnabled))
+	add("disabled", strconv.FormatBool(before.IsDisabled()), strconv.FormatBool(after.IsDisabled()))
+	add("must_change_password", strconv.FormatBool(before.MustChangePassword), strconv.FormatBool(after.MustChangePassword))
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func ValidateAuditEventFilter(filter *AuditEventFilter) error {
+	if filter.ActorID != "" {
+		if err := ValidateUUID(filter.ActorID); err != nil {
+			return NewCodedError("invalid_actor_id", "invalid actor_id")
+		}
+	}
+
+	if filter.TargetID != "" {
+		if err := ValidateUUID(filter.TargetID); err != nil {
+			return NewCodedError("invalid_target_id", "invalid target_id")
+		}
+	}
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return NewCodedError("invalid_time_range", "from must be before to")
+	}
+
+	if filter.Cursor < 0 {
+		return NewCodedError("invalid_cursor", "invalid cursor")
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditPageSize
+	}
+	if filter.Limit < 1 || filter.Limit > MaxAuditPageSize {
+		return NewCodedError("invalid_limit", "limit must be between 1 and 200")
+	}
+
+	return nil
+}
+
+// RequestInfo describes the request an action comes from, for audit events.
+type RequestInfo struct {
+	RequestID string
+	IPAddress string
+	UserAgent string
+	ActorID   string
+}
+
+type requestInfoContextKey struct{}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoContextKey{}, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoContextKey{}).(RequestInfo)
+	return info
+}
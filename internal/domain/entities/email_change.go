@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EmailChangeTTL         = time.Hour
+	EmailChangeMaxAttempts = 5
+)
+
+// EmailChange is a pending change of a user's email address. NewEmail only
+// replaces the current address once the code sent to it is confirmed. Only
+// the hash of the code is stored.
+type EmailChange struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	NewEmail  string     `json:"new_email" gorm:"not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	UsedAt    *time.Time `json:"used_at"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NewEmailChange returns the pending change together with the plaintext code
+// to be emailed to the new address.
+func NewEmailChange(userID uuid.UUID, newEmail string) (*EmailChange, string, error) {
+	code, err := generateVerificationCode()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &EmailChange{
+		ID:        uuid.New(),
+		UserID:    userID,
+		NewEmail:  newEmail,
+		CodeHash:  hashOpaqueToken(code),
+		ExpiresAt: time.Now().Add(EmailChangeTTL),
+		CreatedAt: time.Now(),
+	}, code, nil
+}
+
+func (ec *EmailChange) IsExpired() bool {
+	return time.Now().After(ec.ExpiresAt)
+}
+
+// IsValid reports whether the change can still be confirmed. It also becomes
+// invalid once the code was guessed wrong too many times.
+func (ec *EmailChange) IsValid() bool {
+	return ec.UsedAt == nil && !ec.IsExpired() && ec.Attempts < EmailChangeMaxAttempts
+}
+
+func (ec *EmailChange) CheckCode(code string) bool {
+	return subtle.ConstantTimeCompare([]byte(ec.CodeHash), []byte(hashOpaqueToken(strings.TrimSpace(code)))) == 1
+}
+
+// CanResend enforces a minimum interval between confirmation emails.
+func (ec *EmailChange) CanResend() bool {
+	return time.Since(ec.CreatedAt) >= EmailVerificationResendCooldown
+}
@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EmailVerificationTTL            = 24 * time.Hour
+	EmailVerificationResendCooldown = time.Minute
+	EmailVerificationMaxAttempts    = 5
+	// EmailVerificationMaxPerTTL caps the verifications a user gets within
+	// EmailVerificationTTL. Each one brings new attempts at guessing a code,
+	// so this bounds the guesses per account.
+	EmailVerificationMaxPerTTL = 5
+)
+
+// EmailVerification is a pending confirmation of a user's email address. The
+// user proves ownership either by opening the link, which carries Token, or by
+// typing the short Code. Only hashes of both are stored.
+type EmailVerification struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Email     string     `json:"email" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	UsedAt    *time.Time `json:"used_at"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NewEmailVerification returns the verification record together with the
+// plaintext link token and code to be emailed to the user.
+func NewEmailVerification(userID uuid.UUID, email string) (*EmailVerification, string, string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return &EmailVerification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Email:     email,
+		TokenHash: HashEmailVerificationToken(token),
+		CodeHash:  hashOpaqueToken(code),
+		ExpiresAt: time.Now().Add(EmailVerificationTTL),
+		CreatedAt: time.Now(),
+	}, token, code, nil
+}
+
+func HashEmailVerificationToken(token string) string {
+	return hashOpaqueToken(strings.TrimSpace(token))
+}
+
+func (ev *EmailVerification) IsExpired() bool {
+	return time.Now().After(ev.ExpiresAt)
+}
+
+// IsValid reports whether the verification can still be used. It also
+// becomes invalid once the code was guessed wrong too many times.
+func (ev *EmailVerification) IsValid() bool {
+	return ev.UsedAt == nil && !ev.IsExpired() && ev.Attempts < EmailVerificationMaxAttempts
+}
+
+func (ev *EmailVerification) CheckCode(code string) bool {
+	return subtle.ConstantTimeCompare([]byte(ev.CodeHash), []byte(hashOpaqueToken(strings.TrimSpace(code)))) == 1
+}
+
+// CanResend enforces a minimum interval between verification emails.
+func (ev *EmailVerification) CanResend() bool {
+	return time.Since(ev.CreatedAt) >= EmailVerificationResendCooldown
+}
+
+func ValidateVerifyEmailInput(token, email, code string) error {
+	if strings.TrimSpace(token) != "" {
+		return nil
+	}
+
+	if strings.TrimSpace(email) == "" || strings.TrimSpace(code) == "" {
+		return NewCodedError("verification_input_required", "token or email and code are required")
+	}
+
+	return ValidateEmail(email)
+}
+
+func generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,33 @@
+package entities
+
+// CodedError is an error with a stable code clients can rely on. The code also
+// selects the translated message shown to them: Message is the English text,
+// used in logs, and Params fills the placeholders of the translations.
+type CodedError struct {
+	Code    string
+	Message string
+	Params  map[string]string
+}
+
+func NewCodedError(code, message string) *CodedError {
+	return &CodedError{Code: code, Message: message}
+}
+
+func (e *CodedError) Error() string {
+	return e.Message
+}
+
+func (e *CodedError) ErrorCode() string {
+	return e.Code
+}
+
+func (e *CodedError) ErrorParams() map[string]string {
+	return e.Params
+}
+
+// Is matches errors with the same code, so errors.Is works against a sentinel
+// even when the error was built elsewhere.
+func (e *CodedError) Is(target error) bool {
+	other, ok := target.(*CodedError)
+	return ok && other.Code == e.Code
+}
@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"context"
+	"strings"
+)
+
+// Locales the API and its emails are translated to.
+const (
+	LocalePortuguese = "pt-BR"
+	LocaleEnglish    = "en"
+	LocaleSpanish    = "es"
+)
+
+func SupportedLocales() []string {
+	return []string{LocalePortuguese, LocaleEnglish, LocaleSpanish}
+}
+
+// NormalizeLocale returns the supported locale matching a language tag,
+// ignoring case and falling back from a regional variant to its language, so
+// en-US is en and pt is pt-BR.
+func NormalizeLocale(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return "", false
+	}
+
+	for _, locale := range SupportedLocales() {
+		if strings.EqualFold(tag, locale) {
+			return locale, true
+		}
+	}
+
+	language, _, _ := strings.Cut(tag, "-")
+	for _, locale := range SupportedLocales() {
+		localeLanguage, _, _ := strings.Cut(locale, "-")
+		if strings.EqualFold(language, localeLanguage) {
+			return locale, true
+		}
+	}
+
+	return "", false
+}
+
+// ValidateLocale checks a locale chosen by a user, returning it normalized.
+func ValidateLocale(locale string) (string, error) {
+	normalized, ok := NormalizeLocale(locale)
+	if !ok {
+		return "", &CodedError{
+			Code:    "invalid_locale",
+			Message: "locale must be one of " + strings.Join(SupportedLocales(), ", "),
+			Params:  map[string]string{"locales": strings.Join(SupportedLocales(), ", ")},
+		}
+	}
+	return normalized, nil
+}
+
+type localeContextKey struct{}
+
+// WithLocale stores the locale negotiated for a request, in which its
+// responses are written.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// LocaleFromContext returns the locale of the request, or an empty string
+// outside of one.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey{}).(string)
+	return locale
+}
@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginThrottle counts recent failed logins for a key, which identifies
+// either an account (by email) or a source IP address. Failures are forgotten
+// once ExpiresAt passes without new ones.
+type LoginThrottle struct {
+	Key           string    `json:"key" gorm:"primaryKey"`
+	Failures      int       `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time `json:"last_failure_at" gorm:"not null"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// LoginThrottlePolicy decides how long a key must wait after its failures.
+// The first FreeAttempts failures cost nothing, each further one doubles the
+// delay starting at BaseDelay up to MaxDelay, and after MaxFailures the key
+// is locked for LockoutDuration. A zero MaxFailures disables the lock.
+type LoginThrottlePolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	MaxFailures     int
+	LockoutDuration time.Duration
+	FailureWindow   time.Duration
+}
+
+func LoginThrottleAccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func LoginThrottleIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginThrottleMFAKey counts the wrong second factor codes of a user. It is
+// kept apart from the account key, which a correct password resets.
+func LoginThrottleMFAKey(userID string) string {
+	return "mfa:" + userID
+}
+
+// LoginThrottleMFAChallengeKey counts the wrong codes sent with one MFA
+// challenge token.
+func LoginThrottleMFAChallengeKey(tokenID string) string {
+	return "mfa_challenge:" + tokenID
+}
+
+// Retention is how long failures must be kept to enforce the policy.
+func (p LoginThrottlePolicy) Retention() time.Duration {
+	if p.LockoutDuration > p.FailureWindow {
+		return p.LockoutDuration
+	}
+	return p.FailureWindow
+}
+
+func (p LoginThrottlePolicy) IsLocked(throttle *LoginThrottle, now time.Time) bool {
+	if throttle == nil || p.MaxFailures <= 0 || throttle.Failures < p.MaxFailures {
+		return false
+	}
+	return now.Before(throttle.LastFailureAt.Add(p.LockoutDuration))
+}
+
+// RetryAfter returns how long the key must wait before its next attempt, or
+// zero when it may try now.
+func (p LoginThrottlePolicy) RetryAfter(throttle *LoginThrottle, now time.Time) time.Duration {
+	if throttle == nil || !now.Before(throttle.ExpiresAt) {
+		return 0
+	}
+
+	var until time.Time
+	switch {
+	case p.IsLocked(throttle, now):
+		until = throttle.LastFailureAt.Add(p.LockoutDuration)
+	case throttle.Failures > p.FreeAttempts:
+		delay := p.BaseDelay
+		for i := p.FreeAttempts + 1; i < throttle.Failures && delay < p.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+		until = throttle.LastFailureAt.Add(delay)
+	default:
+		return 0
+	}
+
+	if wait := until.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
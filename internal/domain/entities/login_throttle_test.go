@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginThrottlePolicyRetryAfter(t *testing.T) {
+	policy := LoginThrottlePolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		MaxFailures:     10,
+		LockoutDuration: 15 * time.Minute,
+		FailureWindow:   time.Hour,
+	}
+	lastFailure := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		throttle *LoginThrottle
+		now      time.Time
+		want     time.Duration
+	}{
+		{name: "no failures", now: lastFailure},
+		{name: "free attempts", throttle: throttleWith(3, lastFailure), now: lastFailure},
+		{name: "first delayed attempt", throttle: throttleWith(4, lastFailure), now: lastFailure, want: time.Second},
+		{name: "delay doubles", throttle: throttleWith(5, lastFailure), now: lastFailure, want: 2 * time.Second},
+		{name: "delay doubles again", throttle: throttleWith(6, lastFailure), now: lastFailure, want: 4 * time.Second},
+		{name: "delay is capped", throttle: throttleWith(9, lastFailure), now: lastFailure, want: 10 * time.Second},
+		{name: "delay already waited for", throttle: throttleWith(5, lastFailure), now: lastFailure.Add(3 * time.Second)},
+		{name: "part of the delay waited for", throttle: throttleWith(6, lastFailure), now: lastFailure.Add(time.Second), want: 3 * time.Second},
+		{name: "locked", throttle: throttleWith(10, lastFailure), now: lastFailure, want: 15 * time.Minute},
+		{name: "lock running out", throttle: throttleWith(10, lastFailure), now: lastFailure.Add(14 * time.Minute), want: time.Minute},
+		{name: "lock over", throttle: throttleWith(10, lastFailure), now: lastFailure.Add(15 * time.Minute), want: 0},
+		{name: "failures expired", throttle: throttleWith(10, lastFailure), now: lastFailure.Add(2 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RetryAfter(tt.throttle, tt.now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePolicyIsLocked(t *testing.T) {
+	lastFailure := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := LoginThrottlePolicy{MaxFailures: 5, LockoutDuration: time.Hour, FailureWindow: time.Hour}
+
+	tests := []struct {
+		name     string
+		policy   LoginThrottlePolicy
+		throttle *LoginThrottle
+		now      time.Time
+		want     bool
+	}{
+		{name: "no failures", policy: policy, now: lastFailure},
+		{name: "below the limit", policy: policy, throttle: throttleWith(4, lastFailure), now: lastFailure},
+		{name: "at the limit", policy: policy, throttle: throttleWith(5, lastFailure), now: lastFailure, want: true},
+		{name: "over the limit", policy: policy, throttle: throttleWith(8, lastFailure), now: lastFailure.Add(59 * time.Minute), want: true},
+		{name: "lockout over", policy: policy, throttle: throttleWith(5, lastFailure), now: lastFailure.Add(time.Hour)},
+		{name: "lock disabled", policy: LoginThrottlePolicy{LockoutDuration: time.Hour}, throttle: throttleWith(100, lastFailure), now: lastFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.IsLocked(tt.throttle, tt.now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePolicyRetention(t *testing.T) {
+	policy := LoginThrottlePolicy{LockoutDuration: time.Hour, FailureWindow: 15 * time.Minute}
+	if got := policy.Retention(); got != time.Hour {
+		t.Errorf("got %v, want the lockout duration", got)
+	}
+
+	policy = LoginThrottlePolicy{LockoutDuration: time.Minute, FailureWindow: 15 * time.Minute}
+	if got := policy.Retention(); got != 15*time.Minute {
+		t.Errorf("got %v, want the failure window", got)
+	}
+}
+
+func TestLoginThrottleAccountKeyIgnoresCaseAndSpaces(t *testing.T) {
+	if LoginThrottleAccountKey(" User@Example.com ") != LoginThrottleAccountKey("user@example.com") {
+		t.Error("the same email got different keys")
+	}
+}
+
+func throttleWith(failures int, lastFailure time.Time) *LoginThrottle {
+	return &LoginThrottle{
+		Failures:      failures,
+		LastFailureAt: lastFailure,
+		ExpiresAt:     lastFailure.Add(time.Hour),
+	}
+}
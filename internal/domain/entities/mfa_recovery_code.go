@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MFARecoveryCodeCount  = 10
+	mfaRecoveryCodeLength = 10
+	mfaRecoveryCodeChars  = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NewMFARecoveryCodes generates a fresh set of one-time recovery codes and
+// returns them together with the plaintext values, which are shown to the user
+// once and never stored.
+func NewMFARecoveryCodes(userID uuid.UUID) ([]*MFARecoveryCode, []string, error) {
+	codes := make([]*MFARecoveryCode, 0, MFARecoveryCodeCount)
+	plain := make([]string, 0, MFARecoveryCodeCount)
+
+	for i := 0; i < MFARecoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, &MFARecoveryCode{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: HashMFARecoveryCode(code),
+		})
+		plain = append(plain, code)
+	}
+
+	return codes, plain, nil
+}
+
+func HashMFARecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (rc *MFARecoveryCode) IsUsed() bool {
+	return rc.UsedAt != nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func generateRecoveryCode() (string, error) {
+	// rand.Int draws each character uniformly; reducing a random byte modulo
+	// the alphabet size would favour its first characters.
+	alphabetSize := big.NewInt(int64(len(mfaRecoveryCodeChars)))
+	code := make([]byte, mfaRecoveryCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = mfaRecoveryCodeChars[n.Int64()]
+	}
+
+	half := mfaRecoveryCodeLength / 2
+	return string(code[:half]) + "-" + string(code[half:]), nil
+}
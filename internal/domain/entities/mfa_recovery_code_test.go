@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewMFARecoveryCodes(t *testing.T) {
+	userID := uuid.New()
+
+	codes, plain, err := NewMFARecoveryCodes(userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != MFARecoveryCodeCount || len(plain) != MFARecoveryCodeCount {
+		t.Fatalf("got %d codes and %d plain values, want %d", len(codes), len(plain), MFARecoveryCodeCount)
+	}
+
+	seen := map[string]bool{}
+	for i, code := range plain {
+		if len(code) != mfaRecoveryCodeLength+1 || code[mfaRecoveryCodeLength/2] != '-' {
+			t.Errorf("code %q is not two halves joined by a dash", code)
+		}
+		for _, char := range strings.ReplaceAll(code, "-", "") {
+			if !strings.ContainsRune(mfaRecoveryCodeChars, char) {
+				t.Errorf("code %q has %q, which is not in the alphabet", code, char)
+			}
+		}
+		if seen[code] {
+			t.Errorf("code %q was generated twice", code)
+		}
+		seen[code] = true
+
+		if codes[i].UserID != userID {
+			t.Errorf("code %d belongs to %s, want %s", i, codes[i].UserID, userID)
+		}
+		if codes[i].CodeHash != HashMFARecoveryCode(code) {
+			t.Errorf("code %d is stored with another hash", i)
+		}
+		if codes[i].CodeHash == code || codes[i].IsUsed() {
+			t.Errorf("code %d is stored in plain text or already used", i)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodeUsesWholeAlphabet(t *testing.T) {
+	seen := map[rune]bool{}
+	for i := 0; i < 200; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, char := range code {
+			seen[char] = true
+		}
+	}
+
+	for _, char := range mfaRecoveryCodeChars {
+		if !seen[char] {
+			t.Errorf("%q never appeared in 2000 characters", char)
+		}
+	}
+}
+
+func TestHashMFARecoveryCodeNormalizesInput(t *testing.T) {
+	want := HashMFARecoveryCode("abcde-fghjk")
+
+	for _, code := range []string{"ABCDE-FGHJK", "abcdefghjk", " abcde fghjk ", "abcde-fghjk\n"} {
+		if got := HashMFARecoveryCode(code); got != want {
+			t.Errorf("%q hashed differently from abcde-fghjk", code)
+		}
+	}
+	if HashMFARecoveryCode("abcde-fghjm") == want {
+		t.Error("different codes have the same hash")
+	}
+}
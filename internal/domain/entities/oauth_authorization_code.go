@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuthorizationCodeTTL    = 10 * time.Minute
+	CodeChallengeMethodS256 = "S256"
+)
+
+type OAuthAuthorizationCode struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CodeHash            string     `json:"-" gorm:"not null;uniqueIndex"`
+	ClientID            string     `json:"client_id" gorm:"not null;index"`
+	UserID              uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	RedirectURI         string     `json:"redirect_uri" gorm:"not null"`
+	Scope               string     `json:"scope"`
+	CodeChallenge       string     `json:"-"`
+	CodeChallengeMethod string     `json:"-"`
+	Nonce               string     `json:"-"`
+	AuthTime            time.Time  `json:"auth_time" gorm:"not null"`
+	Used                bool       `json:"used" gorm:"default:false"`
+	TokenFamilyID       *uuid.UUID `json:"-" gorm:"type:uuid"`
+	ExpiresAt           time.Time  `json:"expires_at" gorm:"not null"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func NewOAuthAuthorizationCode(clientID string, userID uuid.UUID, redirectURI, scope, codeChallenge, codeChallengeMethod, nonce string) (*OAuthAuthorizationCode, string, error) {
+	if err := ValidateCodeChallenge(codeChallenge, codeChallengeMethod); err != nil {
+		return nil, "", err
+	}
+
+	if len(nonce) > 255 {
+		return nil, "", NewCodedError("nonce_too_long", "nonce is too long (maximum 255 characters)")
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &OAuthAuthorizationCode{
+		ID:                  uuid.New(),
+		CodeHash:            HashAuthorizationCode(code),
+		ClientID:            clientID,
+		UserID:              userID,
+		RedirectURI:         redirectURI,
+		Scope:               scope,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		Nonce:               nonce,
+		AuthTime:            time.Now(),
+		Used:                false,
+		ExpiresAt:           time.Now().Add(AuthorizationCodeTTL),
+	}, code, nil
+}
+
+func HashAuthorizationCode(code string) string {
+	return hashOpaqueToken(code)
+}
+
+func ValidateCodeChallenge(codeChallenge, codeChallengeMethod string) error {
+	if codeChallenge == "" {
+		if codeChallengeMethod != "" {
+			return NewCodedError("code_challenge_required", "code_challenge is required")
+		}
+		return nil
+	}
+
+	if codeChallengeMethod != CodeChallengeMethodS256 {
+		return NewCodedError("invalid_code_challenge_method", "code_challenge_method must be S256")
+	}
+
+	if len(codeChallenge) != 43 {
+		return NewCodedError("invalid_code_challenge", "invalid code_challenge")
+	}
+
+	return nil
+}
+
+func (ac *OAuthAuthorizationCode) IsExpired() bool {
+	return time.Now().After(ac.ExpiresAt)
+}
+
+func (ac *OAuthAuthorizationCode) HasCodeChallenge() bool {
+	return ac.CodeChallenge != ""
+}
+
+// VerifyCodeVerifier checks the PKCE verifier (RFC 7636) against the
+// challenge sent to the authorization endpoint.
+func (ac *OAuthAuthorizationCode) VerifyCodeVerifier(verifier string) bool {
+	if !ac.HasCodeChallenge() {
+		return verifier == ""
+	}
+
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(ac.CodeChallenge)) == 1
+}
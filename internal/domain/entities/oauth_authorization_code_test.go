@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+)
+
+// The verifier and challenge of RFC 7636, appendix B.
+const (
+	rfc7636Verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfc7636Challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestValidateCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		method    string
+		wantCode  string
+	}{
+		{name: "no PKCE"},
+		{name: "S256", challenge: rfc7636Challenge, method: CodeChallengeMethodS256},
+		{name: "method without challenge", method: CodeChallengeMethodS256, wantCode: "code_challenge_required"},
+		{name: "plain method", challenge: rfc7636Challenge, method: "plain", wantCode: "invalid_code_challenge_method"},
+		{name: "missing method", challenge: rfc7636Challenge, wantCode: "invalid_code_challenge_method"},
+		{name: "short challenge", challenge: rfc7636Challenge[:42], method: CodeChallengeMethodS256, wantCode: "invalid_code_challenge"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCodeChallenge(tt.challenge, tt.method)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			codedErr, ok := err.(*CodedError)
+			if !ok || codedErr.Code != tt.wantCode {
+				t.Errorf("got error %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestVerifyCodeVerifierS256(t *testing.T) {
+	code := &OAuthAuthorizationCode{CodeChallenge: rfc7636Challenge, CodeChallengeMethod: CodeChallengeMethodS256}
+
+	tests := []struct {
+		name     string
+		verifier string
+		want     bool
+	}{
+		{name: "RFC 7636 verifier", verifier: rfc7636Verifier, want: true},
+		{name: "missing verifier"},
+		{name: "challenge sent as verifier", verifier: rfc7636Challenge},
+		{name: "other verifier", verifier: strings.ToUpper(rfc7636Verifier)},
+		{name: "short verifier", verifier: rfc7636Verifier[:42]},
+		{name: "long verifier", verifier: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := code.VerifyCodeVerifier(tt.verifier); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyCodeVerifierWithoutChallenge(t *testing.T) {
+	code := &OAuthAuthorizationCode{}
+
+	if !code.VerifyCodeVerifier("") {
+		t.Error("a code without challenge rejected an empty verifier")
+	}
+	if code.VerifyCodeVerifier(rfc7636Verifier) {
+		t.Error("a code without challenge accepted a verifier")
+	}
+}
@@ -0,0 +1,209 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OAuthClient is an application allowed to obtain tokens from the
+// authorization server. Redirect URIs, grant types and scopes are stored as
+// space separated lists, the same format OAuth uses for scopes on the wire.
+type OAuthClient struct {
+	ID                     uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ClientID               string    `json:"client_id" gorm:"not null;uniqueIndex"`
+	SecretHash             string    `json:"-"`
+	Name                   string    `json:"name" gorm:"not null"`
+	RedirectURIs           string    `json:"redirect_uris" gorm:"type:text;not null;default:''"`
+	PostLogoutRedirectURIs string    `json:"post_logout_redirect_uris" gorm:"type:text;not null;default:''"`
+	GrantTypes             string    `json:"grant_types" gorm:"not null"`
+	Scopes                 string    `json:"scopes" gorm:"not null;default:''"`
+	Confidential           bool      `json:"confidential" gorm:"not null;default:false"`
+	CreatedAt              time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt              time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// NewOAuthClient registers a client and, for confidential clients, returns the
+// generated secret. Only its hash is stored, so it cannot be shown again.
+func NewOAuthClient(name string, redirectURIs, postLogoutRedirectURIs, grantTypes, scopes []string, confidential bool) (*OAuthClient, string, error) {
+	if err := ValidateOAuthClientData(name, redirectURIs, grantTypes, confidential); err != nil {
+		return nil, "", err
+	}
+
+	if err := validateRedirectURIs(postLogoutRedirectURIs); err != nil {
+		return nil, "", err
+	}
+
+	clientID, err := randomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := &OAuthClient{
+		ID:                     uuid.New(),
+		ClientID:               clientID,
+		Name:                   name,
+		RedirectURIs:           strings.Join(redirectURIs, " "),
+		PostLogoutRedirectURIs: strings.Join(postLogoutRedirectURIs, " "),
+		GrantTypes:             strings.Join(grantTypes, " "),
+		Scopes:                 strings.Join(scopes, " "),
+		Confidential:           confidential,
+	}
+
+	if !confidential {
+		return client, "", nil
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	client.SecretHash = hashOpaqueToken(secret)
+
+	return client, secret, nil
+}
+
+func ValidateOAuthClientData(name string, redirectURIs, grantTypes []string, confidential bool) error {
+	if strings.TrimSpace(name) == "" {
+		return NewCodedError("name_required", "name is required")
+	}
+
+	if len(name) > 100 {
+		return NewCodedError("name_too_long", "name must be at most 100 characters")
+	}
+
+	if len(grantTypes) == 0 {
+		return NewCodedError("grant_types_required", "at least one grant type is required")
+	}
+
+	for _, grantType := range grantTypes {
+		switch grantType {
+		case GrantTypeAuthorizationCode, GrantTypeRefreshToken:
+		case GrantTypeClientCredentials:
+			if !confidential {
+				return NewCodedError("client_credentials_requires_confidential_client", "client_credentials requires a confidential client")
+			}
+		default:
+			return &CodedError{
+				Code:    "unsupported_grant_type",
+				Message: "unsupported grant type: " + grantType,
+				Params:  map[string]string{"grant_type": grantType},
+			}
+		}
+	}
+
+	if containsString(grantTypes, GrantTypeAuthorizationCode) && len(redirectURIs) == 0 {
+		return NewCodedError("redirect_uris_required", "at least one redirect uri is required for authorization_code")
+	}
+
+	return validateRedirectURIs(redirectURIs)
+}
+
+func validateRedirectURIs(redirectURIs []string) error {
+	for _, redirectURI := range redirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Fragment != "" {
+			return &CodedError{
+				Code:    "invalid_redirect_uri",
+				Message: "invalid redirect uri: " + redirectURI,
+				Params:  map[string]string{"redirect_uri": redirectURI},
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
+
+func (c *OAuthClient) PostLogoutRedirectURIList() []string {
+	return strings.Fields(c.PostLogoutRedirectURIs)
+}
+
+func (c *OAuthClient) GrantTypeList() []string {
+	return strings.Fields(c.GrantTypes)
+}
+
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+func (c *OAuthClient) AllowsGrantType(grantType string) bool {
+	return containsString(c.GrantTypeList(), grantType)
+}
+
+// AllowsRedirectURI requires an exact match with a registered URI, as
+// recommended by the OAuth 2.0 security best current practice.
+func (c *OAuthClient) AllowsRedirectURI(redirectURI string) bool {
+	return containsString(c.RedirectURIList(), redirectURI)
+}
+
+func (c *OAuthClient) AllowsPostLogoutRedirectURI(redirectURI string) bool {
+	return containsString(c.PostLogoutRedirectURIList(), redirectURI)
+}
+
+// ResolveScope returns the requested scope restricted to the scopes the client
+// was registered with. An empty request means every registered scope.
+func (c *OAuthClient) ResolveScope(requested string) (string, error) {
+	allowed := c.ScopeList()
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(allowed, " "), nil
+	}
+
+	scopes := strings.Fields(requested)
+	for _, scope := range scopes {
+		if !containsString(allowed, scope) {
+			return "", &CodedError{
+				Code:    "scope_not_allowed",
+				Message: "scope not allowed: " + scope,
+				Params:  map[string]string{"scope": scope},
+			}
+		}
+	}
+
+	return strings.Join(scopes, " "), nil
+}
+
+func (c *OAuthClient) CheckSecret(secret string) bool {
+	if !c.Confidential || c.SecretHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(c.SecretHash), []byte(hashOpaqueToken(secret))) == 1
+}
+
+func randomToken(size int) (string, error) {
+	raw := make([]byte, size)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package entities
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var organizationSlugRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Organization is a tenant. Users are global identities that belong to
+// organizations through memberships, each with its own role.
+type Organization struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"not null"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Membership grants a user access to an organization with the given role.
+// Within the organization the membership role replaces the user's global role.
+type Membership struct {
+	ID             uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID     `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_memberships_organization_user"`
+	UserID         uuid.UUID     `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_memberships_organization_user;index"`
+	Role           string        `json:"role" gorm:"not null;default:'user'"`
+	Organization   *Organization `json:"organization,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User           *User         `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func ValidateOrganizationSlug(slug string) error {
+	if strings.TrimSpace(slug) == "" {
+		return NewCodedError("organization_slug_required", "organization slug is required")
+	}
+
+	if !organizationSlugRegex.MatchString(slug) {
+		return NewCodedError("invalid_organization_slug", "organization slug must be up to 63 lowercase letters, digits or '-', not starting or ending with '-'")
+	}
+
+	return nil
+}
+
+func NewOrganization(name, slug string) (*Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, NewCodedError("organization_name_required", "organization name is required")
+	}
+
+	if err := ValidateOrganizationSlug(slug); err != nil {
+		return nil, err
+	}
+
+	return &Organization{
+		ID:   uuid.New(),
+		Name: name,
+		Slug: slug,
+	}, nil
+}
+
+// NewMembership adds a user to an organization. The super_admin role is a
+// platform role and cannot be granted within an organization.
+func NewMembership(organizationID, userID uuid.UUID, role string) (*Membership, error) {
+	if err := ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	if role == RoleSuperAdmin {
+		return nil, NewCodedError("super_admin_in_organization", "the super_admin role cannot be granted within an organization")
+	}
+
+	return &Membership{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+	}, nil
+}
+
+type organizationContextKey struct{}
+
+// WithOrganizationID scopes the repositories called with the returned context
+// to the given organization. An empty id removes the scope.
+func WithOrganizationID(ctx context.Context, organizationID string) context.Context {
+	return context.WithValue(ctx, organizationContextKey{}, organizationID)
+}
+
+// OrganizationIDFromContext returns the organization the context is scoped
+// to, or an empty string for platform-wide access.
+func OrganizationIDFromContext(ctx context.Context) string {
+	organizationID, _ := ctx.Value(organizationContextKey{}).(string)
+	return organizationID
+}
@@ -0,0 +1,120 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OutgoingEmailPending = "pending"
+	OutgoingEmailSent    = "sent"
+	OutgoingEmailDead    = "dead"
+)
+
+// Email templates. Each one is a pair of files, NAME.txt and NAME.html, in
+// the directory of each locale.
+const (
+	EmailTemplatePasswordReset     = "password_reset"
+	EmailTemplateWelcome           = "welcome"
+	EmailTemplateEmailVerification = "email_verification"
+	EmailTemplateAccountLocked     = "account_locked"
+	EmailTemplatePasswordChanged   = "password_changed"
+	EmailTemplateEmailChangeCode   = "email_change_code"
+	EmailTemplateEmailChangeNotice = "email_change_notice"
+	EmailTemplatePhoneChanged      = "phone_changed"
+)
+
+// EmailTemplateNames lists the templates every locale may provide. The
+// default locale must provide all of them.
+func EmailTemplateNames() []string {
+	return []string{
+		EmailTemplatePasswordReset,
+		EmailTemplateWelcome,
+		EmailTemplateEmailVerification,
+		EmailTemplateAccountLocked,
+		EmailTemplatePasswordChanged,
+		EmailTemplateEmailChangeCode,
+		EmailTemplateEmailChangeNotice,
+		EmailTemplatePhoneChanged,
+	}
+}
+
+// OutgoingEmail is a rendered email in the outbox. Failed sends are retried
+// until they succeed or run out of attempts. Bodies may carry codes, so they
+// are cleared once the email is sent or dead-lettered.
+type OutgoingEmail struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Template      string     `json:"template" gorm:"not null"`
+	Recipient     string     `json:"recipient" gorm:"not null"`
+	Subject       string     `json:"subject" gorm:"not null"`
+	TextBody      string     `json:"-" gorm:"not null"`
+	HTMLBody      string     `json:"-" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null;default:'pending';index"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// EmailRetryPolicy spaces the attempts of an email like WebhookRetryPolicy:
+// each failure doubles the wait, starting at BaseDelay up to MaxDelay, and
+// after MaxAttempts failures the email is dead-lettered.
+type EmailRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NewOutgoingEmail queues a rendered email to be sent right away.
+func NewOutgoingEmail(template, recipient, subject, textBody, htmlBody string) (*OutgoingEmail, error) {
+	if strings.TrimSpace(recipient) == "" {
+		return nil, errors.New("recipient is required")
+	}
+	if strings.ContainsAny(recipient+subject, "\r\n") {
+		return nil, errors.New("recipient and subject must be a single line")
+	}
+
+	return &OutgoingEmail{
+		ID:            uuid.New(),
+		Template:      template,
+		Recipient:     recipient,
+		Subject:       subject,
+		TextBody:      textBody,
+		HTMLBody:      htmlBody,
+		Status:        OutgoingEmailPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// RecordAttempt updates the email with the outcome of a send. An empty
+// errMessage is a success.
+func (e *OutgoingEmail) RecordAttempt(errMessage string, policy EmailRetryPolicy, now time.Time) {
+	e.Attempts++
+	e.LastAttemptAt = &now
+	e.LastError = errMessage
+
+	switch {
+	case errMessage == "":
+		e.Status = OutgoingEmailSent
+		e.SentAt = &now
+	case e.Attempts >= policy.MaxAttempts:
+		e.Status = OutgoingEmailDead
+	default:
+		e.NextAttemptAt = now.Add(policy.Delay(e.Attempts))
+		return
+	}
+
+	e.TextBody = ""
+	e.HTMLBody = ""
+}
+
+// Delay returns how long to wait after the given number of failed attempts.
+func (p EmailRetryPolicy) Delay(failures int) time.Duration {
+	return backoffDelay(p.BaseDelay, p.MaxDelay, failures)
+}
@@ -0,0 +1,10 @@
+package entities
+
+// PasswordHasher turns passwords into self-describing hashes, so a hash
+// produced under an older algorithm or parameters can still be verified and
+// then upgraded.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encodedHash string) (bool, error)
+	NeedsRehash(encodedHash string) bool
+}
@@ -0,0 +1,200 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	PasswordViolationTooShort          = "too_short"
+	PasswordViolationTooLong           = "too_long"
+	PasswordViolationMissingUpper      = "missing_uppercase"
+	PasswordViolationMissingLower      = "missing_lowercase"
+	PasswordViolationMissingDigit      = "missing_digit"
+	PasswordViolationMissingSymbol     = "missing_symbol"
+	PasswordViolationRepeatedChars     = "repeated_characters"
+	PasswordViolationPersonalInfo      = "contains_personal_info"
+	PasswordViolationTooWeak           = "too_weak"
+	PasswordViolationBreached          = "breached"
+	PasswordViolationBreachCheckFailed = "breach_check_failed"
+)
+
+// BreachedPasswordChecker tells whether a password appears in a list of
+// passwords exposed in data breaches.
+type BreachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// PasswordPolicy holds the rules a new password must follow and how long it
+// can be used. Zero values disable the corresponding rule, except for the
+// length limits.
+type PasswordPolicy struct {
+	MinLength          int
+	MaxLength          int
+	RequireUppercase   bool
+	RequireLowercase   bool
+	RequireDigit       bool
+	RequireSymbol      bool
+	MaxRepeatedChars   int
+	RejectPersonalInfo bool
+	MinStrengthScore   int
+	BreachChecker      BreachedPasswordChecker
+	MaxAge             time.Duration
+}
+
+// PasswordPolicyViolation is a broken rule. Params fill the placeholders of
+// the translated message, whose code is "password_" followed by Code.
+type PasswordPolicyViolation struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Params  map[string]string `json:"-"`
+}
+
+// PasswordPolicyError lists every rule the password broke, so the client can
+// show them all at once instead of one per attempt.
+type PasswordPolicyError struct {
+	Violations []PasswordPolicyViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return "password does not meet the requirements: " + strings.Join(messages, "; ")
+}
+
+func (e *PasswordPolicyError) ErrorCode() string {
+	return "password_policy"
+}
+
+func (e *PasswordPolicyError) ErrorParams() map[string]string {
+	return nil
+}
+
+// BasicPasswordPolicy only enforces the length limits of ValidatePassword.
+func BasicPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: 6,
+		MaxLength: 128,
+	}
+}
+
+// Validate checks the password against the policy. personalInfo holds values
+// the password must not contain, like the user's name and email address.
+func (p *PasswordPolicy) Validate(password string, personalInfo ...string) error {
+	var violations []PasswordPolicyViolation
+	add := func(code, message string, params ...string) {
+		violation := PasswordPolicyViolation{Code: code, Message: message}
+		if len(params) == 2 {
+			violation.Params = map[string]string{params[0]: params[1]}
+		}
+		violations = append(violations, violation)
+	}
+
+	length := len([]rune(password))
+	if strings.TrimSpace(password) == "" || length < p.MinLength {
+		add(PasswordViolationTooShort, fmt.Sprintf("password must be at least %d characters", p.MinLength), "min", strconv.Itoa(p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(PasswordViolationTooLong, fmt.Sprintf("password is too long (maximum %d characters)", p.MaxLength), "max", strconv.Itoa(p.MaxLength))
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case !unicode.IsSpace(char):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		add(PasswordViolationMissingUpper, "password must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		add(PasswordViolationMissingLower, "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(PasswordViolationMissingDigit, "password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(PasswordViolationMissingSymbol, "password must contain a symbol")
+	}
+
+	if p.MaxRepeatedChars > 0 && longestRun(password) > p.MaxRepeatedChars {
+		add(PasswordViolationRepeatedChars, fmt.Sprintf("password must not repeat the same character more than %d times in a row", p.MaxRepeatedChars), "max", strconv.Itoa(p.MaxRepeatedChars))
+	}
+
+	if p.RejectPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		add(PasswordViolationPersonalInfo, "password must not contain your name or email")
+	}
+
+	if p.MinStrengthScore > 0 {
+		if strength := EstimatePasswordStrength(password, personalInfo...); strength.Score < p.MinStrengthScore {
+			add(PasswordViolationTooWeak, "password is too easy to guess")
+		}
+	}
+
+	if p.BreachChecker != nil && len(violations) == 0 {
+		breached, err := p.BreachChecker.IsBreached(password)
+		if err != nil {
+			add(PasswordViolationBreachCheckFailed, "password could not be checked against known breaches")
+		} else if breached {
+			add(PasswordViolationBreached, "password has appeared in a data breach, choose a different one")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func longestRun(password string) int {
+	longest, current := 0, 0
+	var previous rune
+	for i, char := range []rune(password) {
+		if i > 0 && char == previous {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+		previous = char
+	}
+	return longest
+}
+
+// containsPersonalInfo looks for the words of the name and for the local part
+// of email addresses inside the password, ignoring case. Very short values
+// are skipped because they would match too many passwords.
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	lowered := strings.ToLower(password)
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if at := strings.Index(info, "@"); at >= 0 {
+			info = info[:at]
+		}
+
+		for _, token := range strings.FieldsFunc(info, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len([]rune(token)) >= 3 && strings.Contains(lowered, token) {
+				return true
+			}
+		}
+	}
+	return false
+}
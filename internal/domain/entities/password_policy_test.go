@@ -0,0 +1,165 @@
+package entities
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stubBreachChecker reports the passwords it lists as breached, or fails
+// every check with err.
+type stubBreachChecker struct {
+	breached []string
+	err      error
+	checks   int
+}
+
+func (c *stubBreachChecker) IsBreached(password string) (bool, error) {
+	c.checks++
+	if c.err != nil {
+		return false, c.err
+	}
+	return slices.Contains(c.breached, password), nil
+}
+
+// violationCodes returns the codes of the violations in err, or nil when the
+// password was accepted.
+func violationCodes(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("got error %v, want a PasswordPolicyError", err)
+	}
+	codes := make([]string, 0, len(policyErr.Violations))
+	for _, violation := range policyErr.Violations {
+		codes = append(codes, violation.Code)
+	}
+	return codes
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:          8,
+		MaxLength:          64,
+		RequireUppercase:   true,
+		RequireLowercase:   true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		MaxRepeatedChars:   3,
+		RejectPersonalInfo: true,
+	}
+	personalInfo := []string{"Maria Souza", "maria.souza@example.com"}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "valid", password: "Tr0ub4dor&3x"},
+		{name: "empty", password: "", want: []string{PasswordViolationTooShort, PasswordViolationMissingUpper, PasswordViolationMissingLower, PasswordViolationMissingDigit, PasswordViolationMissingSymbol}},
+		{name: "only spaces", password: "   ", want: []string{PasswordViolationTooShort, PasswordViolationMissingUpper, PasswordViolationMissingLower, PasswordViolationMissingDigit, PasswordViolationMissingSymbol}},
+		{name: "too short", password: "Tr0u&b", want: []string{PasswordViolationTooShort}},
+		{name: "too long stops the checks", password: strings.Repeat("a", 65), want: []string{PasswordViolationTooLong}},
+		{name: "length counts characters, not bytes", password: "Çãõ1&çãõ"},
+		{name: "missing uppercase", password: "tr0ub4dor&3x", want: []string{PasswordViolationMissingUpper}},
+		{name: "missing lowercase", password: "TR0UB4DOR&3X", want: []string{PasswordViolationMissingLower}},
+		{name: "missing digit", password: "Troubador&x", want: []string{PasswordViolationMissingDigit}},
+		{name: "missing symbol", password: "Tr0ub4dor3x", want: []string{PasswordViolationMissingSymbol}},
+		{name: "repeated characters", password: "Tr0ub4dor&3xxxx", want: []string{PasswordViolationRepeatedChars}},
+		{name: "repeated characters at the limit", password: "Tr0ub4dor&3xxx"},
+		{name: "contains the name", password: "Souza&2024xY", want: []string{PasswordViolationPersonalInfo}},
+		{name: "contains the email local part in another case", password: "MARIA.souza&1", want: []string{PasswordViolationPersonalInfo}},
+		{name: "every violation at once", password: "souzaaaa", want: []string{PasswordViolationMissingUpper, PasswordViolationMissingDigit, PasswordViolationMissingSymbol, PasswordViolationRepeatedChars, PasswordViolationPersonalInfo}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationCodes(t, policy.Validate(tt.password, personalInfo...))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got violations %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyPersonalInfoSkipsShortValues(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 8, RejectPersonalInfo: true}
+
+	if err := policy.Validate("Tr0ub4dor&3x", "Al Bo", "jo@example.com"); err != nil {
+		t.Errorf("got %v, want names shorter than 3 characters ignored", err)
+	}
+}
+
+func TestPasswordPolicyViolationParams(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 8, MaxLength: 10, MaxRepeatedChars: 2}
+
+	tests := []struct {
+		name     string
+		password string
+		code     string
+		params   map[string]string
+	}{
+		{name: "too short", password: "abc", code: PasswordViolationTooShort, params: map[string]string{"min": "8"}},
+		{name: "too long", password: "abcdefghijk", code: PasswordViolationTooLong, params: map[string]string{"max": "10"}},
+		{name: "repeated characters", password: "abcddd123", code: PasswordViolationRepeatedChars, params: map[string]string{"max": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var policyErr *PasswordPolicyError
+			if !errors.As(policy.Validate(tt.password), &policyErr) || len(policyErr.Violations) != 1 {
+				t.Fatalf("got %v, want a single violation", policyErr)
+			}
+			violation := policyErr.Violations[0]
+			if violation.Code != tt.code || !maps.Equal(violation.Params, tt.params) {
+				t.Errorf("got %s with %v, want %s with %v", violation.Code, violation.Params, tt.code, tt.params)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyBreachCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		checker    *stubBreachChecker
+		want       []string
+		wantChecks int
+	}{
+		{name: "not breached", password: "Tr0ub4dor&3x", checker: &stubBreachChecker{}, wantChecks: 1},
+		{name: "breached", password: "Tr0ub4dor&3x", checker: &stubBreachChecker{breached: []string{"Tr0ub4dor&3x"}}, want: []string{PasswordViolationBreached}, wantChecks: 1},
+		{name: "check failed", password: "Tr0ub4dor&3x", checker: &stubBreachChecker{err: errors.New("service unavailable")}, want: []string{PasswordViolationBreachCheckFailed}, wantChecks: 1},
+		{name: "not checked when other rules fail", password: "short", checker: &stubBreachChecker{breached: []string{"short"}}, want: []string{PasswordViolationTooShort}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &PasswordPolicy{MinLength: 8, BreachChecker: tt.checker}
+
+			got := violationCodes(t, policy.Validate(tt.password))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got violations %v, want %v", got, tt.want)
+			}
+			if tt.checker.checks != tt.wantChecks {
+				t.Errorf("got %d breach checks, want %d", tt.checker.checks, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyMinStrengthScore(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 8, MinStrengthScore: 3}
+
+	if got := violationCodes(t, policy.Validate("password123")); !slices.Equal(got, []string{PasswordViolationTooWeak}) {
+		t.Errorf("got violations %v for a common password, want it too weak", got)
+	}
+	if got := violationCodes(t, policy.Validate("correct horse battery staple")); got != nil {
+		t.Errorf("got violations %v for a long passphrase, want it accepted", got)
+	}
+}
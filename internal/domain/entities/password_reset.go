@@ -1,101 +1,128 @@
 package entities
 
 import (
-	"crypto/rand"
-	"errors"
+	"crypto/subtle"
 	"fmt"
-	"math/big"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	PasswordResetTTL         = 15 * time.Minute
+	PasswordResetMaxAttempts = 5
+)
+
+// Password reset modes: the user receives either a short code, typed along
+// with the email address, or a link carrying a long random token.
+const (
+	PasswordResetModeCode = "code"
+	PasswordResetModeLink = "link"
+)
+
+// PasswordReset is a pending password reset. Only the hash of the code or
+// link token is stored, and a code guessed wrong too many times stops
+// working.
 type PasswordReset struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
-	Token     string    `json:"token" gorm:"not null;uniqueIndex"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string    `json:"-" gorm:"not null;index"`
 	Email     string    `json:"email" gorm:"not null"`
+	Attempts  int       `json:"attempts" gorm:"not null;default:0"`
 	Used      bool      `json:"used" gorm:"default:false"`
 	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
-func NewPasswordReset(userID uuid.UUID, email string) (*PasswordReset, error) {
+// NewPasswordReset returns the reset together with the plaintext code or
+// link token, depending on mode, to be emailed to the user.
+func NewPasswordReset(userID uuid.UUID, email, mode string) (*PasswordReset, string, error) {
 	if err := ValidatePasswordResetData(email); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	token := generatePIN()
+	var token string
+	var err error
+	switch mode {
+	case PasswordResetModeCode:
+		token, err = generateVerificationCode()
+	case PasswordResetModeLink:
+		token, err = randomToken(32)
+	default:
+		return nil, "", fmt.Errorf("unknown password reset mode: %s", mode)
+	}
+	if err != nil {
+		return nil, "", err
+	}
 
 	return &PasswordReset{
 		ID:        uuid.New(),
 		UserID:    userID,
-		Token:     token,
+		TokenHash: HashPasswordResetToken(token),
 		Email:     email,
 		Used:      false,
-		ExpiresAt: time.Now().Add(15 * time.Minute),
-	}, nil
+		ExpiresAt: time.Now().Add(PasswordResetTTL),
+	}, token, nil
+}
+
+func HashPasswordResetToken(token string) string {
+	return hashOpaqueToken(strings.TrimSpace(token))
+}
+
+func ValidatePasswordResetMode(mode string) error {
+	if mode != PasswordResetModeCode && mode != PasswordResetModeLink {
+		return fmt.Errorf("invalid password reset mode %q: use %s or %s", mode, PasswordResetModeCode, PasswordResetModeLink)
+	}
+	return nil
 }
 
 func (pr *PasswordReset) IsExpired() bool {
 	return time.Now().After(pr.ExpiresAt)
 }
 
-func (pr *PasswordReset) IsValid() bool {
+// IsPending reports whether the reset blocks a new one from being sent. A
+// reset locked by wrong guesses is still pending until it expires, so the
+// guesses per account stay limited.
+func (pr *PasswordReset) IsPending() bool {
 	return !pr.Used && !pr.IsExpired()
 }
 
-func (pr *PasswordReset) MarkAsUsed() {
-	pr.Used = true
+// IsValid reports whether the reset can still be used.
+func (pr *PasswordReset) IsValid() bool {
+	return pr.IsPending() && pr.Attempts < PasswordResetMaxAttempts
 }
 
-func (pr *PasswordReset) ValidateToken(token string) error {
-	if pr.Token != token {
-		return errors.New("invalid token")
-	}
-
-	if !pr.IsValid() {
-		return errors.New("token is expired or already used")
-	}
-
-	return nil
+func (pr *PasswordReset) CheckToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(pr.TokenHash), []byte(HashPasswordResetToken(token))) == 1
 }
 
 func ValidateNewPassword(password string) error {
 	if password == "" {
-		return errors.New("password is required")
+		return NewCodedError("password_required", "password is required")
 	}
 
 	if len(password) < 6 {
-		return errors.New("password must be at least 6 characters")
+		return &CodedError{Code: "password_too_short", Message: "password must be at least 6 characters", Params: map[string]string{"min": "6"}}
 	}
 
 	if len(password) > 128 {
-		return errors.New("password is too long (maximum 128 characters)")
+		return &CodedError{Code: "password_too_long", Message: "password is too long (maximum 128 characters)", Params: map[string]string{"max": "128"}}
 	}
 
 	return nil
 }
 
-func generatePIN() string {
-	max := big.NewInt(999999)
-	n, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		return "123456"
-	}
-	return fmt.Sprintf("%06d", n.Int64())
-}
-
 func ValidatePasswordResetData(email string) error {
 	if email == "" {
-		return errors.New("email is required")
+		return NewCodedError("email_required", "email is required")
 	}
 
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(email) {
-		return errors.New("invalid email format")
+		return NewCodedError("invalid_email", "invalid email format")
 	}
 
 	return nil
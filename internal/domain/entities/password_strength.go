@@ -0,0 +1,237 @@
+package entities
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// PasswordStrength is the result of EstimatePasswordStrength. Score follows
+// the zxcvbn scale: 0 is trivially guessable and 4 is very hard to guess.
+type PasswordStrength struct {
+	Score        int     `json:"score"`
+	GuessesLog10 float64 `json:"guesses_log10"`
+}
+
+// commonPasswordWords is ranked by popularity: the earlier a word appears,
+// the sooner an attacker tries it.
+var commonPasswordWords = []string{
+	"password", "123456", "qwerty", "senha", "admin", "letmein", "welcome", "iloveyou",
+	"monkey", "dragon", "master", "login", "abc123", "football", "baseball", "princess",
+	"sunshine", "shadow", "superman", "michael", "trustno1", "secret", "hello", "freedom",
+	"whatever", "starwars", "batman", "charlie", "jordan", "killer", "pokemon", "test",
+	"teste", "root", "user", "usuario", "changeme", "default", "love", "amor", "brasil",
+	"mudar", "mudar123", "familia", "deus", "jesus", "flamengo", "corinthians", "palmeiras",
+	"futebol", "mae", "pai", "casa", "felicidade", "saudade", "segredo", "acesso",
+	"entrar", "bemvindo", "computer", "internet", "access", "passw0rd", "system", "server",
+	"summer", "winter", "spring", "autumn", "soccer", "hockey", "ranger", "buster",
+	"thomas", "robert", "daniel", "andrew", "joshua", "matthew", "jennifer", "jessica",
+	"ashley", "amanda", "maria", "joao", "pedro", "lucas", "gabriel", "rafael", "ana",
+	"juliana", "fernanda", "company", "empresa", "secure", "seguro", "private", "privado",
+	"qwertyuiop", "asdfgh", "zxcvbn", "abcdef", "google", "facebook", "instagram", "apple",
+	"samsung", "microsoft", "linux", "windows", "mustang", "harley", "ferrari", "cookie",
+	"banana", "orange", "chocolate", "flower", "tigger", "hunter", "ginger", "pepper",
+	"cheese", "matrix", "hacker", "ninja", "azerty", "gatinho", "cachorro", "estrela",
+}
+
+var keyboardRows = []string{
+	"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "azertyuiop", "qsdfghjklm", "wxcvbn",
+}
+
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+	'|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// EstimatePasswordStrength estimates how many guesses an attacker needs,
+// following the approach of zxcvbn: the password is split into the cheapest
+// sequence of known patterns (common words, keyboard walks, sequences,
+// repeats, years and the user's own data) and brute-forced characters.
+func EstimatePasswordStrength(password string, personalInfo ...string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return PasswordStrength{}
+	}
+
+	lowered := []rune(strings.ToLower(password))
+	unleeted := make([]rune, len(lowered))
+	for i, char := range lowered {
+		if plain, ok := leetSubstitutions[char]; ok {
+			unleeted[i] = plain
+		} else {
+			unleeted[i] = char
+		}
+	}
+
+	dictionary := make(map[string]int, len(commonPasswordWords))
+	for rank, word := range commonPasswordWords {
+		dictionary[word] = rank + 1
+	}
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if at := strings.Index(info, "@"); at >= 0 {
+			info = info[:at]
+		}
+		for _, token := range strings.FieldsFunc(info, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			dictionary[token] = 1
+		}
+	}
+
+	n := len(runes)
+	// best[i] is the log10 of the fewest guesses needed for the first i runes.
+	best := make([]float64, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = best[i-1] + math.Log10(bruteforceCardinality(runes[i-1]))
+
+		for start := 0; start < i; start++ {
+			guesses := patternGuesses(runes[start:i], lowered[start:i], unleeted[start:i], dictionary)
+			if guesses > 0 {
+				if candidate := best[start] + math.Log10(guesses); candidate < best[i] {
+					best[i] = candidate
+				}
+			}
+		}
+	}
+
+	guessesLog10 := best[n]
+	return PasswordStrength{
+		Score:        strengthScore(guessesLog10),
+		GuessesLog10: math.Round(guessesLog10*100) / 100,
+	}
+}
+
+// patternGuesses returns the guesses needed for the segment when it matches
+// a known pattern, or 0 when it does not.
+func patternGuesses(original, lowered, unleeted []rune, dictionary map[string]int) float64 {
+	length := len(original)
+	guesses := 0.0
+	consider := func(value float64) {
+		if value > 0 && (guesses == 0 || value < guesses) {
+			guesses = value
+		}
+	}
+
+	variations := 1.0
+	if string(original) != string(lowered) {
+		variations = 2
+	}
+
+	if rank, ok := dictionary[string(lowered)]; ok {
+		consider(float64(rank) * variations)
+	}
+	if rank, ok := dictionary[string(unleeted)]; ok && string(unleeted) != string(lowered) {
+		consider(float64(rank) * variations * 4)
+	}
+	if rank, ok := dictionary[reverseRunes(lowered)]; ok && length > 2 {
+		consider(float64(rank) * variations * 2)
+	}
+
+	if length < 3 {
+		return guesses
+	}
+
+	if isRepeat(lowered) {
+		consider(bruteforceCardinality(original[0]) * float64(length))
+	}
+
+	if isSequence(lowered) {
+		base := 26.0
+		switch {
+		case lowered[0] == 'a' || lowered[0] == '1' || lowered[0] == 'z' || lowered[0] == '9':
+			base = 4
+		case unicode.IsDigit(lowered[0]):
+			base = 10
+		}
+		consider(base * float64(length) * variations)
+	}
+
+	if length >= 4 && isKeyboardWalk(lowered) {
+		consider(float64(len(keyboardRows)) * 10 * float64(length) * variations)
+	}
+
+	if length == 4 && isYear(lowered) {
+		consider(120)
+	}
+
+	return guesses
+}
+
+func bruteforceCardinality(char rune) float64 {
+	switch {
+	case unicode.IsDigit(char):
+		return 10
+	case unicode.IsLower(char), unicode.IsUpper(char):
+		return 26
+	default:
+		return 33
+	}
+}
+
+func strengthScore(guessesLog10 float64) int {
+	switch {
+	case guessesLog10 < 3:
+		return 0
+	case guessesLog10 < 6:
+		return 1
+	case guessesLog10 < 8:
+		return 2
+	case guessesLog10 < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func isRepeat(runes []rune) bool {
+	for _, char := range runes[1:] {
+		if char != runes[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func isSequence(runes []rune) bool {
+	delta := runes[1] - runes[0]
+	if delta != 1 && delta != -1 {
+		return false
+	}
+	for i := 2; i < len(runes); i++ {
+		if runes[i]-runes[i-1] != delta {
+			return false
+		}
+	}
+	return true
+}
+
+func isKeyboardWalk(runes []rune) bool {
+	segment := string(runes)
+	reversed := reverseRunes(runes)
+	for _, row := range keyboardRows {
+		if strings.Contains(row, segment) || strings.Contains(row, reversed) {
+			return true
+		}
+	}
+	return false
+}
+
+func isYear(runes []rune) bool {
+	year := 0
+	for _, char := range runes {
+		if char < '0' || char > '9' {
+			return false
+		}
+		year = year*10 + int(char-'0')
+	}
+	return year >= 1920 && year <= 2039
+}
+
+func reverseRunes(runes []rune) string {
+	reversed := make([]rune, len(runes))
+	for i, char := range runes {
+		reversed[len(runes)-1-i] = char
+	}
+	return string(reversed)
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PhoneVerificationTTL            = 10 * time.Minute
+	PhoneVerificationMaxAttempts    = 5
+	PhoneVerificationResendCooldown = time.Minute
+)
+
+// PhoneVerification is a phone number waiting to be confirmed by the code
+// sent to it by SMS. The number only becomes the user's once confirmed. Only
+// the hash of the code is stored.
+type PhoneVerification struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Phone     string     `json:"phone" gorm:"not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	UsedAt    *time.Time `json:"used_at"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NewPhoneVerification returns the pending verification together with the
+// plaintext code to be sent to the phone.
+func NewPhoneVerification(userID uuid.UUID, phone string) (*PhoneVerification, string, error) {
+	code, err := generateVerificationCode()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &PhoneVerification{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Phone:     phone,
+		CodeHash:  hashOpaqueToken(code),
+		ExpiresAt: time.Now().Add(PhoneVerificationTTL),
+		CreatedAt: time.Now(),
+	}, code, nil
+}
+
+func (pv *PhoneVerification) IsExpired() bool {
+	return time.Now().After(pv.ExpiresAt)
+}
+
+// IsValid reports whether the verification can still be confirmed. It also
+// becomes invalid once the code was guessed wrong too many times.
+func (pv *PhoneVerification) IsValid() bool {
+	return pv.UsedAt == nil && !pv.IsExpired() && pv.Attempts < PhoneVerificationMaxAttempts
+}
+
+func (pv *PhoneVerification) CheckCode(code string) bool {
+	return subtle.ConstantTimeCompare([]byte(pv.CodeHash), []byte(hashOpaqueToken(strings.TrimSpace(code)))) == 1
+}
+
+// CanResend enforces a minimum interval between verification messages.
+func (pv *PhoneVerification) CanResend() bool {
+	return time.Since(pv.CreatedAt) >= PhoneVerificationResendCooldown
+}
@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	FamilyID  uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ClientID  string     `json:"client_id" gorm:"index"`
+	Scope     string     `json:"scope"`
+	Used      bool       `json:"used" gorm:"default:false"`
+	UsedAt    *time.Time `json:"used_at"`
+	Revoked   bool       `json:"revoked" gorm:"default:false"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// OrganizationID is the organization the session was opened for, so
+	// refreshed access tokens stay scoped to it.
+	OrganizationID *uuid.UUID `json:"organization_id" gorm:"type:uuid;index"`
+}
+
+// NewRefreshToken creates a refresh token for the given family and returns it
+// together with the opaque value handed to the client. Only the hash of that
+// value is persisted.
+func NewRefreshToken(userID, familyID uuid.UUID) (*RefreshToken, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	return &RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashRefreshToken(token),
+		Used:      false,
+		Revoked:   false,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}, token, nil
+}
+
+// NewClientRefreshToken creates a refresh token issued to an OAuth client. It
+// can only be redeemed by that client and never widens the granted scope.
+func NewClientRefreshToken(userID, familyID uuid.UUID, clientID, scope string) (*RefreshToken, string, error) {
+	refreshToken, token, err := NewRefreshToken(userID, familyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	refreshToken.ClientID = clientID
+	refreshToken.Scope = scope
+	return refreshToken, token, nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (rt *RefreshToken) IsExpired() bool {
+	return time.Now().After(rt.ExpiresAt)
+}
+
+func (rt *RefreshToken) IsValid() bool {
+	return !rt.Used && !rt.Revoked && !rt.IsExpired()
+}
+
+func ValidateRefreshTokenInput(token string) error {
+	if token == "" {
+		return NewCodedError("refresh_token_required", "refresh token is required")
+	}
+
+	if len(token) > 128 {
+		return NewCodedError("invalid_refresh_token", "invalid refresh token")
+	}
+
+	return nil
+}
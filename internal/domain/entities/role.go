@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PermissionUsersRead    = "users:read"
+	PermissionUsersWrite   = "users:write"
+	PermissionUsersDelete  = "users:delete"
+	PermissionRolesRead    = "roles:read"
+	PermissionRolesWrite   = "roles:write"
+	PermissionRolesAssign  = "roles:assign"
+	PermissionClientsRead  = "clients:read"
+	PermissionClientsWrite = "clients:write"
+	PermissionKeysRead     = "keys:read"
+	PermissionKeysWrite    = "keys:write"
+
+	PermissionOrganizationsRead  = "organizations:read"
+	PermissionOrganizationsWrite = "organizations:write"
+
+	PermissionAuditRead = "audit:read"
+
+	PermissionWebhooksRead  = "webhooks:read"
+	PermissionWebhooksWrite = "webhooks:write"
+)
+
+// tenantPermissions are the permissions that can be exercised within an
+// organization. Every other permission manages the platform itself and is
+// only granted to tokens that are not scoped to an organization.
+var tenantPermissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersDelete,
+	PermissionRolesRead,
+	PermissionRolesAssign,
+	PermissionAuditRead,
+}
+
+var roleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// Permission is an action that can be granted to roles. The set of
+// permissions is defined by the code, since routes check them by name.
+type Permission struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Role groups permissions and is assigned to users and memberships by name.
+// System roles are created at startup and cannot be deleted; the super_admin
+// role always holds every permission and the admin role every permission that
+// applies within an organization.
+type Role struct {
+	ID          uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name        string       `json:"name" gorm:"uniqueIndex;not null"`
+	Description string       `json:"description"`
+	System      bool         `json:"system" gorm:"not null;default:false"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// DefaultPermissions returns every permission known to the API.
+func DefaultPermissions() []Permission {
+	return []Permission{
+		{Name: PermissionUsersRead, Description: "Ver todos os usuários"},
+		{Name: PermissionUsersWrite, Description: "Criar e editar usuários, resetar 2FA e desbloquear contas"},
+		{Name: PermissionUsersDelete, Description: "Deletar usuários"},
+		{Name: PermissionRolesRead, Description: "Ver perfis e permissões"},
+		{Name: PermissionRolesWrite, Description: "Criar, editar e deletar perfis"},
+		{Name: PermissionRolesAssign, Description: "Alterar o perfil de usuários"},
+		{Name: PermissionClientsRead, Description: "Ver clientes OAuth"},
+		{Name: PermissionClientsWrite, Description: "Registrar e remover clientes OAuth"},
+		{Name: PermissionKeysRead, Description: "Ver chaves de assinatura"},
+		{Name: PermissionKeysWrite, Description: "Gerar, promover e aposentar chaves de assinatura"},
+		{Name: PermissionOrganizationsRead, Description: "Ver organizações e acessar os usuários de qualquer organização"},
+		{Name: PermissionOrganizationsWrite, Description: "Criar, editar e deletar organizações e gerenciar seus membros"},
+		{Name: PermissionAuditRead, Description: "Consultar o log de auditoria"},
+		{Name: PermissionWebhooksRead, Description: "Ver webhooks e o histórico de entregas"},
+		{Name: PermissionWebhooksWrite, Description: "Criar, editar e deletar webhooks e reenviar entregas"},
+	}
+}
+
+func IsTenantPermission(name string) bool {
+	for _, permission := range tenantPermissions {
+		if permission == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantPermissions keeps only the permissions that apply within an
+// organization, so a role cannot grant platform access through a membership.
+func TenantPermissions(names []string) []string {
+	var filtered []string
+	for _, name := range names {
+		if IsTenantPermission(name) {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered
+}
+
+func IsKnownPermission(name string) bool {
+	for _, permission := range DefaultPermissions() {
+		if permission.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateRoleName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return NewCodedError("role_name_required", "role name is required")
+	}
+
+	if !roleNameRegex.MatchString(name) {
+		return NewCodedError("invalid_role_name", "role name must be 2-50 lowercase letters, digits, '-' or '_', starting with a letter")
+	}
+
+	return nil
+}
+
+func ValidatePermissionNames(names []string) error {
+	for _, name := range names {
+		if !IsKnownPermission(name) {
+			return &CodedError{
+				Code:    "unknown_permission",
+				Message: "unknown permission: " + name,
+				Params:  map[string]string{"permission": name},
+			}
+		}
+	}
+	return nil
+}
+
+func NewRole(name, description string) (*Role, error) {
+	if err := ValidateRoleName(name); err != nil {
+		return nil, err
+	}
+
+	return &Role{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+	}, nil
+}
+
+func (r *Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SetupToken lets whoever holds it create the first admin through the setup
+// endpoint, when no initial admin is configured. Only its hash is stored: the
+// token itself is printed once, when it is issued.
+type SetupToken struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TokenHash string    `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NewSetupToken returns the token record together with the plaintext token
+// to be shown to the operator.
+func NewSetupToken() (*SetupToken, string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &SetupToken{
+		ID:        uuid.New(),
+		TokenHash: HashSetupToken(token),
+		CreatedAt: time.Now(),
+	}, token, nil
+}
+
+func HashSetupToken(token string) string {
+	return hashOpaqueToken(strings.TrimSpace(token))
+}
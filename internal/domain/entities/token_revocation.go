@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// UserTokenRevocation invalidates every access token of a user issued at or
+// before RevokedBefore, except the one with ExemptTokenID. ExpiresAt marks
+// when the entry can be discarded, since no token issued before the cutoff
+// can still be alive after it.
+type UserTokenRevocation struct {
+	UserID        uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	RevokedBefore time.Time `json:"revoked_before" gorm:"not null"`
+	ExemptTokenID string    `json:"exempt_token_id" gorm:"not null;default:''"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func NewRevokedToken(jti string, userID uuid.UUID, expiresAt time.Time) (*RevokedToken, error) {
+	if strings.TrimSpace(jti) == "" {
+		return nil, NewCodedError("token_id_required", "token id is required")
+	}
+
+	return &RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// NewUserTokenRevocation revokes the tokens of the user issued up to now.
+// Tokens carry their issue time in whole seconds, so the ones issued later
+// in the current second are revoked too; the session that replaces the
+// revoked ones is kept by its token id instead.
+func NewUserTokenRevocation(userID uuid.UUID, exemptTokenID string, maxTokenLifetime time.Duration) *UserTokenRevocation {
+	now := time.Now()
+	return &UserTokenRevocation{
+		UserID:        userID,
+		RevokedBefore: now,
+		ExemptTokenID: exemptTokenID,
+		ExpiresAt:     now.Add(maxTokenLifetime),
+	}
+}
+
+func (r *UserTokenRevocation) Covers(jti string, issuedAt time.Time) bool {
+	if r.ExemptTokenID != "" && jti == r.ExemptTokenID {
+		return false
+	}
+	return !issuedAt.After(r.RevokedBefore) && time.Now().Before(r.ExpiresAt)
+}
@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestUserTokenRevocationCovers(t *testing.T) {
+	revocation := NewUserTokenRevocation(uuid.New(), "replacement", time.Hour)
+	cutoff := revocation.RevokedBefore
+	// Tokens carry their issue time in whole seconds.
+	sameSecond := cutoff.Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		jti      string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "issued earlier", jti: "old", issuedAt: cutoff.Add(-time.Minute), want: true},
+		{name: "issued earlier in the same second", jti: "old", issuedAt: sameSecond, want: true},
+		{name: "issued at the cutoff", jti: "old", issuedAt: cutoff, want: true},
+		{name: "replacement issued in the same second", jti: "replacement", issuedAt: sameSecond},
+		{name: "issued in a later second", jti: "new", issuedAt: sameSecond.Add(time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revocation.Covers(tt.jti, tt.issuedAt); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserTokenRevocationWithoutExemption(t *testing.T) {
+	revocation := NewUserTokenRevocation(uuid.New(), "", time.Hour)
+
+	if !revocation.Covers("", revocation.RevokedBefore.Truncate(time.Second)) {
+		t.Error("a revocation without exemption kept a token without id")
+	}
+}
+
+func TestUserTokenRevocationExpires(t *testing.T) {
+	revocation := NewUserTokenRevocation(uuid.New(), "", time.Hour)
+	revocation.ExpiresAt = time.Now().Add(-time.Second)
+
+	if revocation.Covers("old", revocation.RevokedBefore.Add(-time.Minute)) {
+		t.Error("an expired revocation still covers tokens")
+	}
+}
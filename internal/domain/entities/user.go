@@ -1,18 +1,20 @@
 package entities
 
 import (
-	"errors"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// System roles, created at startup. Other roles are managed through the API.
+// The super_admin role manages the platform and every organization; admin is
+// the administrator of an organization.
 const (
-	RoleAdmin = "admin"
-	RoleUser  = "user"
+	RoleSuperAdmin = "super_admin"
+	RoleAdmin      = "admin"
+	RoleUser       = "user"
 )
 
 type UserFilters struct {
@@ -26,56 +28,74 @@ type UserFilters struct {
 }
 
 type User struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name      string    `json:"name" gorm:"not null"`
-	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Role      string    `json:"role" gorm:"not null;default:'user'"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name               string     `json:"name" gorm:"not null"`
+	Email              string     `json:"email" gorm:"uniqueIndex;not null"`
+	Password           string     `json:"-" gorm:"not null"`
+	Role               string     `json:"role" gorm:"not null;default:'user'"`
+	EmailVerified      bool       `json:"email_verified" gorm:"not null;default:false"`
+	EmailVerifiedAt    *time.Time `json:"email_verified_at"`
+	Phone              *string    `json:"phone" gorm:"uniqueIndex"`
+	PhoneVerifiedAt    *time.Time `json:"phone_verified_at"`
+	Locale             string     `json:"locale" gorm:"not null;default:''"`
+	MFAEnabled         bool       `json:"mfa_enabled" gorm:"not null;default:false"`
+	MFASecret          string     `json:"-"`
+	MFAPendingSecret   string     `json:"-"`
+	MFALastUsedStep    int64      `json:"-" gorm:"not null;default:0"`
+	MFAEnabledAt       *time.Time `json:"mfa_enabled_at"`
+	DisabledAt         *time.Time `json:"disabled_at"`
+	MustChangePassword bool       `json:"must_change_password" gorm:"not null;default:false"`
+	PasswordChangedAt  *time.Time `json:"password_changed_at"`
+	CreatedAt          time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// OrganizationRole is the role of the user in the organization the
+	// repository was scoped to when loading it. It is never written.
+	OrganizationRole string `json:"-" gorm:"->;-:migration"`
+
+	// webhookEvents are the events recorded since the user was last saved.
+	webhookEvents []string
 }
 
 func ValidateUUID(id string) error {
 	if strings.TrimSpace(id) == "" {
-		return errors.New("id is required")
+		return NewCodedError("id_required", "id is required")
 	}
 
 	_, err := uuid.Parse(id)
 	if err != nil {
-		return errors.New("invalid UUID format")
+		return NewCodedError("invalid_uuid", "invalid UUID format")
 	}
 
 	return nil
 }
 
+// ValidateRole checks the format of a role name. Whether the role exists is
+// checked against the role repository.
 func ValidateRole(role string) error {
 	if strings.TrimSpace(role) == "" {
-		return errors.New("role is required")
+		return NewCodedError("role_required", "role is required")
 	}
 
-	if role != RoleAdmin && role != RoleUser {
-		return errors.New("role must be 'admin' or 'user'")
-	}
-
-	return nil
+	return ValidateRoleName(role)
 }
 
 func ValidateName(name string) error {
 	if strings.TrimSpace(name) == "" {
-		return errors.New("name is required")
+		return NewCodedError("name_required", "name is required")
 	}
 
 	if len(name) < 2 {
-		return errors.New("name must be at least 2 characters")
+		return NewCodedError("name_too_short", "name must be at least 2 characters")
 	}
 
 	if len(name) > 100 {
-		return errors.New("name must be at most 100 characters")
+		return NewCodedError("name_too_long", "name must be at most 100 characters")
 	}
 
 	nameRegex := regexp.MustCompile(`^[a-zA-ZÀ-ÿ\s]+$`)
 	if !nameRegex.MatchString(name) {
-		return errors.New("name must contain only letters and spaces")
+		return NewCodedError("invalid_name", "name must contain only letters and spaces")
 	}
 
 	return nil
@@ -83,16 +103,31 @@ func ValidateName(name string) error {
 
 func ValidateEmail(email string) error {
 	if strings.TrimSpace(email) == "" {
-		return errors.New("email is required")
+		return NewCodedError("email_required", "email is required")
 	}
 
 	if len(email) > 255 {
-		return errors.New("email is too long (maximum 255 characters)")
+		return NewCodedError("email_too_long", "email is too long (maximum 255 characters)")
 	}
 
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(email) {
-		return errors.New("invalid email format")
+		return NewCodedError("invalid_email", "invalid email format")
+	}
+
+	return nil
+}
+
+// ValidatePhone checks that phone is in E.164 format: a plus sign, the
+// country code and at most 15 digits in total.
+func ValidatePhone(phone string) error {
+	if strings.TrimSpace(phone) == "" {
+		return NewCodedError("phone_required", "phone is required")
+	}
+
+	phoneRegex := regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	if !phoneRegex.MatchString(phone) {
+		return NewCodedError("invalid_phone", "phone must be in E.164 format, like +5511999998888")
 	}
 
 	return nil
@@ -100,15 +135,15 @@ func ValidateEmail(email string) error {
 
 func ValidatePassword(password string) error {
 	if strings.TrimSpace(password) == "" {
-		return errors.New("password is required")
+		return NewCodedError("password_required", "password is required")
 	}
 
 	if len(password) < 6 {
-		return errors.New("password must be at least 6 characters")
+		return &CodedError{Code: "password_too_short", Message: "password must be at least 6 characters", Params: map[string]string{"min": "6"}}
 	}
 
 	if len(password) > 128 {
-		return errors.New("password is too long (maximum 128 characters)")
+		return &CodedError{Code: "password_too_long", Message: "password is too long (maximum 128 characters)", Params: map[string]string{"max": "128"}}
 	}
 
 	return nil
@@ -141,7 +176,7 @@ func ValidateUserFilters(filters *UserFilters) error {
 	}
 
 	if !isValidSortField {
-		return errors.New("invalid sort_by field")
+		return NewCodedError("invalid_sort_by", "invalid sort_by field")
 	}
 
 	if filters.SortOrder == "" {
@@ -149,7 +184,7 @@ func ValidateUserFilters(filters *UserFilters) error {
 	}
 
 	if filters.SortOrder != "asc" && filters.SortOrder != "desc" {
-		return errors.New("sort_order must be 'asc' or 'desc'")
+		return NewCodedError("invalid_sort_order", "sort_order must be 'asc' or 'desc'")
 	}
 
 	if filters.Role != "" {
@@ -161,7 +196,7 @@ func ValidateUserFilters(filters *UserFilters) error {
 	return nil
 }
 
-func NewUser(name, email, password string) (*User, error) {
+func NewUser(name, email, password string, policy *PasswordPolicy, hasher PasswordHasher) (*User, error) {
 	if err := ValidateName(name); err != nil {
 		return nil, err
 	}
@@ -170,73 +205,205 @@ func NewUser(name, email, password string) (*User, error) {
 		return nil, err
 	}
 
-	if err := ValidatePassword(password); err != nil {
+	if err := policy.Validate(password, name, email); err != nil {
 		return nil, err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := hasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
 	return &User{
-		ID:       uuid.New(),
-		Name:     name,
-		Email:    email,
-		Password: string(hashedPassword),
-		Role:     RoleUser,
+		ID:                uuid.New(),
+		Name:              name,
+		Email:             email,
+		Password:          hashedPassword,
+		Role:              RoleUser,
+		PasswordChangedAt: &now,
 	}, nil
 }
 
-func NewAdminUser(name, email, password string) (*User, error) {
-	user, err := NewUser(name, email, password)
+func NewSuperAdminUser(name, email, password string, policy *PasswordPolicy, hasher PasswordHasher) (*User, error) {
+	user, err := NewUser(name, email, password, policy, hasher)
 	if err != nil {
 		return nil, err
 	}
-	user.Role = RoleAdmin
+	user.Role = RoleSuperAdmin
 	return user, nil
 }
 
+// EffectiveRole returns the role of the user in the organization it was
+// loaded for, or its global role when it was loaded without a tenant.
+func (u *User) EffectiveRole() string {
+	if u.OrganizationRole != "" {
+		return u.OrganizationRole
+	}
+	return u.Role
+}
+
+func (u *User) IsSuperAdmin() bool {
+	return u.Role == RoleSuperAdmin
+}
+
 func (u *User) IsAdmin() bool {
-	return u.Role == RoleAdmin
+	return u.EffectiveRole() == RoleAdmin
 }
 
 func (u *User) IsUser() bool {
-	return u.Role == RoleUser
+	return u.EffectiveRole() == RoleUser
 }
 
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+func (u *User) CheckPassword(hasher PasswordHasher, password string) bool {
+	ok, err := hasher.Verify(password, u.Password)
+	return err == nil && ok
 }
 
-func ValidateLoginData(email, password string) error {
-	if err := ValidateEmail(email); err != nil {
+func (u *User) SetPassword(hasher PasswordHasher, password string) error {
+	hashedPassword, err := hasher.Hash(password)
+	if err != nil {
 		return err
 	}
+	u.Password = hashedPassword
+	return nil
+}
 
-	if err := ValidatePassword(password); err != nil {
+// ChangePassword sets a new password, restarting its age and satisfying a
+// required change. Rehashing the same password uses SetPassword instead.
+func (u *User) ChangePassword(hasher PasswordHasher, password string) error {
+	if err := u.SetPassword(hasher, password); err != nil {
 		return err
 	}
+	now := time.Now()
+	u.PasswordChangedAt = &now
+	u.MustChangePassword = false
+	return nil
+}
+
+// PasswordExpired tells whether the password is older than maxAge. Passwords
+// never expire when maxAge is zero.
+func (u *User) PasswordExpired(maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	changedAt := u.CreatedAt
+	if u.PasswordChangedAt != nil {
+		changedAt = *u.PasswordChangedAt
+	}
+	return time.Since(changedAt) > maxAge
+}
+
+// RequirePasswordChange flags the user to choose a new password on the next
+// login, for passwords someone else set or saw.
+func (u *User) RequirePasswordChange() {
+	u.MustChangePassword = true
+}
+
+func (u *User) MarkEmailVerified() {
+	now := time.Now()
+	u.EmailVerified = true
+	u.EmailVerifiedAt = &now
+}
+
+// PhoneNumber returns the verified phone of the user, or an empty string.
+func (u *User) PhoneNumber() string {
+	if u.Phone == nil {
+		return ""
+	}
+	return *u.Phone
+}
+
+// SetVerifiedPhone stores a phone whose verification code was confirmed.
+// Phones are only stored once verified.
+func (u *User) SetVerifiedPhone(phone string) {
+	now := time.Now()
+	u.Phone = &phone
+	u.PhoneVerifiedAt = &now
+}
 
+func (u *User) RemovePhone() {
+	u.Phone = nil
+	u.PhoneVerifiedAt = nil
+}
+
+// SetLocale stores the language the user's emails and text messages are
+// written in.
+func (u *User) SetLocale(locale string) error {
+	normalized, err := ValidateLocale(locale)
+	if err != nil {
+		return err
+	}
+	u.Locale = normalized
 	return nil
 }
 
-func ValidateResetPasswordInput(token, password string) error {
-	if strings.TrimSpace(token) == "" {
-		return errors.New("token is required")
+// Disable blocks the user from logging in, keeping the account and its data.
+func (u *User) Disable() {
+	now := time.Now()
+	u.DisabledAt = &now
+}
+
+func (u *User) Enable() {
+	u.DisabledAt = nil
+}
+
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+func (u *User) BeginMFAEnrollment(secret string) error {
+	if u.MFAEnabled {
+		return NewCodedError("mfa_already_enabled", "two-factor authentication is already enabled")
+	}
+	u.MFAPendingSecret = secret
+	return nil
+}
+
+func (u *User) EnableMFA(step int64) error {
+	if u.MFAPendingSecret == "" {
+		return NewCodedError("mfa_enrollment_not_started", "two-factor enrollment was not started")
+	}
+	now := time.Now()
+	u.MFAEnabled = true
+	u.MFASecret = u.MFAPendingSecret
+	u.MFAPendingSecret = ""
+	u.MFALastUsedStep = step
+	u.MFAEnabledAt = &now
+	return nil
+}
+
+func (u *User) DisableMFA() {
+	u.MFAEnabled = false
+	u.MFASecret = ""
+	u.MFAPendingSecret = ""
+	u.MFALastUsedStep = 0
+	u.MFAEnabledAt = nil
+}
+
+func ValidateMFACode(code string) error {
+	if strings.TrimSpace(code) == "" {
+		return NewCodedError("code_required", "code is required")
 	}
 
-	if len(token) != 6 {
-		return errors.New("token must be 6 digits")
+	if len(code) != 6 {
+		return NewCodedError("code_invalid_length", "code must be 6 digits")
 	}
 
-	for _, char := range token {
+	for _, char := range code {
 		if char < '0' || char > '9' {
-			return errors.New("token must contain only digits")
+			return NewCodedError("code_not_numeric", "code must contain only digits")
 		}
 	}
 
+	return nil
+}
+
+func ValidateLoginData(email, password string) error {
+	if err := ValidateEmail(email); err != nil {
+		return err
+	}
+
 	if err := ValidatePassword(password); err != nil {
 		return err
 	}
@@ -244,6 +411,46 @@ func ValidateResetPasswordInput(token, password string) error {
 	return nil
 }
 
+// ValidateResetPasswordInput checks that a reset identifies itself with the
+// link token, or with the email address or phone and the code.
+func ValidateResetPasswordInput(token, email, phone, code, password string) error {
+	if strings.TrimSpace(token) == "" {
+		if (strings.TrimSpace(email) == "" && strings.TrimSpace(phone) == "") || strings.TrimSpace(code) == "" {
+			return NewCodedError("reset_input_required", "token, or email or phone and code, are required")
+		}
+
+		if email != "" && phone != "" {
+			return NewCodedError("email_or_phone", "use either email or phone")
+		}
+
+		if email != "" {
+			if err := ValidateEmail(email); err != nil {
+				return err
+			}
+		} else if err := ValidatePhone(phone); err != nil {
+			return err
+		}
+
+		if len(code) != 6 {
+			return NewCodedError("code_invalid_length", "code must be 6 digits")
+		}
+
+		for _, char := range code {
+			if char < '0' || char > '9' {
+				return NewCodedError("code_not_numeric", "code must contain only digits")
+			}
+		}
+	}
+
+	if strings.TrimSpace(password) == "" {
+		return NewCodedError("password_required", "password is required")
+	}
+
+	return nil
+}
+
+// ValidateUpdateUserInput validates the fields of a user update. The role is
+// optional and left unchanged when empty.
 func ValidateUpdateUserInput(name, email, role string) error {
 	if err := ValidateName(name); err != nil {
 		return err
@@ -253,8 +460,10 @@ func ValidateUpdateUserInput(name, email, role string) error {
 		return err
 	}
 
-	if err := ValidateRole(role); err != nil {
-		return err
+	if role != "" {
+		if err := ValidateRole(role); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,343 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types. Subscribers match on them, so existing values must not
+// change.
+const (
+	WebhookEventUserCreated         = "user.created"
+	WebhookEventUserUpdated         = "user.updated"
+	WebhookEventUserDeleted         = "user.deleted"
+	WebhookEventUserLoggedIn        = "user.logged_in"
+	WebhookEventUserPasswordReset   = "user.password_reset"
+	WebhookEventUserPasswordChanged = "user.password_changed"
+	WebhookEventUserEmailChanged    = "user.email_changed"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Headers sent with every delivery. The signature is an HMAC-SHA256, keyed
+// with the subscription secret, of the timestamp, a dot and the body.
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+func WebhookEventTypes() []string {
+	return []string{
+		WebhookEventUserCreated,
+		WebhookEventUserUpdated,
+		WebhookEventUserDeleted,
+		WebhookEventUserLoggedIn,
+		WebhookEventUserPasswordReset,
+		WebhookEventUserPasswordChanged,
+		WebhookEventUserEmailChanged,
+	}
+}
+
+// WebhookSubscription is an endpoint notified of the events it subscribes
+// to. Events are stored as a space separated list. The secret signs the
+// deliveries, so it is kept as is and only shown when created or rotated.
+type WebhookSubscription struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	URL         string    `json:"url" gorm:"not null"`
+	Description string    `json:"description"`
+	Events      string    `json:"events" gorm:"not null"`
+	Secret      string    `json:"-" gorm:"not null"`
+	Active      bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// WebhookEvent is an entry of the outbox. It is written in the same
+// transaction as the change it describes and fanned out to the matching
+// subscriptions by the dispatcher, which then sets DispatchedAt.
+type WebhookEvent struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Type           string     `json:"type" gorm:"not null;index"`
+	OrganizationID string     `json:"organization_id"`
+	Payload        string     `json:"payload" gorm:"type:jsonb;not null"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	DispatchedAt   *time.Time `json:"dispatched_at" gorm:"index"`
+}
+
+// WebhookDelivery is an event to be sent to one subscription. Failed
+// deliveries are retried until they succeed or run out of attempts, when they
+// are dead-lettered and only sent again on request.
+type WebhookDelivery struct {
+	ID             uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SubscriptionID uuid.UUID            `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	EventID        uuid.UUID            `json:"event_id" gorm:"type:uuid;not null;index"`
+	EventType      string               `json:"event_type" gorm:"not null"`
+	Payload        string               `json:"payload" gorm:"type:jsonb;not null"`
+	Status         string               `json:"status" gorm:"not null;default:'pending';index"`
+	Attempts       int                  `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time            `json:"next_attempt_at" gorm:"not null;index"`
+	LastAttemptAt  *time.Time           `json:"last_attempt_at"`
+	LastStatusCode int                  `json:"last_status_code"`
+	LastError      string               `json:"last_error"`
+	DeliveredAt    *time.Time           `json:"delivered_at"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+	Subscription   *WebhookSubscription `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// WebhookDeliveryAttempt logs one request made for a delivery. StatusCode is
+// zero when no response was received.
+type WebhookDeliveryAttempt struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DeliveryID uuid.UUID        `json:"delivery_id" gorm:"type:uuid;not null;index"`
+	Attempt    int              `json:"attempt" gorm:"not null"`
+	StatusCode int              `json:"status_code"`
+	Error      string           `json:"error"`
+	DurationMS int64            `json:"duration_ms"`
+	CreatedAt  time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	Delivery   *WebhookDelivery `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// WebhookRetryPolicy spaces the attempts of a delivery: each failure doubles
+// the wait, starting at BaseDelay up to MaxDelay, and after MaxAttempts
+// failures the delivery is dead-lettered.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// WebhookPayload is the body sent to subscribers.
+type WebhookPayload struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	CreatedAt      string          `json:"created_at"`
+	OrganizationID string          `json:"organization_id,omitempty"`
+	Data           WebhookUserData `json:"data"`
+}
+
+type WebhookUserData struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone,omitempty"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	MFAEnabled    bool   `json:"mfa_enabled"`
+	Disabled      bool   `json:"disabled"`
+}
+
+// NewWebhookSubscription creates a subscription and the secret that signs
+// its deliveries.
+func NewWebhookSubscription(rawURL, description string, events []string) (*WebhookSubscription, string, error) {
+	if err := ValidateWebhookSubscriptionData(rawURL, events); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &WebhookSubscription{
+		ID:          uuid.New(),
+		URL:         rawURL,
+		Description: strings.TrimSpace(description),
+		Events:      strings.Join(events, " "),
+		Secret:      secret,
+		Active:      true,
+	}, secret, nil
+}
+
+func ValidateWebhookSubscriptionData(rawURL string, events []string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return NewCodedError("invalid_webhook_url", "invalid webhook url")
+	}
+
+	if len(events) == 0 {
+		return NewCodedError("webhook_events_required", "at least one event is required")
+	}
+	for _, event := range events {
+		if !containsString(WebhookEventTypes(), event) {
+			return &CodedError{
+				Code:    "unknown_webhook_event",
+				Message: "unknown webhook event: " + event,
+				Params:  map[string]string{"event": event},
+			}
+		}
+	}
+
+	return nil
+}
+
+// RotateSecret replaces the signing secret and returns the new one.
+func (s *WebhookSubscription) RotateSecret() (string, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	s.Secret = secret
+	return secret, nil
+}
+
+func (s *WebhookSubscription) EventList() []string {
+	return strings.Fields(s.Events)
+}
+
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	return containsString(s.EventList(), eventType)
+}
+
+// NewUserWebhookEvent describes an event about a user, with the user as it
+// is after the change.
+func NewUserWebhookEvent(eventType string, user *User, organizationID string) (*WebhookEvent, error) {
+	event := &WebhookEvent{
+		ID:             uuid.New(),
+		Type:           eventType,
+		OrganizationID: organizationID,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	payload, err := json.Marshal(WebhookPayload{
+		ID:             event.ID.String(),
+		Type:           eventType,
+		CreatedAt:      event.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		OrganizationID: organizationID,
+		Data: WebhookUserData{
+			ID:            user.ID.String(),
+			Name:          user.Name,
+			Email:         user.Email,
+			Phone:         user.PhoneNumber(),
+			Role:          user.EffectiveRole(),
+			EmailVerified: user.EmailVerified,
+			MFAEnabled:    user.MFAEnabled,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	event.Payload = string(payload)
+
+	return event, nil
+}
+
+// NewWebhookDelivery queues the event for a subscription, to be sent right
+// away.
+func NewWebhookDelivery(subscription *WebhookSubscription, event *WebhookEvent, now time.Time) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        event.Payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  now,
+	}
+}
+
+// RecordAttempt updates the delivery with the outcome of a request and
+// returns the attempt to log. Any 2xx response is a success.
+func (d *WebhookDelivery) RecordAttempt(statusCode int, errMessage string, duration time.Duration, policy WebhookRetryPolicy, now time.Time) *WebhookDeliveryAttempt {
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.LastStatusCode = statusCode
+	d.LastError = errMessage
+
+	switch {
+	case errMessage == "" && statusCode >= 200 && statusCode < 300:
+		d.Status = WebhookDeliverySucceeded
+		d.DeliveredAt = &now
+	case d.Attempts >= policy.MaxAttempts:
+		d.Status = WebhookDeliveryDead
+	default:
+		d.NextAttemptAt = now.Add(policy.Delay(d.Attempts))
+	}
+
+	return &WebhookDeliveryAttempt{
+		ID:         uuid.New(),
+		DeliveryID: d.ID,
+		Attempt:    d.Attempts,
+		StatusCode: statusCode,
+		Error:      errMessage,
+		DurationMS: duration.Milliseconds(),
+	}
+}
+
+// Retry sends a delivery again, with a fresh set of attempts.
+func (d *WebhookDelivery) Retry(now time.Time) error {
+	if d.Status == WebhookDeliverySucceeded {
+		return NewCodedError("delivery_already_succeeded", "delivery already succeeded")
+	}
+
+	d.Status = WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	return nil
+}
+
+// Delay returns how long to wait after the given number of failed attempts.
+func (p WebhookRetryPolicy) Delay(failures int) time.Duration {
+	return backoffDelay(p.BaseDelay, p.MaxDelay, failures)
+}
+
+// backoffDelay doubles base for each failure after the first, up to max.
+func backoffDelay(base, max time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// SignWebhookPayload returns the signature header value for a body sent at
+// the given unix timestamp. Receivers should recompute it, compare it in
+// constant time and reject old timestamps to prevent replays.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RecordWebhookEvent marks the user as having gone through an event. The
+// user repository writes the events recorded on a user to the outbox when
+// saving it.
+func (u *User) RecordWebhookEvent(eventType string) {
+	if !containsString(u.webhookEvents, eventType) {
+		u.webhookEvents = append(u.webhookEvents, eventType)
+	}
+}
+
+// TakeWebhookEvents returns the events recorded on the user and forgets them.
+func (u *User) TakeWebhookEvents() []string {
+	events := u.webhookEvents
+	u.webhookEvents = nil
+	return events
+}
+
+func newWebhookSecret() (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + token, nil
+}
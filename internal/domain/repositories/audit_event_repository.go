@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// AuditEventRepository is append-only: events can be added and read, never
+// changed.
+type AuditEventRepository interface {
+	Append(ctx context.Context, event *entities.AuditEvent) error
+	Find(ctx context.Context, filter *entities.AuditEventFilter) ([]*entities.AuditEvent, error)
+	FindAfter(ctx context.Context, sequence int64, limit int) ([]*entities.AuditEvent, error)
+}
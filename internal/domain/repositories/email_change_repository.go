@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type EmailChangeRepository interface {
+	Create(ctx context.Context, change *entities.EmailChange) error
+	FindLatestByUserID(ctx context.Context, userID string) (*entities.EmailChange, error)
+	ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error)
+	MarkAsUsed(ctx context.Context, id string) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
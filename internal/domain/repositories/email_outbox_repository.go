@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type EmailOutboxRepository interface {
+	Enqueue(ctx context.Context, email *entities.OutgoingEmail) error
+	// ClaimDue returns pending emails due by now. Claimed emails are
+	// postponed by lease, so other instances skip them while they are sent.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.OutgoingEmail, error)
+	Update(ctx context.Context, email *entities.OutgoingEmail) error
+}
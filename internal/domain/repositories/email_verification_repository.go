@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, verification *entities.EmailVerification) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entities.EmailVerification, error)
+	FindLatestByUserID(ctx context.Context, userID string) (*entities.EmailVerification, error)
+	CountCreatedSince(ctx context.Context, userID string, since time.Time) (int64, error)
+	ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error)
+	MarkAsUsed(ctx context.Context, id string) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type LoginThrottleRepository interface {
+	Find(ctx context.Context, key string) (*entities.LoginThrottle, error)
+	RegisterFailure(ctx context.Context, key string, retention time.Duration) (*entities.LoginThrottle, error)
+	Reset(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type MFARecoveryCodeRepository interface {
+	ReplaceForUser(ctx context.Context, userID string, codes []*entities.MFARecoveryCode) error
+	FindUnusedByHash(ctx context.Context, userID, codeHash string) (*entities.MFARecoveryCode, error)
+	MarkAsUsed(ctx context.Context, id string) (bool, error)
+	CountUnused(ctx context.Context, userID string) (int64, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
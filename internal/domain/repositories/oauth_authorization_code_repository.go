@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type OAuthAuthorizationCodeRepository interface {
+	Create(ctx context.Context, code *entities.OAuthAuthorizationCode) error
+	FindByCodeHash(ctx context.Context, codeHash string) (*entities.OAuthAuthorizationCode, error)
+	MarkAsUsed(ctx context.Context, id string, tokenFamilyID string) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *entities.OAuthClient) error
+	FindByClientID(ctx context.Context, clientID string) (*entities.OAuthClient, error)
+	FindAll(ctx context.Context) ([]*entities.OAuthClient, error)
+	Delete(ctx context.Context, clientID string) error
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, organization *entities.Organization) error
+	FindByID(ctx context.Context, id string) (*entities.Organization, error)
+	FindBySlug(ctx context.Context, slug string) (*entities.Organization, error)
+	FindAll(ctx context.Context) ([]*entities.Organization, error)
+	Update(ctx context.Context, organization *entities.Organization) error
+	Delete(ctx context.Context, id string) error
+
+	AddMember(ctx context.Context, membership *entities.Membership) error
+	FindMembership(ctx context.Context, organizationID, userID string) (*entities.Membership, error)
+	FindMembershipsByUserID(ctx context.Context, userID string) ([]*entities.Membership, error)
+	FindMembers(ctx context.Context, organizationID string) ([]*entities.Membership, error)
+	UpdateMembership(ctx context.Context, membership *entities.Membership) error
+	RemoveMember(ctx context.Context, organizationID, userID string) error
+	CountMembersByRole(ctx context.Context, role string) (int64, error)
+	FindMemberIDsByRole(ctx context.Context, role string) ([]string, error)
+}
@@ -8,8 +8,9 @@ import (
 
 type PasswordResetRepository interface {
 	Create(ctx context.Context, passwordReset *entities.PasswordReset) error
-	FindByToken(ctx context.Context, token string) (*entities.PasswordReset, error)
-	FindByUserID(ctx context.Context, userID string) (*entities.PasswordReset, error)
-	Update(ctx context.Context, passwordReset *entities.PasswordReset) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entities.PasswordReset, error)
+	FindLatestByUserID(ctx context.Context, userID string) (*entities.PasswordReset, error)
+	ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error)
+	MarkAsUsed(ctx context.Context, id string) (bool, error)
 	DeleteExpired(ctx context.Context) error
 }
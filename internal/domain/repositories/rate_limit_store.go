@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitStore counts requests in fixed windows of the given length. The
+// limiter combines the current and the previous window into a sliding window.
+type RateLimitStore interface {
+	// Increment adds a hit to the window containing now and returns the hit
+	// counts of that window and of the one before it.
+	Increment(ctx context.Context, key string, window time.Duration, now time.Time) (current, previous int64, err error)
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, refreshToken *entities.RefreshToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error)
+	MarkAsUsed(ctx context.Context, id string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeByUserID(ctx context.Context, userID string) error
+	RevokeByUserIDAndClientID(ctx context.Context, userID, clientID string) error
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *entities.Role) error
+	FindByID(ctx context.Context, id string) (*entities.Role, error)
+	FindByName(ctx context.Context, name string) (*entities.Role, error)
+	FindAll(ctx context.Context) ([]*entities.Role, error)
+	Update(ctx context.Context, role *entities.Role) error
+	Delete(ctx context.Context, id string) error
+	FindAllPermissions(ctx context.Context) ([]*entities.Permission, error)
+	FindPermissionsByNames(ctx context.Context, names []string) ([]entities.Permission, error)
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// SetupTokenRepository keeps at most one setup token. Issue stores a token
+// unless one is already pending, or replacing it when asked to, and reports
+// whether it was stored.
+type SetupTokenRepository interface {
+	Issue(ctx context.Context, token *entities.SetupToken, replace bool) (bool, error)
+	Consume(ctx context.Context, tokenHash string) (bool, error)
+	DeleteAll(ctx context.Context) error
+}
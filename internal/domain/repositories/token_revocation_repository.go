@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type TokenRevocationRepository interface {
+	RevokeToken(ctx context.Context, revokedToken *entities.RevokedToken) error
+	RevokeAllForUser(ctx context.Context, revocation *entities.UserTokenRevocation) error
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
@@ -11,8 +11,12 @@ type UserRepository interface {
 	FindByEmail(ctx context.Context, email string) (*entities.User, error)
 	FindByID(ctx context.Context, id string) (*entities.User, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	FindByPhone(ctx context.Context, phone string) (*entities.User, error)
+	ExistsByPhone(ctx context.Context, phone string) (bool, error)
 	Update(ctx context.Context, user *entities.User) error
 	FindAll(ctx context.Context) ([]*entities.User, error)
 	FindAllWithFilters(ctx context.Context, filters *entities.UserFilters) ([]*entities.User, error)
 	Delete(ctx context.Context, id string) error
+	CountByRole(ctx context.Context, role string) (int64, error)
+	FindIDsByRole(ctx context.Context, role string) ([]string, error)
 }
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error
+	FindSubscriptionByID(ctx context.Context, id string) (*entities.WebhookSubscription, error)
+	FindAllSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// Enqueue writes an event to the outbox on its own. Events about changes
+	// to users are written by the UserRepository, in the same transaction.
+	Enqueue(ctx context.Context, event *entities.WebhookEvent) error
+	// FanOut creates the deliveries of up to limit outbox events not yet
+	// dispatched, for the active subscriptions to each, and returns how many
+	// events it processed.
+	FanOut(ctx context.Context, limit int) (int, error)
+	// ClaimDueDeliveries returns pending deliveries due by now, of active
+	// subscriptions, with their subscription loaded. Claimed deliveries are
+	// postponed by lease, so other instances skip them while they are sent.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.WebhookDelivery, error)
+	// SaveAttempt stores the state of a delivery together with the attempt
+	// that led to it.
+	SaveAttempt(ctx context.Context, delivery *entities.WebhookDelivery, attempt *entities.WebhookDeliveryAttempt) error
+	UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error
+	FindDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*entities.WebhookDelivery, error)
+	FindDelivery(ctx context.Context, subscriptionID, deliveryID string) (*entities.WebhookDelivery, error)
+	FindAttempts(ctx context.Context, deliveryID string) ([]*entities.WebhookDeliveryAttempt, error)
+}
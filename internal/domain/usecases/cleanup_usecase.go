@@ -0,0 +1,65 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ExpiringStore is a store whose records stop mattering once they expire.
+type ExpiringStore interface {
+	DeleteExpired(ctx context.Context) error
+}
+
+// CleanupTarget names a store for the logs.
+type CleanupTarget struct {
+	Name  string
+	Store ExpiringStore
+}
+
+// CleanupUseCase deletes expired records, which are ignored once they expire
+// but would otherwise pile up forever.
+type CleanupUseCase struct {
+	targets  []CleanupTarget
+	interval time.Duration
+}
+
+func NewCleanupUseCase(interval time.Duration, targets ...CleanupTarget) *CleanupUseCase {
+	return &CleanupUseCase{
+		targets:  targets,
+		interval: interval,
+	}
+}
+
+// RunWorker deletes expired records every interval until the context is
+// done.
+func (uc *CleanupUseCase) RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(uc.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error deleting expired records: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeleteExpired deletes the expired records of every store. A failing store
+// does not keep the others from being cleaned.
+func (uc *CleanupUseCase) DeleteExpired(ctx context.Context) error {
+	var errs []error
+	for _, target := range uc.targets {
+		if err := target.Store.DeleteExpired(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStore counts the cleanups it gets and fails them with err.
+type countingStore struct {
+	cleanups atomic.Int32
+	err      error
+}
+
+func (s *countingStore) DeleteExpired(ctx context.Context) error {
+	s.cleanups.Add(1)
+	return s.err
+}
+
+func TestCleanupDeleteExpiredGoesOnAfterAFailure(t *testing.T) {
+	failing := &countingStore{err: errors.New("database down")}
+	healthy := &countingStore{}
+	uc := NewCleanupUseCase(time.Hour, CleanupTarget{Name: "failing", Store: failing}, CleanupTarget{Name: "healthy", Store: healthy})
+
+	err := uc.DeleteExpired(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failing: database down") {
+		t.Errorf("got error %v, want the failure of the store named", err)
+	}
+	if failing.cleanups.Load() != 1 || healthy.cleanups.Load() != 1 {
+		t.Errorf("got %d and %d cleanups, want one for each store", failing.cleanups.Load(), healthy.cleanups.Load())
+	}
+}
+
+func TestCleanupRunWorkerCleansUntilCanceled(t *testing.T) {
+	store := &countingStore{}
+	uc := NewCleanupUseCase(10*time.Millisecond, CleanupTarget{Name: "store", Store: store})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		uc.RunWorker(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for store.cleanups.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := store.cleanups.Load(); got < 2 {
+		t.Errorf("got %d cleanups, want one on start and more every interval", got)
+	}
+}
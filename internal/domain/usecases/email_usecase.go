@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+// EmailDispatchConfig controls the background worker: how often it runs, how
+// many emails it sends at once, how long each send may take and how failed
+// sends are retried.
+type EmailDispatchConfig struct {
+	Interval  time.Duration
+	BatchSize int
+	Timeout   time.Duration
+	Retry     entities.EmailRetryPolicy
+}
+
+// EmailUseCase renders emails from templates into the outbox and delivers
+// them, so mail is not lost while the email server is unavailable.
+type EmailUseCase struct {
+	outboxRepo repositories.EmailOutboxRepository
+	sender     services.EmailSender
+	templates  *services.EmailTemplates
+	dispatch   EmailDispatchConfig
+}
+
+func NewEmailUseCase(outboxRepo repositories.EmailOutboxRepository, sender services.EmailSender, templates *services.EmailTemplates, dispatch EmailDispatchConfig) *EmailUseCase {
+	return &EmailUseCase{
+		outboxRepo: outboxRepo,
+		sender:     sender,
+		templates:  templates,
+		dispatch:   dispatch,
+	}
+}
+
+// Enqueue renders the template in the locale and writes the email to the
+// outbox, to be sent by the worker.
+func (uc *EmailUseCase) Enqueue(ctx context.Context, to, locale, template string, data map[string]any) error {
+	message, err := uc.templates.Render(template, locale, data)
+	if err != nil {
+		return err
+	}
+
+	email, err := entities.NewOutgoingEmail(template, to, message.Subject, message.TextBody, message.HTMLBody)
+	if err != nil {
+		return err
+	}
+
+	return uc.outboxRepo.Enqueue(ctx, email)
+}
+
+// RunWorker sends the queued emails every interval until the context is
+// done. Emails already being sent when it is done are finished first.
+func (uc *EmailUseCase) RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(uc.dispatch.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error dispatching emails: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends the emails that are due.
+func (uc *EmailUseCase) Dispatch(ctx context.Context) error {
+	// The lease covers the time to send a whole batch, as emails are sent
+	// concurrently and each is bounded by the timeout.
+	lease := 2 * uc.dispatch.Timeout
+	emails, err := uc.outboxRepo.ClaimDue(ctx, time.Now(), lease, uc.dispatch.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	// A claimed batch is sent even if ctx is canceled meanwhile, so shutting
+	// down does not leave emails half-sent until the lease expires.
+	sendCtx := context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	for _, email := range emails {
+		wg.Add(1)
+		go func(email *entities.OutgoingEmail) {
+			defer wg.Done()
+			uc.send(sendCtx, email)
+		}(email)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (uc *EmailUseCase) send(ctx context.Context, email *entities.OutgoingEmail) {
+	sendCtx, cancel := context.WithTimeout(ctx, uc.dispatch.Timeout)
+	err := uc.sender.Send(sendCtx, &services.EmailMessage{
+		To:       email.Recipient,
+		Subject:  email.Subject,
+		TextBody: email.TextBody,
+		HTMLBody: email.HTMLBody,
+	})
+	cancel()
+
+	var errMessage string
+	if err != nil {
+		errMessage = err.Error()
+	}
+
+	email.RecordAttempt(errMessage, uc.dispatch.Retry, time.Now())
+	if err := uc.outboxRepo.Update(ctx, email); err != nil {
+		log.Printf("Error saving email %s: %v", email.ID, err)
+		return
+	}
+
+	switch email.Status {
+	case entities.OutgoingEmailSent:
+		log.Printf("Email %s sent to %s", email.Template, email.Recipient)
+	case entities.OutgoingEmailDead:
+		log.Printf("Email %s to %s dead-lettered after %d attempts: %s", email.ID, email.Recipient, email.Attempts, errMessage)
+	default:
+		log.Printf("Error sending email %s to %s, retrying at %s: %s", email.ID, email.Recipient, email.NextAttemptAt.Format(time.RFC3339), errMessage)
+	}
+}
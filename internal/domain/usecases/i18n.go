@@ -0,0 +1,46 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/i18n"
+)
+
+// localized returns the message with the code in the locale of the request.
+func localized(ctx context.Context, code string) string {
+	return i18n.Text(entities.LocaleFromContext(ctx), code, nil)
+}
+
+// userLocale returns the language to write to the user in: the one the user
+// chose or, for users who never chose one, the one of the request.
+func userLocale(ctx context.Context, user *entities.User) string {
+	if user.Locale != "" {
+		return user.Locale
+	}
+	return entities.LocaleFromContext(ctx)
+}
+
+// permissionDescription returns the description of a permission in the
+// locale of the request, falling back to the one stored with it.
+func permissionDescription(ctx context.Context, permission *entities.Permission) string {
+	code := "permission_description_" + strings.ReplaceAll(permission.Name, ":", "_")
+	if message, ok := i18n.Lookup(entities.LocaleFromContext(ctx), code, nil); ok {
+		return message
+	}
+	return permission.Description
+}
+
+// roleDescription returns the description of a role, translated to the
+// locale of the request for the system roles. Other roles keep the
+// description they were created with.
+func roleDescription(ctx context.Context, role *entities.Role) string {
+	if !role.System {
+		return role.Description
+	}
+	if message, ok := i18n.Lookup(entities.LocaleFromContext(ctx), "role_description_"+role.Name, nil); ok {
+		return message
+	}
+	return role.Description
+}
@@ -0,0 +1,117 @@
+package usecases
+
+import (
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+type GenerateKeyInput struct {
+	Algorithm string `json:"algorithm"`
+}
+
+type KeyOutput struct {
+	KID       string `json:"kid"`
+	Algorithm string `json:"algorithm"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListKeysOutput struct {
+	Keys []KeyOutput `json:"keys"`
+}
+
+type KeyUseCase struct {
+	keyManager *services.KeyManager
+}
+
+func NewKeyUseCase(jwtService *services.JWTService) *KeyUseCase {
+	return &KeyUseCase{
+		keyManager: jwtService.KeyManager(),
+	}
+}
+
+func (uc *KeyUseCase) GetJWKS() services.JSONWebKeySet {
+	if uc.keyManager == nil {
+		return services.JSONWebKeySet{Keys: []services.JSONWebKey{}}
+	}
+	return uc.keyManager.JWKS()
+}
+
+func (uc *KeyUseCase) ListKeys() (*ListKeysOutput, error) {
+	if uc.keyManager == nil {
+		return nil, entities.NewCodedError("signing_keys_disabled", "asymmetric signing keys are not enabled")
+	}
+
+	keys := uc.keyManager.ListKeys()
+	output := &ListKeysOutput{Keys: make([]KeyOutput, 0, len(keys))}
+	for _, key := range keys {
+		output.Keys = append(output.Keys, toKeyOutput(key))
+	}
+	return output, nil
+}
+
+func (uc *KeyUseCase) GenerateKey(input GenerateKeyInput) (*KeyOutput, error) {
+	if uc.keyManager == nil {
+		return nil, entities.NewCodedError("signing_keys_disabled", "asymmetric signing keys are not enabled")
+	}
+
+	key, err := uc.keyManager.GenerateKey(input.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toKeyOutput(*key)
+	return &output, nil
+}
+
+func (uc *KeyUseCase) PromoteKey(kid string) (*KeyOutput, error) {
+	if uc.keyManager == nil {
+		return nil, entities.NewCodedError("signing_keys_disabled", "asymmetric signing keys are not enabled")
+	}
+
+	if err := uc.keyManager.PromoteKey(kid); err != nil {
+		return nil, err
+	}
+	return uc.findKey(kid)
+}
+
+func (uc *KeyUseCase) RetireKey(kid string) (*KeyOutput, error) {
+	if uc.keyManager == nil {
+		return nil, entities.NewCodedError("signing_keys_disabled", "asymmetric signing keys are not enabled")
+	}
+
+	if err := uc.keyManager.RetireKey(kid); err != nil {
+		return nil, err
+	}
+	return uc.findKey(kid)
+}
+
+// RotateKey generates a key and promotes it immediately. Verifiers that cache
+// the JWKS may briefly reject the new tokens; for a zero-downtime rotation
+// generate the key first and promote it once caches had time to refresh.
+func (uc *KeyUseCase) RotateKey(input GenerateKeyInput) (*KeyOutput, error) {
+	key, err := uc.GenerateKey(input)
+	if err != nil {
+		return nil, err
+	}
+	return uc.PromoteKey(key.KID)
+}
+
+func (uc *KeyUseCase) findKey(kid string) (*KeyOutput, error) {
+	for _, key := range uc.keyManager.ListKeys() {
+		if key.KID == kid {
+			output := toKeyOutput(key)
+			return &output, nil
+		}
+	}
+	return nil, entities.NewCodedError("key_not_found", "key not found")
+}
+
+func toKeyOutput(key services.SigningKey) KeyOutput {
+	return KeyOutput{
+		KID:       key.KID,
+		Algorithm: key.Algorithm,
+		Status:    key.Status,
+		CreatedAt: key.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
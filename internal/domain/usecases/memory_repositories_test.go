@@ -0,0 +1,402 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+	infrarepositories "api-auth-go/internal/infrastructure/repositories"
+	"api-auth-go/internal/infrastructure/services"
+
+	"github.com/google/uuid"
+)
+
+// The stand-ins below keep just enough state in memory for the use cases
+// under test. Each one embeds its repository interface, so calling a method
+// a test does not expect panics instead of silently doing nothing.
+
+type memoryUserRepository struct {
+	repositories.UserRepository
+
+	mu    sync.Mutex
+	users map[string]*entities.User
+}
+
+func newMemoryUserRepository(users ...*entities.User) *memoryUserRepository {
+	repo := &memoryUserRepository{users: map[string]*entities.User{}}
+	for _, user := range users {
+		repo.users[user.ID.String()] = user
+	}
+	return repo
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id string) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *user
+	r.users[user.ID.String()] = &copied
+	return nil
+}
+
+type memoryAuditRepository struct {
+	repositories.AuditEventRepository
+
+	mu     sync.Mutex
+	events []*entities.AuditEvent
+}
+
+func (r *memoryAuditRepository) Append(ctx context.Context, event *entities.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+// reasons returns the reason of every event with the action, in order.
+func (r *memoryAuditRepository) reasons(action string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reasons []string
+	for _, event := range r.events {
+		if event.Action == action {
+			reasons = append(reasons, event.Metadata["reason"])
+		}
+	}
+	return reasons
+}
+
+type memoryEmailOutbox struct {
+	repositories.EmailOutboxRepository
+
+	mu     sync.Mutex
+	emails []*entities.OutgoingEmail
+}
+
+func (r *memoryEmailOutbox) Enqueue(ctx context.Context, email *entities.OutgoingEmail) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.emails = append(r.emails, email)
+	return nil
+}
+
+// sent returns the recipients of the emails made from the template.
+func (r *memoryEmailOutbox) sent(template string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var recipients []string
+	for _, email := range r.emails {
+		if email.Template == template {
+			recipients = append(recipients, email.Recipient)
+		}
+	}
+	return recipients
+}
+
+type memoryRecoveryCodeRepository struct {
+	repositories.MFARecoveryCodeRepository
+
+	mu    sync.Mutex
+	codes []*entities.MFARecoveryCode
+}
+
+func (r *memoryRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID string, codes []*entities.MFARecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.codes[:0]
+	for _, code := range r.codes {
+		if code.UserID.String() != userID {
+			kept = append(kept, code)
+		}
+	}
+	r.codes = append(kept, codes...)
+	return nil
+}
+
+func (r *memoryRecoveryCodeRepository) FindUnusedByHash(ctx context.Context, userID, codeHash string) (*entities.MFARecoveryCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.codes {
+		if code.UserID.String() == userID && code.CodeHash == codeHash && !code.IsUsed() {
+			copied := *code
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRecoveryCodeRepository) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.codes {
+		if code.ID.String() == id && !code.IsUsed() {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryRefreshTokenRepository struct {
+	repositories.RefreshTokenRepository
+
+	mu     sync.Mutex
+	tokens []*entities.RefreshToken
+}
+
+func (r *memoryRefreshTokenRepository) Create(ctx context.Context, refreshToken *entities.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *refreshToken
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRefreshTokenRepository) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.ID.String() == id && !token.Used {
+			now := time.Now()
+			token.Used, token.UsedAt = true, &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.FamilyID.String() == familyID {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID.String() == userID {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeByUserIDAndClientID(ctx context.Context, userID, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID.String() == userID && token.ClientID == clientID {
+			token.Revoked = true
+		}
+	}
+	return nil
+}
+
+// memoryRoleRepository knows no roles, so sessions carry no permissions.
+type memoryRoleRepository struct {
+	repositories.RoleRepository
+}
+
+func (r *memoryRoleRepository) FindByName(ctx context.Context, name string) (*entities.Role, error) {
+	return nil, nil
+}
+
+type memoryWebhookRepository struct {
+	repositories.WebhookRepository
+}
+
+func (r *memoryWebhookRepository) Enqueue(ctx context.Context, event *entities.WebhookEvent) error {
+	return nil
+}
+
+type memoryOAuthClientRepository struct {
+	repositories.OAuthClientRepository
+
+	clients []*entities.OAuthClient
+}
+
+func (r *memoryOAuthClientRepository) FindByClientID(ctx context.Context, clientID string) (*entities.OAuthClient, error) {
+	for _, client := range r.clients {
+		if client.ClientID == clientID {
+			return client, nil
+		}
+	}
+	return nil, nil
+}
+
+type memoryAuthorizationCodeRepository struct {
+	repositories.OAuthAuthorizationCodeRepository
+
+	mu    sync.Mutex
+	codes []*entities.OAuthAuthorizationCode
+}
+
+func (r *memoryAuthorizationCodeRepository) Create(ctx context.Context, code *entities.OAuthAuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *code
+	r.codes = append(r.codes, &copied)
+	return nil
+}
+
+func (r *memoryAuthorizationCodeRepository) FindByCodeHash(ctx context.Context, codeHash string) (*entities.OAuthAuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.codes {
+		if code.CodeHash == codeHash {
+			copied := *code
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryAuthorizationCodeRepository) MarkAsUsed(ctx context.Context, id string, tokenFamilyID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	familyID, err := uuid.Parse(tokenFamilyID)
+	if err != nil {
+		return false, err
+	}
+	for _, code := range r.codes {
+		if code.ID.String() == id && !code.Used {
+			code.Used, code.TokenFamilyID = true, &familyID
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var testPasswordPolicy = entities.BasicPasswordPolicy()
+
+// plainPasswordHasher stores passwords as they are, so tests do not pay for
+// a real key derivation.
+type plainPasswordHasher struct{}
+
+func (plainPasswordHasher) Hash(password string) (string, error) {
+	return "plain$" + password, nil
+}
+
+func (plainPasswordHasher) Verify(password, encodedHash string) (bool, error) {
+	return encodedHash == "plain$"+password, nil
+}
+
+func (plainPasswordHasher) NeedsRehash(encodedHash string) bool {
+	return false
+}
+
+// userUseCaseFixture is a UserUseCase wired to in-memory repositories.
+type userUseCaseFixture struct {
+	*UserUseCase
+	users          *memoryUserRepository
+	audit          *memoryAuditRepository
+	outbox         *memoryEmailOutbox
+	recoveryCodes  *memoryRecoveryCodeRepository
+	refreshTokens  *memoryRefreshTokenRepository
+	loginThrottles repositories.LoginThrottleRepository
+	revocations    repositories.TokenRevocationRepository
+}
+
+func newUserUseCaseFixture(t *testing.T, throttling LoginThrottlingConfig, users ...*entities.User) *userUseCaseFixture {
+	t.Helper()
+
+	templates, err := services.LoadEmailTemplates("", entities.LocalePortuguese, entities.EmailTemplateNames())
+	if err != nil {
+		t.Fatalf("failed to load email templates: %v", err)
+	}
+
+	fixture := &userUseCaseFixture{
+		users:          newMemoryUserRepository(users...),
+		audit:          &memoryAuditRepository{},
+		outbox:         &memoryEmailOutbox{},
+		recoveryCodes:  &memoryRecoveryCodeRepository{},
+		refreshTokens:  &memoryRefreshTokenRepository{},
+		loginThrottles: infrarepositories.NewInMemoryLoginThrottleRepository(),
+		revocations:    infrarepositories.NewInMemoryTokenRevocationRepository(),
+	}
+	emails := NewEmailUseCase(fixture.outbox, nil, templates, EmailDispatchConfig{})
+
+	fixture.UserUseCase = NewUserUseCase(fixture.users, nil, fixture.refreshTokens, fixture.revocations, fixture.recoveryCodes, nil, nil, nil, services.NewJWTService(), nil, emails, plainPasswordHasher{}, testPasswordPolicy, RegistrationConfig{}, PasswordResetConfig{}, fixture.loginThrottles, throttling, &memoryRoleRepository{}, nil, fixture.audit, &memoryWebhookRepository{})
+	return fixture
+}
+
+// newTestUser returns a user with the password, hashed by
+// plainPasswordHasher.
+func newTestUser(t *testing.T, email, password string) *entities.User {
+	t.Helper()
+
+	user, err := entities.NewUser("Test User", email, password, testPasswordPolicy, plainPasswordHasher{})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	user.EmailVerified = true
+	return user
+}
+
+// withClientIP returns a context for a request coming from the IP address.
+func withClientIP(ip string) context.Context {
+	return entities.WithRequestInfo(context.Background(), entities.RequestInfo{IPAddress: ip})
+}
@@ -0,0 +1,507 @@
+package usecases
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+// OAuthError is an error reported to clients with the codes defined by
+// RFC 6749, either in the token endpoint response or in the redirect back
+// from the authorization endpoint.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+type RegisterOAuthClientInput struct {
+	Name                   string   `json:"name" validate:"required"`
+	RedirectURIs           []string `json:"redirect_uris"`
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+	GrantTypes             []string `json:"grant_types" validate:"required"`
+	Scopes                 []string `json:"scopes"`
+	Confidential           bool     `json:"confidential"`
+}
+
+type OAuthClientOutput struct {
+	ClientID               string   `json:"client_id"`
+	ClientSecret           string   `json:"client_secret,omitempty"`
+	Name                   string   `json:"name"`
+	RedirectURIs           []string `json:"redirect_uris"`
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+	GrantTypes             []string `json:"grant_types"`
+	Scopes                 []string `json:"scopes"`
+	Confidential           bool     `json:"confidential"`
+	CreatedAt              string   `json:"created_at"`
+}
+
+type ListOAuthClientsOutput struct {
+	Clients []OAuthClientOutput `json:"clients"`
+}
+
+type DeleteOAuthClientOutput struct {
+	Message string `json:"message"`
+}
+
+type AuthorizeInput struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Nonce               string `form:"nonce"`
+}
+
+type AuthorizeDecisionInput struct {
+	AuthorizeInput
+	Email    string `form:"email"`
+	Password string `form:"password"`
+	MFACode  string `form:"mfa_code"`
+	Decision string `form:"decision"`
+}
+
+type AuthorizePromptOutput struct {
+	ClientName string
+	Scopes     []string
+}
+
+type AuthorizeOutput struct {
+	RedirectURL string
+}
+
+type TokenInput struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type TokenOutput struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+type OAuthUseCase struct {
+	clientRepo       repositories.OAuthClientRepository
+	codeRepo         repositories.OAuthAuthorizationCodeRepository
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	userUseCase      *UserUseCase
+	jwtService       *services.JWTService
+	issuer           string
+}
+
+func NewOAuthUseCase(clientRepo repositories.OAuthClientRepository, codeRepo repositories.OAuthAuthorizationCodeRepository, userRepo repositories.UserRepository, refreshTokenRepo repositories.RefreshTokenRepository, userUseCase *UserUseCase, jwtService *services.JWTService, issuer string) *OAuthUseCase {
+	return &OAuthUseCase{
+		clientRepo:       clientRepo,
+		codeRepo:         codeRepo,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		userUseCase:      userUseCase,
+		jwtService:       jwtService,
+		issuer:           strings.TrimRight(issuer, "/"),
+	}
+}
+
+func (uc *OAuthUseCase) RegisterClient(ctx context.Context, input RegisterOAuthClientInput) (*OAuthClientOutput, error) {
+	client, secret, err := entities.NewOAuthClient(input.Name, input.RedirectURIs, input.PostLogoutRedirectURIs, input.GrantTypes, input.Scopes, input.Confidential)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.clientRepo.Create(ctx, client); err != nil {
+		return nil, err
+	}
+
+	output := toOAuthClientOutput(client)
+	output.ClientSecret = secret
+	return &output, nil
+}
+
+func (uc *OAuthUseCase) ListClients(ctx context.Context) (*ListOAuthClientsOutput, error) {
+	clients, err := uc.clientRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListOAuthClientsOutput{Clients: make([]OAuthClientOutput, 0, len(clients))}
+	for _, client := range clients {
+		output.Clients = append(output.Clients, toOAuthClientOutput(client))
+	}
+	return output, nil
+}
+
+func (uc *OAuthUseCase) DeleteClient(ctx context.Context, clientID string) (*DeleteOAuthClientOutput, error) {
+	client, err := uc.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, entities.NewCodedError("client_not_found", "client not found")
+	}
+
+	if err := uc.clientRepo.Delete(ctx, clientID); err != nil {
+		return nil, err
+	}
+
+	return &DeleteOAuthClientOutput{
+		Message: localized(ctx, "client_deleted"),
+	}, nil
+}
+
+// PrepareAuthorization validates an authorization request. Problems with the
+// client or the redirect URI are returned as plain errors and must be shown to
+// the user, since redirecting would leak the response to an unverified URI.
+// Every other problem is an *OAuthError to be sent back to the client.
+func (uc *OAuthUseCase) PrepareAuthorization(ctx context.Context, input AuthorizeInput) (*AuthorizePromptOutput, error) {
+	client, scope, err := uc.validateAuthorizeRequest(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorizePromptOutput{
+		ClientName: client.Name,
+		Scopes:     strings.Fields(scope),
+	}, nil
+}
+
+// Authorize handles the submitted login and consent form. Invalid credentials
+// are returned as plain errors so the form can be shown again.
+func (uc *OAuthUseCase) Authorize(ctx context.Context, input AuthorizeDecisionInput) (*AuthorizeOutput, error) {
+	client, scope, err := uc.validateAuthorizeRequest(ctx, input.AuthorizeInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Decision != "approve" {
+		return nil, newOAuthError("access_denied", "the user denied the request")
+	}
+
+	user, err := uc.userUseCase.Authenticate(ctx, input.Email, input.Password, input.MFACode)
+	if err != nil {
+		return nil, err
+	}
+
+	code, plainCode, err := entities.NewOAuthAuthorizationCode(client.ClientID, user.ID, input.RedirectURI, scope, input.CodeChallenge, input.CodeChallengeMethod, input.Nonce)
+	if err != nil {
+		return nil, newOAuthError("invalid_request", err.Error())
+	}
+
+	if err := uc.codeRepo.Create(ctx, code); err != nil {
+		return nil, err
+	}
+
+	return &AuthorizeOutput{
+		RedirectURL: BuildAuthorizationRedirect(input.RedirectURI, url.Values{"code": {plainCode}}, input.State),
+	}, nil
+}
+
+func (uc *OAuthUseCase) Token(ctx context.Context, input TokenInput) (*TokenOutput, error) {
+	client, err := uc.authenticateClient(ctx, input.ClientID, input.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.AllowsGrantType(input.GrantType) {
+		if input.GrantType == "" {
+			return nil, newOAuthError("invalid_request", "grant_type is required")
+		}
+		return nil, newOAuthError("unauthorized_client", "the client is not allowed to use this grant type")
+	}
+
+	switch input.GrantType {
+	case entities.GrantTypeAuthorizationCode:
+		return uc.exchangeAuthorizationCode(ctx, client, input)
+	case entities.GrantTypeRefreshToken:
+		return uc.exchangeRefreshToken(ctx, client, input)
+	case entities.GrantTypeClientCredentials:
+		return uc.exchangeClientCredentials(client, input)
+	default:
+		return nil, newOAuthError("unsupported_grant_type", "")
+	}
+}
+
+func (uc *OAuthUseCase) validateAuthorizeRequest(ctx context.Context, input AuthorizeInput) (*entities.OAuthClient, string, error) {
+	if input.ClientID == "" {
+		return nil, "", entities.NewCodedError("client_id_required", "client_id is required")
+	}
+
+	client, err := uc.clientRepo.FindByClientID(ctx, input.ClientID)
+	if err != nil {
+		return nil, "", err
+	}
+	if client == nil {
+		return nil, "", entities.NewCodedError("unknown_client", "unknown client")
+	}
+
+	if !client.AllowsRedirectURI(input.RedirectURI) {
+		return nil, "", entities.NewCodedError("redirect_uri_not_registered", "redirect_uri is not registered for this client")
+	}
+
+	if input.ResponseType != "code" {
+		return nil, "", newOAuthError("unsupported_response_type", "only response_type=code is supported")
+	}
+
+	if !client.AllowsGrantType(entities.GrantTypeAuthorizationCode) {
+		return nil, "", newOAuthError("unauthorized_client", "the client is not allowed to use the authorization code grant")
+	}
+
+	if !client.Confidential && input.CodeChallenge == "" {
+		return nil, "", newOAuthError("invalid_request", "public clients must use PKCE")
+	}
+
+	if err := entities.ValidateCodeChallenge(input.CodeChallenge, input.CodeChallengeMethod); err != nil {
+		return nil, "", newOAuthError("invalid_request", err.Error())
+	}
+
+	scope, err := client.ResolveScope(input.Scope)
+	if err != nil {
+		return nil, "", newOAuthError("invalid_scope", err.Error())
+	}
+
+	return client, scope, nil
+}
+
+func (uc *OAuthUseCase) authenticateClient(ctx context.Context, clientID, clientSecret string) (*entities.OAuthClient, error) {
+	if clientID == "" {
+		return nil, newOAuthError("invalid_client", "client authentication failed")
+	}
+
+	client, err := uc.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, newOAuthError("invalid_client", "client authentication failed")
+	}
+
+	if client.Confidential && !client.CheckSecret(clientSecret) {
+		return nil, newOAuthError("invalid_client", "client authentication failed")
+	}
+	if !client.Confidential && clientSecret != "" {
+		return nil, newOAuthError("invalid_client", "client authentication failed")
+	}
+
+	return client, nil
+}
+
+func (uc *OAuthUseCase) exchangeAuthorizationCode(ctx context.Context, client *entities.OAuthClient, input TokenInput) (*TokenOutput, error) {
+	if input.Code == "" {
+		return nil, newOAuthError("invalid_request", "code is required")
+	}
+
+	code, err := uc.codeRepo.FindByCodeHash(ctx, entities.HashAuthorizationCode(input.Code))
+	if err != nil {
+		return nil, err
+	}
+	if code == nil || code.ClientID != client.ClientID {
+		return nil, newOAuthError("invalid_grant", "invalid authorization code")
+	}
+
+	if code.Used {
+		if code.TokenFamilyID != nil {
+			if err := uc.refreshTokenRepo.RevokeFamily(ctx, code.TokenFamilyID.String()); err != nil {
+				return nil, err
+			}
+		}
+		return nil, newOAuthError("invalid_grant", "invalid authorization code")
+	}
+
+	if code.IsExpired() || code.RedirectURI != input.RedirectURI {
+		return nil, newOAuthError("invalid_grant", "invalid authorization code")
+	}
+
+	if !code.VerifyCodeVerifier(input.CodeVerifier) {
+		return nil, newOAuthError("invalid_grant", "invalid code_verifier")
+	}
+
+	familyID := uuid.New()
+	consumed, err := uc.codeRepo.MarkAsUsed(ctx, code.ID.String(), familyID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, newOAuthError("invalid_grant", "invalid authorization code")
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, code.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.IsDisabled() {
+		return nil, newOAuthError("invalid_grant", "invalid authorization code")
+	}
+
+	output, err := uc.issueUserTokens(ctx, client, user, code.Scope, code.Scope, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasScope(code.Scope, ScopeOpenID) {
+		idToken, err := uc.issueIDToken(client, user, code)
+		if err != nil {
+			return nil, err
+		}
+		output.IDToken = idToken
+	}
+
+	return output, nil
+}
+
+func (uc *OAuthUseCase) exchangeRefreshToken(ctx context.Context, client *entities.OAuthClient, input TokenInput) (*TokenOutput, error) {
+	if input.RefreshToken == "" {
+		return nil, newOAuthError("invalid_request", "refresh_token is required")
+	}
+
+	stored, err := consumeRefreshToken(ctx, uc.refreshTokenRepo, input.RefreshToken, client.ClientID)
+	if err != nil {
+		return nil, newOAuthError("invalid_grant", err.Error())
+	}
+
+	scope := stored.Scope
+	if input.Scope != "" {
+		granted := strings.Fields(stored.Scope)
+		for _, requested := range strings.Fields(input.Scope) {
+			if !containsScope(granted, requested) {
+				return nil, newOAuthError("invalid_scope", "scope exceeds the original grant")
+			}
+		}
+		scope = strings.Join(strings.Fields(input.Scope), " ")
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, stored.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.IsDisabled() {
+		if err := uc.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID.String()); err != nil {
+			return nil, err
+		}
+		return nil, newOAuthError("invalid_grant", "invalid refresh token")
+	}
+
+	// A narrower scope only applies to the new access token; the rotated
+	// refresh token keeps the original grant.
+	return uc.issueUserTokens(ctx, client, user, scope, stored.Scope, stored.FamilyID)
+}
+
+func (uc *OAuthUseCase) exchangeClientCredentials(client *entities.OAuthClient, input TokenInput) (*TokenOutput, error) {
+	if !client.Confidential {
+		return nil, newOAuthError("unauthorized_client", "client_credentials requires a confidential client")
+	}
+
+	scope, err := client.ResolveScope(input.Scope)
+	if err != nil {
+		return nil, newOAuthError("invalid_scope", err.Error())
+	}
+
+	accessToken, err := uc.jwtService.GenerateClientAccessToken(client.ClientID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenOutput{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(services.AccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+func (uc *OAuthUseCase) issueUserTokens(ctx context.Context, client *entities.OAuthClient, user *entities.User, scope, refreshScope string, familyID uuid.UUID) (*TokenOutput, error) {
+	accessToken, err := uc.jwtService.GenerateOAuthAccessToken(user.ID.String(), user.Email, user.Name, user.Role, client.ClientID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &TokenOutput{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(services.AccessTokenTTL.Seconds()),
+		Scope:       scope,
+	}
+
+	if client.AllowsGrantType(entities.GrantTypeRefreshToken) {
+		refreshToken, plain, err := entities.NewClientRefreshToken(user.ID, familyID, client.ClientID, refreshScope)
+		if err != nil {
+			return nil, err
+		}
+		if err := uc.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+			return nil, err
+		}
+		output.RefreshToken = plain
+	}
+
+	return output, nil
+}
+
+// BuildAuthorizationRedirect appends the response parameters and state to the
+// client's redirect URI, preserving any query it was registered with.
+func BuildAuthorizationRedirect(redirectURI string, params url.Values, state string) string {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := parsed.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func toOAuthClientOutput(client *entities.OAuthClient) OAuthClientOutput {
+	return OAuthClientOutput{
+		ClientID:               client.ClientID,
+		Name:                   client.Name,
+		RedirectURIs:           client.RedirectURIList(),
+		PostLogoutRedirectURIs: client.PostLogoutRedirectURIList(),
+		GrantTypes:             client.GrantTypeList(),
+		Scopes:                 client.ScopeList(),
+		Confidential:           client.Confidential,
+		CreatedAt:              client.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
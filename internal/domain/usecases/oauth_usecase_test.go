@@ -0,0 +1,187 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+const testRedirectURI = "https://app.example.com/callback"
+
+// oauthUseCaseFixture is an OAuthUseCase wired to in-memory repositories,
+// with a public client that may use authorization codes and refresh tokens.
+type oauthUseCaseFixture struct {
+	*OAuthUseCase
+	client        *entities.OAuthClient
+	user          *entities.User
+	codes         *memoryAuthorizationCodeRepository
+	refreshTokens *memoryRefreshTokenRepository
+}
+
+func newOAuthUseCaseFixture(t *testing.T) *oauthUseCaseFixture {
+	t.Helper()
+
+	client, _, err := entities.NewOAuthClient("Test App", []string{testRedirectURI}, nil, []string{entities.GrantTypeAuthorizationCode, entities.GrantTypeRefreshToken}, []string{ScopeOpenID, ScopeProfile, ScopeEmail}, false)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	fixture := &oauthUseCaseFixture{
+		client:        client,
+		user:          newTestUser(t, testEmail, testPassword),
+		codes:         &memoryAuthorizationCodeRepository{},
+		refreshTokens: &memoryRefreshTokenRepository{},
+	}
+	fixture.OAuthUseCase = NewOAuthUseCase(&memoryOAuthClientRepository{clients: []*entities.OAuthClient{client}}, fixture.codes, newMemoryUserRepository(fixture.user), fixture.refreshTokens, nil, services.NewJWTService(), "https://auth.example.com")
+	return fixture
+}
+
+// issueCode stores an authorization code for the user, bound to the PKCE
+// verifier, and returns its plain value.
+func (f *oauthUseCaseFixture) issueCode(t *testing.T, scope, verifier, nonce string) string {
+	t.Helper()
+
+	code, plain, err := entities.NewOAuthAuthorizationCode(f.client.ClientID, f.user.ID, testRedirectURI, scope, pkceChallenge(verifier), entities.CodeChallengeMethodS256, nonce)
+	if err != nil {
+		t.Fatalf("failed to create authorization code: %v", err)
+	}
+	f.codes.Create(context.Background(), code)
+	return plain
+}
+
+func (f *oauthUseCaseFixture) exchangeCode(code, verifier string) (*TokenOutput, error) {
+	return f.Token(context.Background(), TokenInput{
+		GrantType:    entities.GrantTypeAuthorizationCode,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: verifier,
+		ClientID:     f.client.ClientID,
+	})
+}
+
+func (f *oauthUseCaseFixture) exchangeRefreshToken(refreshToken string) (*TokenOutput, error) {
+	return f.Token(context.Background(), TokenInput{
+		GrantType:    entities.GrantTypeRefreshToken,
+		RefreshToken: refreshToken,
+		ClientID:     f.client.ClientID,
+	})
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var oauthErr *OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.Code != code {
+		t.Fatalf("got error %v, want %s", err, code)
+	}
+}
+
+var testVerifier = strings.Repeat("verifier-", 6)
+
+func TestTokenVerifiesPKCE(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+	code := f.issueCode(t, ScopeProfile, testVerifier, "")
+
+	for _, verifier := range []string{"", pkceChallenge(testVerifier), strings.Repeat("other-verifier-", 4)} {
+		_, err := f.exchangeCode(code, verifier)
+		assertOAuthError(t, err, "invalid_grant")
+	}
+
+	// A wrong verifier does not burn the code for the client that has the
+	// right one.
+	output, err := f.exchangeCode(code, testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.AccessToken == "" || output.RefreshToken == "" || output.Scope != ScopeProfile {
+		t.Errorf("got %+v, want an access and a refresh token for the profile scope", output)
+	}
+}
+
+func TestTokenCodeReplayRevokesIssuedTokens(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+	code := f.issueCode(t, ScopeProfile, testVerifier, "")
+
+	output, err := f.exchangeCode(code, testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = f.exchangeCode(code, testVerifier)
+	assertOAuthError(t, err, "invalid_grant")
+
+	_, err = f.exchangeRefreshToken(output.RefreshToken)
+	assertOAuthError(t, err, "invalid_grant")
+}
+
+func TestTokenRefreshTokenReuseRevokesFamily(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+
+	first, err := f.exchangeCode(f.issueCode(t, ScopeProfile, testVerifier, ""), testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := f.exchangeRefreshToken(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken {
+		t.Fatal("the refresh token was not rotated")
+	}
+
+	_, err = f.exchangeRefreshToken(first.RefreshToken)
+	assertOAuthError(t, err, "invalid_grant")
+
+	_, err = f.exchangeRefreshToken(second.RefreshToken)
+	assertOAuthError(t, err, "invalid_grant")
+}
+
+func TestTokenRefreshKeepsGrantedScope(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+
+	first, err := f.exchangeCode(f.issueCode(t, "profile email", testVerifier, ""), testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	narrowed, err := f.Token(context.Background(), TokenInput{GrantType: entities.GrantTypeRefreshToken, RefreshToken: first.RefreshToken, Scope: ScopeEmail, ClientID: f.client.ClientID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if narrowed.Scope != ScopeEmail {
+		t.Errorf("got scope %q, want email", narrowed.Scope)
+	}
+
+	// The rotated refresh token still carries the original grant.
+	widened, err := f.exchangeRefreshToken(narrowed.RefreshToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if widened.Scope != "profile email" {
+		t.Errorf("got scope %q, want the original grant", widened.Scope)
+	}
+}
+
+func TestTokenRefreshCannotWidenScope(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+
+	output, err := f.exchangeCode(f.issueCode(t, ScopeProfile, testVerifier, ""), testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = f.Token(context.Background(), TokenInput{GrantType: entities.GrantTypeRefreshToken, RefreshToken: output.RefreshToken, Scope: "profile email", ClientID: f.client.ClientID})
+	assertOAuthError(t, err, "invalid_scope")
+}
@@ -0,0 +1,195 @@
+package usecases
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+const IDTokenTTL = time.Hour
+
+type DiscoveryOutput struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	EndSessionEndpoint                string   `json:"end_session_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+type UserInfoInput struct {
+	UserID   string
+	ClientID string
+	Scope    string
+}
+
+type EndSessionInput struct {
+	IDTokenHint           string `form:"id_token_hint"`
+	PostLogoutRedirectURI string `form:"post_logout_redirect_uri"`
+	State                 string `form:"state"`
+	ClientID              string `form:"client_id"`
+}
+
+type EndSessionOutput struct {
+	RedirectURL string
+}
+
+func (uc *OAuthUseCase) Discovery() DiscoveryOutput {
+	return DiscoveryOutput{
+		Issuer:                            uc.issuer,
+		AuthorizationEndpoint:             uc.issuer + "/oauth/authorize",
+		TokenEndpoint:                     uc.issuer + "/oauth/token",
+		UserInfoEndpoint:                  uc.issuer + "/userinfo",
+		JWKSURI:                           uc.issuer + "/.well-known/jwks.json",
+		EndSessionEndpoint:                uc.issuer + "/oauth/logout",
+		ScopesSupported:                   []string{ScopeOpenID, ScopeProfile, ScopeEmail},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{entities.GrantTypeAuthorizationCode, entities.GrantTypeRefreshToken, entities.GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{uc.jwtService.SigningAlgorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{entities.CodeChallengeMethodS256},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "azp", "name", "email", "email_verified", "updated_at"},
+	}
+}
+
+// UserInfo returns the claims about the token owner allowed by its scope.
+// First-party tokens, issued by our own login, carry no scope and see every
+// claim.
+func (uc *OAuthUseCase) UserInfo(ctx context.Context, input UserInfoInput) (map[string]interface{}, error) {
+	firstParty := input.ClientID == ""
+	if !firstParty && !hasScope(input.Scope, ScopeOpenID) {
+		return nil, newOAuthError("insufficient_scope", "the openid scope is required")
+	}
+
+	if err := entities.ValidateUUID(input.UserID); err != nil {
+		return nil, newOAuthError("invalid_token", "")
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, newOAuthError("invalid_token", "")
+	}
+
+	claims := map[string]interface{}{
+		"sub": user.ID.String(),
+	}
+
+	if firstParty || hasScope(input.Scope, ScopeProfile) {
+		claims["name"] = user.Name
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+
+	if firstParty || hasScope(input.Scope, ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.EmailVerified
+	}
+
+	return claims, nil
+}
+
+// EndSession implements RP-initiated logout. Since the authorization page does
+// not keep a browser session, logging out revokes the refresh tokens the user
+// granted to the client identified by the ID token hint.
+func (uc *OAuthUseCase) EndSession(ctx context.Context, input EndSessionInput) (*EndSessionOutput, error) {
+	clientID := input.ClientID
+	userID := ""
+
+	if input.IDTokenHint != "" {
+		claims, err := uc.jwtService.ParseIDTokenHint(input.IDTokenHint)
+		if err != nil || claims.Issuer != uc.issuer || len(claims.Audience) == 0 {
+			return nil, entities.NewCodedError("invalid_id_token_hint", "invalid id_token_hint")
+		}
+
+		hintClientID := claims.Audience[0]
+		if clientID != "" && clientID != hintClientID {
+			return nil, entities.NewCodedError("client_id_mismatch", "client_id does not match id_token_hint")
+		}
+		clientID = hintClientID
+		userID = claims.Subject
+	}
+
+	var client *entities.OAuthClient
+	if clientID != "" {
+		found, err := uc.clientRepo.FindByClientID(ctx, clientID)
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return nil, entities.NewCodedError("unknown_client", "unknown client")
+		}
+		client = found
+	}
+
+	if input.PostLogoutRedirectURI != "" {
+		if client == nil || !client.AllowsPostLogoutRedirectURI(input.PostLogoutRedirectURI) {
+			return nil, entities.NewCodedError("post_logout_redirect_uri_not_registered", "post_logout_redirect_uri is not registered for this client")
+		}
+	}
+
+	if client != nil && userID != "" {
+		if err := uc.refreshTokenRepo.RevokeByUserIDAndClientID(ctx, userID, client.ClientID); err != nil {
+			return nil, err
+		}
+	}
+
+	output := &EndSessionOutput{}
+	if input.PostLogoutRedirectURI != "" {
+		output.RedirectURL = BuildAuthorizationRedirect(input.PostLogoutRedirectURI, url.Values{}, input.State)
+	}
+	return output, nil
+}
+
+func (uc *OAuthUseCase) issueIDToken(client *entities.OAuthClient, user *entities.User, code *entities.OAuthAuthorizationCode) (string, error) {
+	now := time.Now()
+	claims := services.IDTokenClaims{
+		Nonce:           code.Nonce,
+		AuthTime:        code.AuthTime.Unix(),
+		AuthorizedParty: client.ClientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    uc.issuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{client.ClientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(IDTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	if hasScope(code.Scope, ScopeProfile) {
+		claims.Name = user.Name
+	}
+
+	if hasScope(code.Scope, ScopeEmail) {
+		claims.Email = user.Email
+		claims.EmailVerified = &user.EmailVerified
+	}
+
+	return uc.jwtService.SignIDToken(claims)
+}
+
+func hasScope(scope, wanted string) bool {
+	return containsScope(strings.Fields(scope), wanted)
+}
@@ -0,0 +1,124 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTokenIssuesIDTokenForOpenIDScope(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+
+	output, err := f.exchangeCode(f.issueCode(t, "openid email", testVerifier, "n-0S6_WzA2Mj"), testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, err := f.jwtService.ParseIDTokenHint(output.IDToken)
+	if err != nil {
+		t.Fatalf("got an invalid ID token: %v", err)
+	}
+	if claims.Issuer != "https://auth.example.com" || claims.Subject != f.user.ID.String() {
+		t.Errorf("got issuer %q and subject %q", claims.Issuer, claims.Subject)
+	}
+	if len(claims.Audience) != 1 || claims.Audience[0] != f.client.ClientID || claims.AuthorizedParty != f.client.ClientID {
+		t.Errorf("got audience %v and azp %q, want the client", claims.Audience, claims.AuthorizedParty)
+	}
+	if claims.Nonce != "n-0S6_WzA2Mj" || claims.AuthTime == 0 {
+		t.Errorf("got nonce %q and auth_time %d", claims.Nonce, claims.AuthTime)
+	}
+
+	// Only the claims of the granted scopes are included.
+	if claims.Email != testEmail || claims.EmailVerified == nil || !*claims.EmailVerified {
+		t.Errorf("got email %q, verified %v", claims.Email, claims.EmailVerified)
+	}
+	if claims.Name != "" {
+		t.Errorf("got name %q without the profile scope", claims.Name)
+	}
+}
+
+func TestTokenIssuesNoIDTokenWithoutOpenIDScope(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+
+	output, err := f.exchangeCode(f.issueCode(t, "profile email", testVerifier, ""), testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.IDToken != "" {
+		t.Error("got an ID token without the openid scope")
+	}
+}
+
+func TestUserInfoFiltersClaimsByScope(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+	userID := f.user.ID.String()
+
+	tests := []struct {
+		name       string
+		input      UserInfoInput
+		wantClaims []string
+		wantError  string
+	}{
+		{name: "first-party token", input: UserInfoInput{UserID: userID}, wantClaims: []string{"sub", "name", "updated_at", "email", "email_verified"}},
+		{name: "openid only", input: UserInfoInput{UserID: userID, ClientID: "client", Scope: "openid"}, wantClaims: []string{"sub"}},
+		{name: "profile", input: UserInfoInput{UserID: userID, ClientID: "client", Scope: "openid profile"}, wantClaims: []string{"sub", "name", "updated_at"}},
+		{name: "email", input: UserInfoInput{UserID: userID, ClientID: "client", Scope: "openid email"}, wantClaims: []string{"sub", "email", "email_verified"}},
+		{name: "no openid scope", input: UserInfoInput{UserID: userID, ClientID: "client", Scope: "profile email"}, wantError: "insufficient_scope"},
+		{name: "client token", input: UserInfoInput{UserID: "client", ClientID: "client", Scope: "openid"}, wantError: "invalid_token"},
+		{name: "deleted user", input: UserInfoInput{UserID: "9b2d8c4e-0000-4000-8000-000000000000"}, wantError: "invalid_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := f.UserInfo(context.Background(), tt.input)
+			if tt.wantError != "" {
+				assertOAuthError(t, err, tt.wantError)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(claims) != len(tt.wantClaims) {
+				t.Errorf("got claims %v, want %v", claims, tt.wantClaims)
+			}
+			for _, claim := range tt.wantClaims {
+				if _, ok := claims[claim]; !ok {
+					t.Errorf("missing claim %s", claim)
+				}
+			}
+		})
+	}
+}
+
+func TestEndSessionRevokesRefreshTokensOfTheClient(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+
+	output, err := f.exchangeCode(f.issueCode(t, "openid", testVerifier, ""), testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = f.EndSession(context.Background(), EndSessionInput{IDTokenHint: output.IDToken, ClientID: "other-client"})
+	assertCode(t, err, "client_id_mismatch")
+
+	if _, err := f.EndSession(context.Background(), EndSessionInput{IDTokenHint: output.IDToken}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = f.exchangeRefreshToken(output.RefreshToken)
+	assertOAuthError(t, err, "invalid_grant")
+}
+
+func TestEndSessionRejectsForeignIDTokenHint(t *testing.T) {
+	f := newOAuthUseCaseFixture(t)
+	other := newOAuthUseCaseFixture(t)
+	other.issuer = "https://other.example.com"
+
+	output, err := other.exchangeCode(other.issueCode(t, "openid", testVerifier, ""), testVerifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = f.EndSession(context.Background(), EndSessionInput{IDTokenHint: output.IDToken})
+	assertCode(t, err, "invalid_id_token_hint")
+}
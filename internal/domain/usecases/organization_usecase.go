@@ -0,0 +1,372 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+var (
+	ErrOrganizationNotFound = entities.NewCodedError("organization_not_found", "organization not found")
+	ErrNotMember            = entities.NewCodedError("not_member", "you are not a member of this organization")
+)
+
+type CreateOrganizationInput struct {
+	Name string `json:"name" validate:"required"`
+	Slug string `json:"slug" validate:"required"`
+}
+
+type UpdateOrganizationInput struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type OrganizationOutput struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ListOrganizationsOutput struct {
+	Organizations []OrganizationOutput `json:"organizations"`
+}
+
+type AddMemberInput struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+type MemberOutput struct {
+	UserID   string `json:"user_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joined_at"`
+}
+
+type ListMembersOutput struct {
+	Members []MemberOutput `json:"members"`
+}
+
+type UserOrganizationOutput struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joined_at"`
+}
+
+type ListUserOrganizationsOutput struct {
+	Organizations []UserOrganizationOutput `json:"organizations"`
+}
+
+type DeleteOrganizationOutput struct {
+	Message string `json:"message"`
+}
+
+type RemoveMemberOutput struct {
+	Message string `json:"message"`
+}
+
+// OrganizationUseCase manages organizations and their memberships. Except for
+// ListUserOrganizations, it is meant for super admins: organization admins
+// manage their members through the user routes, scoped to their organization.
+type OrganizationUseCase struct {
+	orgRepo   repositories.OrganizationRepository
+	userRepo  repositories.UserRepository
+	roleRepo  repositories.RoleRepository
+	auditRepo repositories.AuditEventRepository
+}
+
+func NewOrganizationUseCase(orgRepo repositories.OrganizationRepository, userRepo repositories.UserRepository, roleRepo repositories.RoleRepository, auditRepo repositories.AuditEventRepository) *OrganizationUseCase {
+	return &OrganizationUseCase{
+		orgRepo:   orgRepo,
+		userRepo:  userRepo,
+		roleRepo:  roleRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+func (uc *OrganizationUseCase) ListOrganizations(ctx context.Context) (*ListOrganizationsOutput, error) {
+	organizations, err := uc.orgRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListOrganizationsOutput{Organizations: make([]OrganizationOutput, 0, len(organizations))}
+	for _, organization := range organizations {
+		output.Organizations = append(output.Organizations, toOrganizationOutput(organization))
+	}
+	return output, nil
+}
+
+func (uc *OrganizationUseCase) GetOrganization(ctx context.Context, organizationID string) (*OrganizationOutput, error) {
+	organization, err := findOrganization(ctx, uc.orgRepo, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toOrganizationOutput(organization)
+	return &output, nil
+}
+
+func (uc *OrganizationUseCase) CreateOrganization(ctx context.Context, input CreateOrganizationInput) (*OrganizationOutput, error) {
+	organization, err := entities.NewOrganization(input.Name, input.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.orgRepo.FindBySlug(ctx, organization.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, entities.NewCodedError("organization_slug_already_exists", "organization slug already exists")
+	}
+
+	if err := uc.orgRepo.Create(ctx, organization); err != nil {
+		return nil, err
+	}
+
+	output := toOrganizationOutput(organization)
+	return &output, nil
+}
+
+// UpdateOrganization renames an organization. The slug cannot change, since
+// it is used in subdomains and by clients to select the organization.
+func (uc *OrganizationUseCase) UpdateOrganization(ctx context.Context, organizationID string, input UpdateOrganizationInput) (*OrganizationOutput, error) {
+	organization, err := findOrganization(ctx, uc.orgRepo, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, entities.NewCodedError("organization_name_required", "organization name is required")
+	}
+	organization.Name = name
+
+	if err := uc.orgRepo.Update(ctx, organization); err != nil {
+		return nil, err
+	}
+
+	output := toOrganizationOutput(organization)
+	return &output, nil
+}
+
+// DeleteOrganization removes the organization and its memberships. Sessions
+// opened for it stop being refreshed, and the users are kept.
+func (uc *OrganizationUseCase) DeleteOrganization(ctx context.Context, organizationID string) (*DeleteOrganizationOutput, error) {
+	organization, err := findOrganization(ctx, uc.orgRepo, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.orgRepo.Delete(ctx, organization.ID.String()); err != nil {
+		return nil, err
+	}
+
+	return &DeleteOrganizationOutput{
+		Message: localized(ctx, "organization_deleted"),
+	}, nil
+}
+
+func (uc *OrganizationUseCase) ListMembers(ctx context.Context, organizationID string) (*ListMembersOutput, error) {
+	organization, err := findOrganization(ctx, uc.orgRepo, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := uc.orgRepo.FindMembers(ctx, organization.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListMembersOutput{Members: make([]MemberOutput, 0, len(memberships))}
+	for _, membership := range memberships {
+		member := MemberOutput{
+			UserID:   membership.UserID.String(),
+			Role:     membership.Role,
+			JoinedAt: membership.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		if membership.User != nil {
+			member.Name = membership.User.Name
+			member.Email = membership.User.Email
+		}
+		output.Members = append(output.Members, member)
+	}
+	return output, nil
+}
+
+// AddMember adds an existing user, identified by id or email, to the
+// organization. The role defaults to user.
+func (uc *OrganizationUseCase) AddMember(ctx context.Context, organizationID string, input AddMemberInput) (*MemberOutput, error) {
+	organization, err := findOrganization(ctx, uc.orgRepo, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users are global identities, so they are looked up across
+	// organizations.
+	ctx = entities.WithOrganizationID(ctx, "")
+
+	var user *entities.User
+	switch {
+	case input.UserID != "":
+		if err := entities.ValidateUUID(input.UserID); err != nil {
+			return nil, err
+		}
+		user, err = uc.userRepo.FindByID(ctx, input.UserID)
+	case input.Email != "":
+		user, err = uc.userRepo.FindByEmail(ctx, input.Email)
+	default:
+		return nil, entities.NewCodedError("member_required", "user_id or email is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, entities.NewCodedError("user_not_found", "user not found")
+	}
+
+	role := input.Role
+	if role == "" {
+		role = entities.RoleUser
+	}
+
+	membership, err := entities.NewMembership(organization.ID, user.ID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	existingRole, err := uc.roleRepo.FindByName(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	if existingRole == nil {
+		return nil, entities.NewCodedError("role_not_found", "role not found")
+	}
+
+	existing, err := uc.orgRepo.FindMembership(ctx, organization.ID.String(), user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, entities.NewCodedError("already_member", "user is already a member of this organization")
+	}
+
+	if err := uc.orgRepo.AddMember(ctx, membership); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionMemberAdded, "", user.ID.String())
+	event.OrganizationID = organization.ID.String()
+	event.Changes = map[string]entities.AuditChange{"role": {After: membership.Role}}
+	recordAudit(ctx, uc.auditRepo, event)
+
+	return &MemberOutput{
+		UserID:   user.ID.String(),
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     membership.Role,
+		JoinedAt: membership.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// RemoveMember takes a user out of the organization. Access tokens already
+// issued for it stay valid until they expire, but cannot be refreshed.
+func (uc *OrganizationUseCase) RemoveMember(ctx context.Context, organizationID, userID string) (*RemoveMemberOutput, error) {
+	organization, err := findOrganization(ctx, uc.orgRepo, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entities.ValidateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	membership, err := uc.orgRepo.FindMembership(ctx, organization.ID.String(), userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil {
+		return nil, entities.NewCodedError("membership_not_found", "membership not found")
+	}
+
+	if err := uc.orgRepo.RemoveMember(ctx, organization.ID.String(), userID); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionMemberRemoved, "", userID)
+	event.OrganizationID = organization.ID.String()
+	event.Changes = map[string]entities.AuditChange{"role": {Before: membership.Role}}
+	recordAudit(ctx, uc.auditRepo, event)
+
+	return &RemoveMemberOutput{
+		Message: localized(ctx, "member_removed"),
+	}, nil
+}
+
+// ListUserOrganizations lists the organizations the user belongs to, with
+// the user's role in each.
+func (uc *OrganizationUseCase) ListUserOrganizations(ctx context.Context, userID string) (*ListUserOrganizationsOutput, error) {
+	if err := entities.ValidateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	memberships, err := uc.orgRepo.FindMembershipsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListUserOrganizationsOutput{Organizations: make([]UserOrganizationOutput, 0, len(memberships))}
+	for _, membership := range memberships {
+		if membership.Organization == nil {
+			continue
+		}
+		output.Organizations = append(output.Organizations, UserOrganizationOutput{
+			ID:       membership.Organization.ID.String(),
+			Name:     membership.Organization.Name,
+			Slug:     membership.Organization.Slug,
+			Role:     membership.Role,
+			JoinedAt: membership.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+	return output, nil
+}
+
+// findOrganization looks an organization up by id or by slug.
+func findOrganization(ctx context.Context, orgRepo repositories.OrganizationRepository, reference string) (*entities.Organization, error) {
+	if strings.TrimSpace(reference) == "" {
+		return nil, entities.NewCodedError("organization_required", "organization is required")
+	}
+
+	var organization *entities.Organization
+	var err error
+	if entities.ValidateUUID(reference) == nil {
+		organization, err = orgRepo.FindByID(ctx, reference)
+	} else {
+		organization, err = orgRepo.FindBySlug(ctx, reference)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return organization, nil
+}
+
+func toOrganizationOutput(organization *entities.Organization) OrganizationOutput {
+	return OrganizationOutput{
+		ID:        organization.ID.String(),
+		Name:      organization.Name,
+		Slug:      organization.Slug,
+		CreatedAt: organization.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: organization.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
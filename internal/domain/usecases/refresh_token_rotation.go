@@ -0,0 +1,48 @@
+package usecases
+
+import (
+	"context"
+	"log"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+// consumeRefreshToken redeems a refresh token exactly once. Presenting a token
+// that was already used means it leaked, so the whole family issued from the
+// same login is revoked. clientID must match the client the token was issued
+// to; first-party tokens have an empty client.
+func consumeRefreshToken(ctx context.Context, repo repositories.RefreshTokenRepository, token, clientID string) (*entities.RefreshToken, error) {
+	stored, err := repo.FindByTokenHash(ctx, entities.HashRefreshToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.ClientID != clientID {
+		return nil, entities.NewCodedError("invalid_refresh_token", "invalid refresh token")
+	}
+
+	if stored.Used {
+		if err := repo.RevokeFamily(ctx, stored.FamilyID.String()); err != nil {
+			return nil, err
+		}
+		log.Printf("Refresh token reuse detected for user %s, family %s revoked", stored.UserID, stored.FamilyID)
+		return nil, entities.NewCodedError("invalid_refresh_token", "invalid refresh token")
+	}
+
+	if !stored.IsValid() {
+		return nil, entities.NewCodedError("invalid_refresh_token", "invalid refresh token")
+	}
+
+	consumed, err := repo.MarkAsUsed(ctx, stored.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		if err := repo.RevokeFamily(ctx, stored.FamilyID.String()); err != nil {
+			return nil, err
+		}
+		return nil, entities.NewCodedError("invalid_refresh_token", "invalid refresh token")
+	}
+
+	return stored, nil
+}
@@ -0,0 +1,102 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+// issueRefreshToken stores a new refresh token of the family for the client
+// and returns its plain value.
+func issueRefreshToken(t *testing.T, repo *memoryRefreshTokenRepository, familyID uuid.UUID, clientID string) string {
+	t.Helper()
+
+	refreshToken, plain, err := entities.NewClientRefreshToken(uuid.New(), familyID, clientID, "profile")
+	if err != nil {
+		t.Fatalf("failed to create refresh token: %v", err)
+	}
+	repo.Create(context.Background(), refreshToken)
+	return plain
+}
+
+func TestConsumeRefreshTokenIsSingleUse(t *testing.T) {
+	repo := &memoryRefreshTokenRepository{}
+	ctx := context.Background()
+	token := issueRefreshToken(t, repo, uuid.New(), "")
+
+	stored, err := consumeRefreshToken(ctx, repo, token, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.TokenHash != entities.HashRefreshToken(token) {
+		t.Error("got another token than the one consumed")
+	}
+
+	_, err = consumeRefreshToken(ctx, repo, token, "")
+	assertCode(t, err, "invalid_refresh_token")
+}
+
+func TestConsumeRefreshTokenReuseRevokesFamily(t *testing.T) {
+	repo := &memoryRefreshTokenRepository{}
+	ctx := context.Background()
+	familyID, otherFamilyID := uuid.New(), uuid.New()
+
+	stolen := issueRefreshToken(t, repo, familyID, "")
+	if _, err := consumeRefreshToken(ctx, repo, stolen, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rotated := issueRefreshToken(t, repo, familyID, "")
+	unrelated := issueRefreshToken(t, repo, otherFamilyID, "")
+
+	_, err := consumeRefreshToken(ctx, repo, stolen, "")
+	assertCode(t, err, "invalid_refresh_token")
+
+	// The legitimate holder is logged out too, since there is no telling
+	// which of the two presented the token first.
+	_, err = consumeRefreshToken(ctx, repo, rotated, "")
+	assertCode(t, err, "invalid_refresh_token")
+
+	if _, err := consumeRefreshToken(ctx, repo, unrelated, ""); err != nil {
+		t.Errorf("got error %v for a token of another family", err)
+	}
+}
+
+func TestConsumeRefreshTokenChecksClient(t *testing.T) {
+	repo := &memoryRefreshTokenRepository{}
+	ctx := context.Background()
+	token := issueRefreshToken(t, repo, uuid.New(), "client")
+
+	for _, clientID := range []string{"", "other-client"} {
+		_, err := consumeRefreshToken(ctx, repo, token, clientID)
+		assertCode(t, err, "invalid_refresh_token")
+	}
+
+	// Presenting the token with the wrong client does not burn it.
+	if _, err := consumeRefreshToken(ctx, repo, token, "client"); err != nil {
+		t.Errorf("got error %v for the client the token was issued to", err)
+	}
+}
+
+func TestConsumeRefreshTokenRejectsInvalidTokens(t *testing.T) {
+	repo := &memoryRefreshTokenRepository{}
+	ctx := context.Background()
+
+	expired := issueRefreshToken(t, repo, uuid.New(), "")
+	repo.tokens[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+	revokedFamilyID := uuid.New()
+	revoked := issueRefreshToken(t, repo, revokedFamilyID, "")
+	repo.RevokeFamily(ctx, revokedFamilyID.String())
+
+	for name, token := range map[string]string{"unknown": "unknown", "expired": expired, "revoked": revoked} {
+		if _, err := consumeRefreshToken(ctx, repo, token, ""); err == nil {
+			t.Errorf("%s: got no error", name)
+		} else {
+			assertCode(t, err, "invalid_refresh_token")
+		}
+	}
+}
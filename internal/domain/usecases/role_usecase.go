@@ -0,0 +1,304 @@
+package usecases
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+var (
+	ErrSystemRole = entities.NewCodedError("system_role", "system roles cannot be renamed or deleted, and the permissions of the super_admin and admin roles are managed by the system")
+	ErrRoleInUse  = entities.NewCodedError("role_in_use", "role is assigned to users or organization members")
+)
+
+type CreateRoleInput struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleInput struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleOutput struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	System      bool     `json:"system"`
+	Permissions []string `json:"permissions"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type ListRolesOutput struct {
+	Roles []RoleOutput `json:"roles"`
+}
+
+type PermissionOutput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ListPermissionsOutput struct {
+	Permissions []PermissionOutput `json:"permissions"`
+}
+
+type DeleteRoleOutput struct {
+	Message string `json:"message"`
+}
+
+type RoleUseCase struct {
+	roleRepo    repositories.RoleRepository
+	userRepo    repositories.UserRepository
+	orgRepo     repositories.OrganizationRepository
+	userUseCase *UserUseCase
+}
+
+func NewRoleUseCase(roleRepo repositories.RoleRepository, userRepo repositories.UserRepository, orgRepo repositories.OrganizationRepository, userUseCase *UserUseCase) *RoleUseCase {
+	return &RoleUseCase{
+		roleRepo:    roleRepo,
+		userRepo:    userRepo,
+		orgRepo:     orgRepo,
+		userUseCase: userUseCase,
+	}
+}
+
+func (uc *RoleUseCase) ListRoles(ctx context.Context) (*ListRolesOutput, error) {
+	roles, err := uc.roleRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListRolesOutput{Roles: make([]RoleOutput, 0, len(roles))}
+	for _, role := range roles {
+		output.Roles = append(output.Roles, toRoleOutput(ctx, role))
+	}
+	return output, nil
+}
+
+func (uc *RoleUseCase) GetRole(ctx context.Context, roleID string) (*RoleOutput, error) {
+	role, err := uc.findRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toRoleOutput(ctx, role)
+	return &output, nil
+}
+
+// CreateRole creates a custom role. Actors can only grant permissions they
+// hold themselves.
+func (uc *RoleUseCase) CreateRole(ctx context.Context, actor entities.Actor, input CreateRoleInput) (*RoleOutput, error) {
+	role, err := entities.NewRole(input.Name, input.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.roleRepo.FindByName(ctx, role.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, entities.NewCodedError("role_already_exists", "role already exists")
+	}
+
+	role.Permissions, err = uc.grantablePermissions(ctx, actor, input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	output := toRoleOutput(ctx, role)
+	return &output, nil
+}
+
+// UpdateRole replaces the description and permissions of a role. When the
+// permissions change, the sessions of everyone holding the role end, so the
+// new permissions apply from their next login.
+func (uc *RoleUseCase) UpdateRole(ctx context.Context, actor entities.Actor, roleID string, input UpdateRoleInput) (*RoleOutput, error) {
+	role, err := uc.findRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Name == entities.RoleSuperAdmin || role.Name == entities.RoleAdmin {
+		return nil, ErrSystemRole
+	}
+
+	// Permissions the actor does not hold may be kept but not added.
+	held := role.PermissionNames()
+	var added []string
+	for _, name := range input.Permissions {
+		if !slices.Contains(held, name) {
+			added = append(added, name)
+		}
+	}
+	if _, err := uc.grantablePermissions(ctx, actor, added); err != nil {
+		return nil, err
+	}
+
+	permissions, err := uc.permissionsByNames(ctx, input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	permissionsChanged := !equalPermissionNames(held, input.Permissions)
+
+	role.Description = input.Description
+	role.Permissions = permissions
+
+	if err := uc.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+
+	if permissionsChanged {
+		if err := uc.revokeHolderSessions(ctx, role.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	output := toRoleOutput(ctx, role)
+	return &output, nil
+}
+
+func (uc *RoleUseCase) DeleteRole(ctx context.Context, roleID string) (*DeleteRoleOutput, error) {
+	role, err := uc.findRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role.System {
+		return nil, ErrSystemRole
+	}
+
+	// Roles are shared by every organization, so they are counted across
+	// all of them.
+	users, err := uc.userRepo.CountByRole(entities.WithOrganizationID(ctx, ""), role.Name)
+	if err != nil {
+		return nil, err
+	}
+	members, err := uc.orgRepo.CountMembersByRole(ctx, role.Name)
+	if err != nil {
+		return nil, err
+	}
+	if users > 0 || members > 0 {
+		return nil, ErrRoleInUse
+	}
+
+	if err := uc.roleRepo.Delete(ctx, role.ID.String()); err != nil {
+		return nil, err
+	}
+
+	return &DeleteRoleOutput{
+		Message: localized(ctx, "role_deleted"),
+	}, nil
+}
+
+func (uc *RoleUseCase) ListPermissions(ctx context.Context) (*ListPermissionsOutput, error) {
+	permissions, err := uc.roleRepo.FindAllPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListPermissionsOutput{Permissions: make([]PermissionOutput, 0, len(permissions))}
+	for _, permission := range permissions {
+		output.Permissions = append(output.Permissions, PermissionOutput{
+			Name:        permission.Name,
+			Description: permissionDescription(ctx, permission),
+		})
+	}
+	return output, nil
+}
+
+// revokeHolderSessions ends the sessions of the users holding the role,
+// globally or as members of any organization.
+func (uc *RoleUseCase) revokeHolderSessions(ctx context.Context, role string) error {
+	userIDs, err := uc.userRepo.FindIDsByRole(entities.WithOrganizationID(ctx, ""), role)
+	if err != nil {
+		return err
+	}
+	memberIDs, err := uc.orgRepo.FindMemberIDsByRole(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	revoked := map[string]bool{}
+	for _, id := range append(userIDs, memberIDs...) {
+		if revoked[id] {
+			continue
+		}
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return err
+		}
+		if err := uc.userUseCase.revokeUserSessions(ctx, userID); err != nil {
+			return err
+		}
+		revoked[id] = true
+	}
+	return nil
+}
+
+func (uc *RoleUseCase) findRole(ctx context.Context, roleID string) (*entities.Role, error) {
+	if err := entities.ValidateUUID(roleID); err != nil {
+		return nil, err
+	}
+
+	role, err := uc.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, entities.NewCodedError("role_not_found", "role not found")
+	}
+	return role, nil
+}
+
+func (uc *RoleUseCase) grantablePermissions(ctx context.Context, actor entities.Actor, names []string) ([]entities.Permission, error) {
+	if err := entities.ValidatePermissionNames(names); err != nil {
+		return nil, err
+	}
+
+	if !actor.HasAllPermissions(names) {
+		return nil, ErrForbidden
+	}
+
+	return uc.permissionsByNames(ctx, names)
+}
+
+func (uc *RoleUseCase) permissionsByNames(ctx context.Context, names []string) ([]entities.Permission, error) {
+	if err := entities.ValidatePermissionNames(names); err != nil {
+		return nil, err
+	}
+	return uc.roleRepo.FindPermissionsByNames(ctx, names)
+}
+
+func toRoleOutput(ctx context.Context, role *entities.Role) RoleOutput {
+	return RoleOutput{
+		ID:          role.ID.String(),
+		Name:        role.Name,
+		Description: roleDescription(ctx, role),
+		System:      role.System,
+		Permissions: role.PermissionNames(),
+		CreatedAt:   role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// equalPermissionNames tells whether two lists name the same permissions, in
+// any order.
+func equalPermissionNames(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
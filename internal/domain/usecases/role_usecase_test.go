@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+// editableRoleRepository holds a single role that can be updated.
+type editableRoleRepository struct {
+	repositories.RoleRepository
+
+	role *entities.Role
+}
+
+func (r *editableRoleRepository) FindByID(ctx context.Context, id string) (*entities.Role, error) {
+	if r.role.ID.String() != id {
+		return nil, nil
+	}
+	return r.role, nil
+}
+
+func (r *editableRoleRepository) Update(ctx context.Context, role *entities.Role) error {
+	r.role = role
+	return nil
+}
+
+func (r *editableRoleRepository) FindPermissionsByNames(ctx context.Context, names []string) ([]entities.Permission, error) {
+	permissions := make([]entities.Permission, 0, len(names))
+	for _, name := range names {
+		permissions = append(permissions, entities.Permission{ID: uuid.New(), Name: name})
+	}
+	return permissions, nil
+}
+
+// memberRoleRepository holds the organization roles of users by their id.
+type memberRoleRepository struct {
+	repositories.OrganizationRepository
+
+	roles map[string]string
+}
+
+func (r *memberRoleRepository) FindMemberIDsByRole(ctx context.Context, role string) ([]string, error) {
+	var ids []string
+	for id, memberRole := range r.roles {
+		if memberRole == role {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryUserRepository) FindIDsByRole(ctx context.Context, role string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+	for id, user := range r.users {
+		if user.Role == role {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func TestUpdateRoleRevokesTheSessionsOfItsHolders(t *testing.T) {
+	const support = "support"
+	actor := entities.Actor{Permissions: []string{entities.PermissionUsersRead, entities.PermissionUsersWrite}}
+
+	tests := []struct {
+		name        string
+		permissions []string
+		wantRevoked bool
+	}{
+		{"permission removed", []string{}, true},
+		{"permission added", []string{entities.PermissionUsersRead, entities.PermissionUsersWrite}, true},
+		{"same permissions", []string{entities.PermissionUsersRead}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globalHolder := newTestUser(t, "global@example.com", testPassword)
+			globalHolder.Role = support
+			member := newTestUser(t, "member@example.com", testPassword)
+			other := newTestUser(t, "other@example.com", testPassword)
+			users := newUserUseCaseFixture(t, lenientThrottling, globalHolder, member, other)
+
+			role := &entities.Role{
+				ID:          uuid.New(),
+				Name:        support,
+				Permissions: []entities.Permission{{ID: uuid.New(), Name: entities.PermissionUsersRead}},
+			}
+			members := &memberRoleRepository{roles: map[string]string{member.ID.String(): support}}
+			uc := NewRoleUseCase(&editableRoleRepository{role: role}, users.users, members, users.UserUseCase)
+
+			if _, err := uc.UpdateRole(context.Background(), actor, role.ID.String(), UpdateRoleInput{Permissions: tt.permissions}); err != nil {
+				t.Fatalf("UpdateRole failed: %v", err)
+			}
+
+			issuedAt := time.Now().Add(-time.Minute)
+			for _, holder := range []*entities.User{globalHolder, member} {
+				revoked, err := users.revocations.IsRevoked(context.Background(), uuid.NewString(), holder.ID.String(), issuedAt)
+				if err != nil {
+					t.Fatalf("IsRevoked failed: %v", err)
+				}
+				if revoked != tt.wantRevoked {
+					t.Errorf("%s: got revoked %v, want %v", holder.Email, revoked, tt.wantRevoked)
+				}
+			}
+
+			revoked, err := users.revocations.IsRevoked(context.Background(), uuid.NewString(), other.ID.String(), issuedAt)
+			if err != nil {
+				t.Fatalf("IsRevoked failed: %v", err)
+			}
+			if revoked {
+				t.Error("got the session of a user without the role revoked")
+			}
+		})
+	}
+}
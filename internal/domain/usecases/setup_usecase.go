@@ -0,0 +1,187 @@
+package usecases
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+var (
+	ErrSetupComplete     = entities.NewCodedError("setup_complete", "setup is already complete")
+	ErrInvalidSetupToken = entities.NewCodedError("invalid_setup_token", "invalid setup token")
+)
+
+// Outcomes of Bootstrap.
+const (
+	BootstrapComplete     = "complete"
+	BootstrapAdminCreated = "admin_created"
+	BootstrapTokenIssued  = "token_issued"
+	BootstrapTokenPending = "token_pending"
+)
+
+// InitialAdmin is the first admin as configured. An empty email means none
+// is configured and the first admin is created through the setup endpoint.
+type InitialAdmin struct {
+	Name     string
+	Email    string
+	Password string
+}
+
+// BootstrapOutput tells what Bootstrap did. SetupToken is only set when a
+// token was issued, and is not shown again.
+type BootstrapOutput struct {
+	Status     string `json:"status"`
+	AdminEmail string `json:"admin_email,omitempty"`
+	SetupToken string `json:"setup_token,omitempty"`
+}
+
+type SetupInput struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type SetupUseCase struct {
+	userRepo       repositories.UserRepository
+	setupTokenRepo repositories.SetupTokenRepository
+	passwordHasher entities.PasswordHasher
+	passwordPolicy *entities.PasswordPolicy
+	auditRepo      repositories.AuditEventRepository
+}
+
+func NewSetupUseCase(userRepo repositories.UserRepository, setupTokenRepo repositories.SetupTokenRepository, passwordHasher entities.PasswordHasher, passwordPolicy *entities.PasswordPolicy, auditRepo repositories.AuditEventRepository) *SetupUseCase {
+	return &SetupUseCase{
+		userRepo:       userRepo,
+		setupTokenRepo: setupTokenRepo,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		auditRepo:      auditRepo,
+	}
+}
+
+// Bootstrap makes sure the platform can get its first admin. Once a super
+// admin exists it only discards any leftover setup token. Otherwise it
+// creates the configured initial admin or, when there is none, issues a
+// setup token; a pending token is kept unless reissue is set, so it is only
+// shown once.
+func (uc *SetupUseCase) Bootstrap(ctx context.Context, admin InitialAdmin, reissue bool) (*BootstrapOutput, error) {
+	exists, err := uc.adminExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := uc.setupTokenRepo.DeleteAll(ctx); err != nil {
+			return nil, err
+		}
+		return &BootstrapOutput{Status: BootstrapComplete}, nil
+	}
+
+	if admin.Email != "" {
+		user, err := uc.createAdmin(ctx, admin.Name, admin.Email, admin.Password, "config")
+		if err != nil {
+			return nil, err
+		}
+		return &BootstrapOutput{Status: BootstrapAdminCreated, AdminEmail: user.Email}, nil
+	}
+
+	token, plainToken, err := entities.NewSetupToken()
+	if err != nil {
+		return nil, err
+	}
+
+	issued, err := uc.setupTokenRepo.Issue(ctx, token, reissue)
+	if err != nil {
+		return nil, err
+	}
+	if !issued {
+		return &BootstrapOutput{Status: BootstrapTokenPending}, nil
+	}
+	return &BootstrapOutput{Status: BootstrapTokenIssued, SetupToken: plainToken}, nil
+}
+
+// Setup creates the first admin with the setup token. It is refused for good
+// once a super admin exists.
+func (uc *SetupUseCase) Setup(ctx context.Context, input SetupInput) (*CreateUserOutput, error) {
+	exists, err := uc.adminExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrSetupComplete
+	}
+
+	if input.Token == "" {
+		return nil, ErrInvalidSetupToken
+	}
+
+	// The input is checked before the token is consumed, so a rejected
+	// password does not burn it.
+	if err := entities.ValidateName(input.Name); err != nil {
+		return nil, err
+	}
+	if err := entities.ValidateEmail(input.Email); err != nil {
+		return nil, err
+	}
+	if err := uc.passwordPolicy.Validate(input.Password, input.Name, input.Email); err != nil {
+		return nil, err
+	}
+
+	consumed, err := uc.setupTokenRepo.Consume(ctx, entities.HashSetupToken(input.Token))
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidSetupToken
+	}
+
+	user, err := uc.createAdmin(ctx, input.Name, input.Email, input.Password, "setup")
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateUserOutput{
+		ID:        user.ID.String(),
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+func (uc *SetupUseCase) adminExists(ctx context.Context) (bool, error) {
+	admins, err := uc.userRepo.CountByRole(ctx, entities.RoleSuperAdmin)
+	return admins > 0, err
+}
+
+// createAdmin creates a super admin flagged to change its password on the
+// first login, since the password was written in the configuration or typed
+// while provisioning.
+func (uc *SetupUseCase) createAdmin(ctx context.Context, name, email, password, source string) (*entities.User, error) {
+	exists, err := uc.userRepo.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, entities.NewCodedError("email_already_exists", "email already exists")
+	}
+
+	user, err := entities.NewSuperAdminUser(name, email, password, uc.passwordPolicy, uc.passwordHasher)
+	if err != nil {
+		return nil, err
+	}
+	user.MarkEmailVerified()
+	user.RequirePasswordChange()
+
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionUserCreated, "", user.ID.String())
+	event.Changes = entities.DiffUsers(&entities.User{}, user)
+	event.Metadata = map[string]string{"source": source}
+	recordAudit(ctx, uc.auditRepo, event)
+
+	return user, nil
+}
@@ -0,0 +1,276 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+// ErrEmailChangeRequiresConfirmation is returned when users try to change
+// their own email directly instead of confirming the new address.
+var ErrEmailChangeRequiresConfirmation = entities.NewCodedError("email_change_requires_confirmation", "your own email can only be changed by confirming the new address")
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangeEmailOutput struct {
+	Message      string `json:"message"`
+	PendingEmail string `json:"pending_email"`
+	ExpiresAt    string `json:"expires_at"`
+}
+
+type ConfirmEmailChangeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// AccountSessionOutput is returned by account changes that end the other
+// sessions of the user. The caller's session is replaced as well, so it
+// carries the new tokens.
+type AccountSessionOutput struct {
+	Message      string `json:"message"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// ChangePassword replaces the password of a signed in user who knows the
+// current one. Every other session is ended and the user is notified by
+// email.
+func (uc *UserUseCase) ChangePassword(ctx context.Context, userID, organizationID string, input ChangePasswordInput) (*AccountSessionOutput, error) {
+	user, err := uc.findAccountUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed, err := uc.confirmPassword(ctx, user, input.CurrentPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, entities.NewCodedError("invalid_password", "invalid password")
+	}
+
+	if err := uc.passwordPolicy.Validate(input.NewPassword, user.Name, user.Email); err != nil {
+		return nil, err
+	}
+	if user.CheckPassword(uc.passwordHasher, input.NewPassword) {
+		return nil, entities.NewCodedError("password_unchanged", "new password must be different from the current password")
+	}
+
+	if err := user.ChangePassword(uc.passwordHasher, input.NewPassword); err != nil {
+		return nil, err
+	}
+	user.RecordWebhookEvent(entities.WebhookEventUserPasswordChanged)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	output, err := uc.replaceSessions(ctx, user, organizationID, localized(ctx, "password_changed"))
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionPasswordChanged, user.ID.String(), user.ID.String()))
+
+	uc.sendEmail(ctx, user, user.Email, entities.EmailTemplatePasswordChanged, map[string]any{
+		"Name": user.Name,
+	})
+
+	return output, nil
+}
+
+// RequestEmailChange starts changing the email of a signed in user. The new
+// address only replaces the current one once the code sent to it is
+// confirmed, and the current address is told about the request.
+func (uc *UserUseCase) RequestEmailChange(ctx context.Context, userID string, input ChangeEmailInput) (*ChangeEmailOutput, error) {
+	newEmail := strings.TrimSpace(input.NewEmail)
+	if err := entities.ValidateEmail(newEmail); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.findAccountUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed, err := uc.confirmPassword(ctx, user, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, entities.NewCodedError("invalid_password", "invalid password")
+	}
+
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, entities.NewCodedError("email_unchanged", "new email must be different from the current email")
+	}
+
+	exists, err := uc.userRepo.ExistsByEmail(ctx, newEmail)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, entities.NewCodedError("email_already_exists", "email already exists")
+	}
+
+	latest, err := uc.emailChangeRepo.FindLatestByUserID(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.IsValid() && !latest.CanResend() {
+		return nil, ErrVerificationEmailCooldown
+	}
+
+	// Only the latest change can be confirmed, so this one replaces any
+	// change still pending.
+	change, code, err := entities.NewEmailChange(user.ID, newEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.emailChangeRepo.Create(ctx, change); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionEmailChangeRequested, user.ID.String(), user.ID.String())
+	event.Metadata = map[string]string{"new_email": newEmail}
+	recordAudit(ctx, uc.auditRepo, event)
+
+	uc.sendEmail(ctx, user, newEmail, entities.EmailTemplateEmailChangeCode, map[string]any{
+		"Name": user.Name,
+		"Code": code,
+	})
+	// The current address is told, so the owner notices a hijacked session.
+	uc.sendEmail(ctx, user, user.Email, entities.EmailTemplateEmailChangeNotice, map[string]any{
+		"Name":     user.Name,
+		"NewEmail": newEmail,
+	})
+
+	return &ChangeEmailOutput{
+		Message:      localized(ctx, "email_change_code_sent"),
+		PendingEmail: newEmail,
+		ExpiresAt:    change.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// ConfirmEmailChange swaps the email of the user for the pending one once
+// the code sent to it is confirmed, which also verifies the new address.
+// Every other session is ended.
+func (uc *UserUseCase) ConfirmEmailChange(ctx context.Context, userID, organizationID string, input ConfirmEmailChangeInput) (*AccountSessionOutput, error) {
+	if strings.TrimSpace(input.Code) == "" {
+		return nil, entities.NewCodedError("code_required", "code is required")
+	}
+
+	user, err := uc.findAccountUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := entities.NewCodedError("invalid_or_expired_code", "invalid or expired code")
+
+	change, err := uc.emailChangeRepo.FindLatestByUserID(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if change == nil || !change.IsValid() {
+		return nil, invalid
+	}
+	// The attempt is counted before the code is compared, so concurrent
+	// guesses cannot get past the limit.
+	reserved, err := uc.emailChangeRepo.ReserveAttempt(ctx, change.ID.String(), entities.EmailChangeMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved || !change.CheckCode(input.Code) {
+		return nil, invalid
+	}
+
+	// The address may have been taken since the change was requested.
+	exists, err := uc.userRepo.ExistsByEmail(ctx, change.NewEmail)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, entities.NewCodedError("email_already_exists", "email already exists")
+	}
+
+	used, err := uc.emailChangeRepo.MarkAsUsed(ctx, change.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, invalid
+	}
+
+	before := *user
+	user.Email = change.NewEmail
+	user.MarkEmailVerified()
+	user.RecordWebhookEvent(entities.WebhookEventUserEmailChanged)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	output, err := uc.replaceSessions(ctx, user, organizationID, localized(ctx, "email_changed"))
+	if err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionEmailChanged, user.ID.String(), user.ID.String())
+	event.Changes = entities.DiffUsers(&before, user)
+	recordAudit(ctx, uc.auditRepo, event)
+
+	return output, nil
+}
+
+func (uc *UserUseCase) findAccountUser(ctx context.Context, userID string) (*entities.User, error) {
+	if err := entities.ValidateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, entities.NewCodedError("user_not_found", "user not found")
+	}
+	return user, nil
+}
+
+// replaceSessions ends every session of the user and opens a new one for
+// the caller.
+func (uc *UserUseCase) replaceSessions(ctx context.Context, user *entities.User, organizationID, message string) (*AccountSessionOutput, error) {
+	access, err := uc.generateAccessToken(ctx, user, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.revokeOtherSessions(ctx, user.ID, access.id); err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := uc.issueRefreshToken(ctx, user.ID, uuid.New(), organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountSessionOutput{
+		Message:      message,
+		Token:        access.token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(services.AccessTokenTTL.Seconds()),
+	}, nil
+}
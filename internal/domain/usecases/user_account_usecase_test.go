@@ -0,0 +1,69 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAccountChangesThrottleWrongPasswords(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(uc *userUseCaseFixture, userID, password string) error
+	}{
+		{
+			name: "change password",
+			change: func(uc *userUseCaseFixture, userID, password string) error {
+				_, err := uc.ChangePassword(context.Background(), userID, "", ChangePasswordInput{CurrentPassword: password, NewPassword: "another horse battery"})
+				return err
+			},
+		},
+		{
+			name: "request email change",
+			change: func(uc *userUseCaseFixture, userID, password string) error {
+				_, err := uc.RequestEmailChange(context.Background(), userID, ChangeEmailInput{NewEmail: "new@example.com", Password: password})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, testEmail, testPassword)
+			uc := newUserUseCaseFixture(t, delayThrottling, user)
+
+			for i := 0; i < 3; i++ {
+				assertCode(t, tt.change(uc, user.ID.String(), "wrong password"), "invalid_password")
+			}
+
+			// Even the right password waits, and so do logins, as the
+			// failures count against the account.
+			assertThrottled(t, tt.change(uc, user.ID.String(), testPassword), time.Minute)
+			_, err := uc.verifyCredentials(context.Background(), testEmail, testPassword)
+			assertThrottled(t, err, time.Minute)
+		})
+	}
+}
+
+func TestChangePasswordResetsAccountThrottle(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, delayThrottling, user)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := uc.ChangePassword(ctx, user.ID.String(), "", ChangePasswordInput{CurrentPassword: "wrong password", NewPassword: "another horse battery"})
+		assertCode(t, err, "invalid_password")
+	}
+	if _, err := uc.ChangePassword(ctx, user.ID.String(), "", ChangePasswordInput{CurrentPassword: testPassword, NewPassword: "another horse battery"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The counter starts over, so a single failure is not delayed.
+	if _, err := uc.verifyCredentials(ctx, testEmail, "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("got error %v, want invalid credentials", err)
+	}
+	if _, err := uc.verifyCredentials(ctx, testEmail, "another horse battery"); err != nil {
+		t.Errorf("got error %v for the new password", err)
+	}
+}
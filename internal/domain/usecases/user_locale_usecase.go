@@ -0,0 +1,48 @@
+package usecases
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+type UpdateLocaleInput struct {
+	Locale string `json:"locale" validate:"required"`
+}
+
+type LocaleOutput struct {
+	Message string `json:"message"`
+	Locale  string `json:"locale"`
+}
+
+// UpdateLocale changes the language the signed in user's emails and text
+// messages are written in. Responses still follow the Accept-Language of
+// each request.
+func (uc *UserUseCase) UpdateLocale(ctx context.Context, userID string, input UpdateLocaleInput) (*LocaleOutput, error) {
+	user, err := uc.findAccountUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *user
+	if err := user.SetLocale(input.Locale); err != nil {
+		return nil, err
+	}
+
+	if user.Locale != before.Locale {
+		user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+
+		event := entities.NewAuditEvent(entities.AuditActionUserUpdated, user.ID.String(), user.ID.String())
+		event.Changes = entities.DiffUsers(&before, user)
+		recordAudit(ctx, uc.auditRepo, event)
+	}
+
+	return &LocaleOutput{
+		Message: localized(ctx, "locale_updated"),
+		Locale:  user.Locale,
+	}, nil
+}
@@ -0,0 +1,258 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCredentials = entities.NewCodedError("invalid_credentials", "invalid email or password")
+	ErrAccountDisabled    = entities.NewCodedError("account_disabled", "account is disabled")
+)
+
+// LoginThrottlingConfig holds the policies applied to failed logins, counted
+// separately per account and per source IP. The source IP is the one of the
+// request info, which only honours X-Forwarded-For from trusted proxies.
+type LoginThrottlingConfig struct {
+	Account entities.LoginThrottlePolicy
+	IP      entities.LoginThrottlePolicy
+}
+
+// LoginThrottledError is returned while an account or IP address has to wait
+// before trying again. It is the same whether or not the account exists.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts, please try again later"
+}
+
+func (e *LoginThrottledError) ErrorCode() string {
+	return "login_throttled"
+}
+
+func (e *LoginThrottledError) ErrorParams() map[string]string {
+	return nil
+}
+
+type UnlockUserOutput struct {
+	Message string `json:"message"`
+}
+
+// UnlockUser clears the failed login and second factor counters of an
+// account, lifting a lockout before it expires. Counters kept for source IPs
+// are not affected. Within an organization only accounts it owns can be
+// unlocked (see checkAccountOwnership).
+func (uc *UserUseCase) UnlockUser(ctx context.Context, actor entities.Actor, userID string) (*UnlockUserOutput, error) {
+	if err := entities.ValidateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, entities.NewCodedError("user_not_found", "user not found")
+	}
+
+	if err := uc.checkAccountOwnership(ctx, actor, user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.loginThrottleRepo.Reset(ctx, entities.LoginThrottleAccountKey(user.Email)); err != nil {
+		return nil, err
+	}
+	if err := uc.loginThrottleRepo.Reset(ctx, entities.LoginThrottleMFAKey(user.ID.String())); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionUserUnlocked, actor.UserID, user.ID.String()))
+
+	return &UnlockUserOutput{
+		Message: localized(ctx, "user_unlocked"),
+	}, nil
+}
+
+// verifyCredentials checks an email and password under the login throttling
+// rules. Unknown emails are counted and delayed like wrong passwords, and a
+// dummy hash is verified for them, so neither the response nor its timing
+// tells whether the account exists. Disabled accounts are only reported as
+// such once the password is verified.
+func (uc *UserUseCase) verifyCredentials(ctx context.Context, email, password string) (*entities.User, error) {
+	accountKey := entities.LoginThrottleAccountKey(email)
+	attempt, err := uc.reserveLoginAttempt(ctx, accountKey)
+	if err != nil {
+		var throttled *LoginThrottledError
+		if errors.As(err, &throttled) {
+			uc.auditLoginFailure(ctx, email, nil, "throttled")
+		}
+		return nil, err
+	}
+
+	user, err := uc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		uc.passwordHasher.Verify(password, uc.dummyPasswordHash())
+	} else if uc.checkPassword(ctx, user, password) {
+		uc.releaseLoginAttempts(ctx, accountKey)
+		if user.IsDisabled() {
+			uc.auditLoginFailure(ctx, email, user, "disabled")
+			return nil, ErrAccountDisabled
+		}
+		return user, nil
+	}
+
+	uc.auditLoginFailure(ctx, email, user, "invalid_credentials")
+	if err := uc.registerLoginFailure(ctx, attempt, user); err != nil {
+		return nil, err
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// confirmPassword checks the password a signed-in user gives to confirm a
+// sensitive change. It is throttled and counted under the account key like
+// a login, so a stolen session cannot be used to guess the password either.
+func (uc *UserUseCase) confirmPassword(ctx context.Context, user *entities.User, password string) (bool, error) {
+	accountKey := entities.LoginThrottleAccountKey(user.Email)
+	attempt, err := uc.reserveLoginAttempt(ctx, accountKey)
+	if err != nil {
+		return false, err
+	}
+
+	if uc.checkPassword(ctx, user, password) {
+		uc.releaseLoginAttempts(ctx, accountKey)
+		return true, nil
+	}
+
+	if err := uc.registerLoginFailure(ctx, attempt, user); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// auditLoginFailure records a failed login. The user is nil when the email
+// does not belong to an account.
+func (uc *UserUseCase) auditLoginFailure(ctx context.Context, email string, user *entities.User, reason string) {
+	event := entities.NewAuditEvent(entities.AuditActionLoginFailed, "", "")
+	if user != nil {
+		event.ActorID = user.ID.String()
+		event.TargetID = user.ID.String()
+	}
+	event.Metadata = map[string]string{"email": email, "reason": reason}
+	recordAudit(ctx, uc.auditRepo, event)
+}
+
+// checkLoginThrottle returns a LoginThrottledError while the key or the
+// source IP has to wait, and otherwise the counter of the key, if any.
+func (uc *UserUseCase) checkLoginThrottle(ctx context.Context, key string) (*entities.LoginThrottle, error) {
+	now := time.Now()
+	ipAddress := entities.RequestInfoFromContext(ctx).IPAddress
+
+	throttle, err := uc.loginThrottleRepo.Find(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	retryAfter := uc.loginThrottling.Account.RetryAfter(throttle, now)
+
+	if ipAddress != "" {
+		throttle, err := uc.loginThrottleRepo.Find(ctx, entities.LoginThrottleIPKey(ipAddress))
+		if err != nil {
+			return nil, err
+		}
+		if wait := uc.loginThrottling.IP.RetryAfter(throttle, now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return nil, &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return throttle, nil
+}
+
+// reserveLoginAttempt counts an attempt against the key before the secret is
+// verified, so concurrent attempts cannot all pass the check before any of
+// them is counted. An attempt that finds others reserved since its check is
+// judged as if they had just failed, which holds a burst to what the policy
+// allows. The reserved attempt is returned, and released on success by
+// releaseLoginAttempts.
+func (uc *UserUseCase) reserveLoginAttempt(ctx context.Context, key string) (*entities.LoginThrottle, error) {
+	checked, err := uc.checkLoginThrottle(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := uc.loginThrottling.Account
+	attempt, err := uc.loginThrottleRepo.RegisterFailure(ctx, key, policy.Retention())
+	if err != nil {
+		return nil, err
+	}
+
+	checkedFailures := 0
+	if checked != nil {
+		checkedFailures = checked.Failures
+	}
+	if attempt.Failures-1 > checkedFailures {
+		racing := *attempt
+		racing.Failures--
+		if wait := policy.RetryAfter(&racing, attempt.LastFailureAt); wait > 0 {
+			return nil, &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+	return attempt, nil
+}
+
+// releaseLoginAttempts clears the counter of the key once an attempt
+// succeeds.
+func (uc *UserUseCase) releaseLoginAttempts(ctx context.Context, key string) {
+	if err := uc.loginThrottleRepo.Reset(ctx, key); err != nil {
+		log.Printf("Error resetting login throttle: %v", err)
+	}
+}
+
+// registerLoginFailure records that a reserved attempt failed: the source IP
+// is counted too and, on the failure that locks an existing account, its
+// owner is told by email.
+func (uc *UserUseCase) registerLoginFailure(ctx context.Context, attempt *entities.LoginThrottle, user *entities.User) error {
+	if ipAddress := entities.RequestInfoFromContext(ctx).IPAddress; ipAddress != "" {
+		if _, err := uc.loginThrottleRepo.RegisterFailure(ctx, entities.LoginThrottleIPKey(ipAddress), uc.loginThrottling.IP.Retention()); err != nil {
+			return err
+		}
+	}
+
+	policy := uc.loginThrottling.Account
+	if user != nil && policy.MaxFailures > 0 && attempt.Failures == policy.MaxFailures {
+		lockedUntil := attempt.LastFailureAt.Add(policy.LockoutDuration)
+		uc.sendEmail(ctx, user, user.Email, entities.EmailTemplateAccountLocked, map[string]any{
+			"Name":        user.Name,
+			"LockedUntil": lockedUntil.UTC(),
+		})
+	}
+
+	return nil
+}
+
+// dummyPasswordHash returns a hash made with the current settings, verified
+// against when the email is unknown so that lookups take the same time.
+func (uc *UserUseCase) dummyPasswordHash() string {
+	uc.dummyHashOnce.Do(func() {
+		hash, err := uc.passwordHasher.Hash(uuid.NewString())
+		if err != nil {
+			log.Printf("Error creating dummy password hash: %v", err)
+			return
+		}
+		uc.dummyHash = hash
+	})
+	return uc.dummyHash
+}
@@ -0,0 +1,229 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+const (
+	testEmail    = "user@example.com"
+	testPassword = "correct horse battery"
+)
+
+// delayThrottling delays every attempt after the second failure of an
+// account, without ever locking it.
+var delayThrottling = LoginThrottlingConfig{
+	Account: entities.LoginThrottlePolicy{FreeAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, FailureWindow: time.Hour},
+	IP:      entities.LoginThrottlePolicy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, FailureWindow: time.Hour},
+}
+
+// lockoutThrottling locks an account for an hour on its third failure.
+var lockoutThrottling = LoginThrottlingConfig{
+	Account: entities.LoginThrottlePolicy{MaxFailures: 3, LockoutDuration: time.Hour, FailureWindow: time.Hour},
+	IP:      entities.LoginThrottlePolicy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, FailureWindow: time.Hour},
+}
+
+func assertThrottled(t *testing.T, err error, minWait time.Duration) {
+	t.Helper()
+
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("got error %v, want a LoginThrottledError", err)
+	}
+	if throttled.RetryAfter <= minWait-time.Second || throttled.RetryAfter > minWait {
+		t.Errorf("got retry after %v, want about %v", throttled.RetryAfter, minWait)
+	}
+}
+
+func TestVerifyCredentialsDelaysAfterFreeAttempts(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, delayThrottling, user)
+	ctx := withClientIP("192.0.2.1")
+
+	for i := 0; i < 3; i++ {
+		if _, err := uc.verifyCredentials(ctx, testEmail, "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: got error %v, want invalid credentials", i+1, err)
+		}
+	}
+
+	// Even the right password waits for the delay.
+	_, err := uc.verifyCredentials(ctx, testEmail, testPassword)
+	assertThrottled(t, err, time.Minute)
+
+	want := []string{"invalid_credentials", "invalid_credentials", "invalid_credentials", "throttled"}
+	if got := uc.audit.reasons(entities.AuditActionLoginFailed); !slices.Equal(got, want) {
+		t.Errorf("got audited reasons %v, want %v", got, want)
+	}
+}
+
+func TestVerifyCredentialsResetsAccountOnSuccess(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, delayThrottling, user)
+	ctx := context.Background()
+
+	uc.verifyCredentials(ctx, testEmail, "wrong password")
+	uc.verifyCredentials(ctx, testEmail, "wrong password")
+
+	if _, err := uc.verifyCredentials(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	throttle, err := uc.loginThrottles.Find(ctx, entities.LoginThrottleAccountKey(testEmail))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if throttle != nil {
+		t.Errorf("got %d failures left after a successful login, want none", throttle.Failures)
+	}
+}
+
+func TestVerifyCredentialsThrottlesUnknownEmails(t *testing.T) {
+	uc := newUserUseCaseFixture(t, delayThrottling)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := uc.verifyCredentials(ctx, "nobody@example.com", "any password"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: got error %v, want the same error as a wrong password", i+1, err)
+		}
+	}
+
+	_, err := uc.verifyCredentials(ctx, "nobody@example.com", "any password")
+	assertThrottled(t, err, time.Minute)
+}
+
+func TestVerifyCredentialsLocksAccount(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, lockoutThrottling, user)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := uc.verifyCredentials(ctx, testEmail, "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: got error %v, want invalid credentials", i+1, err)
+		}
+	}
+
+	_, err := uc.verifyCredentials(ctx, testEmail, testPassword)
+	assertThrottled(t, err, time.Hour)
+
+	if got := uc.outbox.sent(entities.EmailTemplateAccountLocked); !slices.Equal(got, []string{testEmail}) {
+		t.Errorf("got lock notices for %v, want one for the user", got)
+	}
+}
+
+func TestVerifyCredentialsThrottlesSourceIP(t *testing.T) {
+	uc := newUserUseCaseFixture(t, LoginThrottlingConfig{
+		Account: entities.LoginThrottlePolicy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, FailureWindow: time.Hour},
+		IP:      entities.LoginThrottlePolicy{FreeAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, FailureWindow: time.Hour},
+	}, newTestUser(t, testEmail, testPassword))
+
+	// Guessing across accounts is counted against the address.
+	for i, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if _, err := uc.verifyCredentials(withClientIP("192.0.2.1"), email, "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: got error %v, want invalid credentials", i+1, err)
+		}
+	}
+
+	_, err := uc.verifyCredentials(withClientIP("192.0.2.1"), testEmail, testPassword)
+	assertThrottled(t, err, time.Minute)
+
+	if _, err := uc.verifyCredentials(withClientIP("192.0.2.2"), testEmail, testPassword); err != nil {
+		t.Errorf("got error %v from another address, want the login to succeed", err)
+	}
+}
+
+func TestUnlockUserClearsLockout(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, lockoutThrottling, user)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		uc.verifyCredentials(ctx, testEmail, "wrong password")
+	}
+
+	if _, err := uc.UnlockUser(ctx, entities.Actor{UserID: "admin"}, user.ID.String()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := uc.verifyCredentials(ctx, testEmail, testPassword); err != nil {
+		t.Errorf("got error %v after unlocking, want the login to succeed", err)
+	}
+}
+
+// gatedLoginThrottleRepository makes every lookup wait for the others, so
+// concurrent attempts all pass the check before any of them is counted.
+type gatedLoginThrottleRepository struct {
+	repositories.LoginThrottleRepository
+
+	gate sync.WaitGroup
+}
+
+func (r *gatedLoginThrottleRepository) Find(ctx context.Context, key string) (*entities.LoginThrottle, error) {
+	throttle, err := r.LoginThrottleRepository.Find(ctx, key)
+	r.gate.Done()
+	r.gate.Wait()
+	return throttle, err
+}
+
+// countingPasswordHasher counts the passwords verified.
+type countingPasswordHasher struct {
+	plainPasswordHasher
+
+	verified atomic.Int32
+}
+
+func (h *countingPasswordHasher) Verify(password, encodedHash string) (bool, error) {
+	h.verified.Add(1)
+	return h.plainPasswordHasher.Verify(password, encodedHash)
+}
+
+func TestVerifyCredentialsConcurrentAttemptsStayWithinLockout(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, lockoutThrottling, user)
+	hasher := &countingPasswordHasher{}
+	uc.passwordHasher = hasher
+
+	const attempts = 10
+	gated := &gatedLoginThrottleRepository{LoginThrottleRepository: uc.loginThrottles}
+	gated.gate.Add(attempts)
+	uc.loginThrottleRepo = gated
+
+	var wg sync.WaitGroup
+	var failed, throttled atomic.Int32
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.verifyCredentials(context.Background(), testEmail, "wrong password")
+			var throttledErr *LoginThrottledError
+			switch {
+			case errors.Is(err, ErrInvalidCredentials):
+				failed.Add(1)
+			case errors.As(err, &throttledErr):
+				throttled.Add(1)
+			default:
+				t.Errorf("got error %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	uc.loginThrottleRepo = uc.loginThrottles
+
+	maxFailures := int32(lockoutThrottling.Account.MaxFailures)
+	if got := hasher.verified.Load(); got != maxFailures {
+		t.Errorf("got %d passwords verified, want %d", got, maxFailures)
+	}
+	if failed.Load() != maxFailures || throttled.Load() != attempts-maxFailures {
+		t.Errorf("got %d failed and %d throttled, want %d and %d", failed.Load(), throttled.Load(), maxFailures, attempts-maxFailures)
+	}
+
+	_, err := uc.verifyCredentials(context.Background(), testEmail, testPassword)
+	assertThrottled(t, err, time.Hour)
+}
@@ -0,0 +1,380 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+// maxMFAChallengeAttempts is how many wrong codes an MFA challenge token
+// accepts before it is revoked and the user has to log in again.
+const maxMFAChallengeAttempts = 5
+
+type VerifyMFAInput struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type EnrollMFAOutput struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type ConfirmMFAInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableMFAInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code"`
+}
+
+type MFARecoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Message       string   `json:"message"`
+}
+
+type MFAOutput struct {
+	Message string `json:"message"`
+}
+
+func (uc *UserUseCase) VerifyMFA(ctx context.Context, input VerifyMFAInput) (*LoginOutput, error) {
+	if input.MFAToken == "" {
+		return nil, entities.NewCodedError("mfa_token_required", "mfa token is required")
+	}
+
+	invalidToken := entities.NewCodedError("invalid_mfa_token", "invalid or expired mfa token")
+
+	claims, err := uc.jwtService.ValidateMFAChallengeToken(input.MFAToken)
+	if err != nil {
+		return nil, invalidToken
+	}
+
+	revoked, err := uc.revocationRepo.IsRevoked(ctx, claims.ID, claims.UserID, claims.IssuedAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, invalidToken
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.MFAEnabled {
+		return nil, invalidToken
+	}
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+
+	if err := uc.checkSecondFactor(ctx, user, input.Code, input.RecoveryCode); err != nil {
+		var codedErr *entities.CodedError
+		if errors.As(err, &codedErr) {
+			if err := uc.registerMFAChallengeFailure(ctx, claims); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	// The challenge is single use, so a leaked token cannot be completed
+	// again with a later code.
+	if err := uc.revokeMFAChallenge(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	return uc.completeLogin(ctx, user, claims.OrganizationID)
+}
+
+// registerMFAChallengeFailure counts a wrong code sent with a challenge token
+// and revokes the token after maxMFAChallengeAttempts of them.
+func (uc *UserUseCase) registerMFAChallengeFailure(ctx context.Context, claims *services.Claims) error {
+	throttle, err := uc.loginThrottleRepo.RegisterFailure(ctx, entities.LoginThrottleMFAChallengeKey(claims.ID), services.MFAChallengeTokenTTL)
+	if err != nil {
+		return err
+	}
+	if throttle.Failures < maxMFAChallengeAttempts {
+		return nil
+	}
+	return uc.revokeMFAChallenge(ctx, claims)
+}
+
+func (uc *UserUseCase) revokeMFAChallenge(ctx context.Context, claims *services.Claims) error {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	revokedToken, err := entities.NewRevokedToken(claims.ID, userID, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+	return uc.revocationRepo.RevokeToken(ctx, revokedToken)
+}
+
+func (uc *UserUseCase) EnrollMFA(ctx context.Context, userID string) (*EnrollMFAOutput, error) {
+	user, err := uc.findUserForMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := uc.totpService.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := user.BeginMFAEnrollment(secret); err != nil {
+		return nil, err
+	}
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &EnrollMFAOutput{
+		Secret:     secret,
+		OTPAuthURI: uc.totpService.ProvisioningURI(user.Email, secret),
+	}, nil
+}
+
+func (uc *UserUseCase) ConfirmMFA(ctx context.Context, userID string, input ConfirmMFAInput) (*MFARecoveryCodesOutput, error) {
+	if err := entities.ValidateMFACode(input.Code); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.findUserForMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		return nil, entities.NewCodedError("mfa_already_enabled", "two-factor authentication is already enabled")
+	}
+	if user.MFAPendingSecret == "" {
+		return nil, entities.NewCodedError("mfa_enrollment_not_started", "two-factor enrollment was not started")
+	}
+
+	step, ok := uc.totpService.Validate(user.MFAPendingSecret, input.Code, 0)
+	if !ok {
+		return nil, entities.NewCodedError("invalid_code", "invalid code")
+	}
+
+	if err := user.EnableMFA(step); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := uc.replaceRecoveryCodes(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionMFAEnabled, user.ID.String(), user.ID.String()))
+
+	return &MFARecoveryCodesOutput{
+		RecoveryCodes: recoveryCodes,
+		Message:       localized(ctx, "mfa_enabled"),
+	}, nil
+}
+
+func (uc *UserUseCase) DisableMFA(ctx context.Context, userID string, input DisableMFAInput) (*MFAOutput, error) {
+	user, err := uc.findUserForMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MFAEnabled {
+		return nil, entities.NewCodedError("mfa_not_enabled", "two-factor authentication is not enabled")
+	}
+
+	confirmed, err := uc.confirmPassword(ctx, user, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, entities.NewCodedError("invalid_password", "invalid password")
+	}
+
+	if err := uc.checkSecondFactor(ctx, user, input.Code, ""); err != nil {
+		return nil, err
+	}
+
+	if err := uc.resetMFA(ctx, user); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionMFADisabled, user.ID.String(), user.ID.String()))
+
+	return &MFAOutput{
+		Message: localized(ctx, "mfa_disabled"),
+	}, nil
+}
+
+func (uc *UserUseCase) RegenerateRecoveryCodes(ctx context.Context, userID string, input ConfirmMFAInput) (*MFARecoveryCodesOutput, error) {
+	user, err := uc.findUserForMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.MFAEnabled {
+		return nil, entities.NewCodedError("mfa_not_enabled", "two-factor authentication is not enabled")
+	}
+
+	if err := uc.checkSecondFactor(ctx, user, input.Code, ""); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := uc.replaceRecoveryCodes(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionMFARecoveryCodesReplaced, user.ID.String(), user.ID.String()))
+
+	return &MFARecoveryCodesOutput{
+		RecoveryCodes: recoveryCodes,
+		Message:       localized(ctx, "mfa_recovery_codes_regenerated"),
+	}, nil
+}
+
+// AdminResetMFA turns off two-factor authentication for a user who lost
+// access to it. Within an organization only accounts it owns can be reset
+// (see checkAccountOwnership).
+func (uc *UserUseCase) AdminResetMFA(ctx context.Context, actor entities.Actor, userID string) (*MFAOutput, error) {
+	user, err := uc.findUserForMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkAccountOwnership(ctx, actor, user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.resetMFA(ctx, user); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionMFAReset, actor.UserID, user.ID.String()))
+
+	return &MFAOutput{
+		Message: localized(ctx, "mfa_reset"),
+	}, nil
+}
+
+func (uc *UserUseCase) findUserForMFA(ctx context.Context, userID string) (*entities.User, error) {
+	if err := entities.ValidateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, entities.NewCodedError("user_not_found", "user not found")
+	}
+
+	return user, nil
+}
+
+// checkSecondFactor verifies the second factor of a login, or of a change to
+// two-factor authentication, under the login throttling rules. Wrong codes
+// are counted per user under a key of their own, which a correct password
+// does not reset, so guessing codes is slowed down and eventually locked like
+// guessing passwords.
+func (uc *UserUseCase) checkSecondFactor(ctx context.Context, user *entities.User, code, recoveryCode string) error {
+	key := entities.LoginThrottleMFAKey(user.ID.String())
+	attempt, err := uc.reserveLoginAttempt(ctx, key)
+	if err != nil {
+		var throttled *LoginThrottledError
+		if errors.As(err, &throttled) {
+			uc.auditLoginFailure(ctx, user.Email, user, "throttled")
+		}
+		return err
+	}
+
+	if err := uc.verifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		var codedErr *entities.CodedError
+		if !errors.As(err, &codedErr) {
+			return err
+		}
+
+		uc.auditLoginFailure(ctx, user.Email, user, "invalid_mfa_code")
+		if err := uc.registerLoginFailure(ctx, attempt, user); err != nil {
+			return err
+		}
+		return err
+	}
+
+	uc.releaseLoginAttempts(ctx, key)
+	return nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+// TOTP codes are bound to their time step so the same code cannot be replayed.
+func (uc *UserUseCase) verifySecondFactor(ctx context.Context, user *entities.User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		stored, err := uc.recoveryCodeRepo.FindUnusedByHash(ctx, user.ID.String(), entities.HashMFARecoveryCode(recoveryCode))
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return entities.NewCodedError("invalid_recovery_code", "invalid recovery code")
+		}
+
+		consumed, err := uc.recoveryCodeRepo.MarkAsUsed(ctx, stored.ID.String())
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return entities.NewCodedError("invalid_recovery_code", "invalid recovery code")
+		}
+		return nil
+	}
+
+	if err := entities.ValidateMFACode(code); err != nil {
+		return err
+	}
+
+	step, ok := uc.totpService.Validate(user.MFASecret, code, user.MFALastUsedStep)
+	if !ok {
+		return entities.NewCodedError("invalid_code", "invalid code")
+	}
+
+	user.MFALastUsedStep = step
+	return uc.userRepo.Update(ctx, user)
+}
+
+func (uc *UserUseCase) replaceRecoveryCodes(ctx context.Context, user *entities.User) ([]string, error) {
+	codes, plain, err := entities.NewMFARecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.recoveryCodeRepo.ReplaceForUser(ctx, user.ID.String(), codes); err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
+
+func (uc *UserUseCase) resetMFA(ctx context.Context, user *entities.User) error {
+	user.DisableMFA()
+	user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return uc.recoveryCodeRepo.DeleteByUserID(ctx, user.ID.String())
+}
@@ -0,0 +1,186 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// lenientThrottling never delays nor locks, so only the per-challenge limit
+// applies.
+var lenientThrottling = LoginThrottlingConfig{
+	Account: entities.LoginThrottlePolicy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, FailureWindow: time.Hour},
+	IP:      entities.LoginThrottlePolicy{FreeAttempts: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, FailureWindow: time.Hour},
+}
+
+// wrongTOTPCode is a well formed code that the tests treat as wrong; the
+// chance of it being the current code for testMFASecret is negligible.
+const wrongTOTPCode = "000000"
+
+const testMFASecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+// newMFAUser returns a user with two-factor authentication on and its
+// recovery codes.
+func newMFAUser(t *testing.T, uc *userUseCaseFixture) (*entities.User, []string) {
+	t.Helper()
+
+	user := newTestUser(t, testEmail, testPassword)
+	user.MFAEnabled = true
+	user.MFASecret = testMFASecret
+	uc.users.Update(context.Background(), user)
+
+	recoveryCodes, err := uc.replaceRecoveryCodes(context.Background(), user)
+	if err != nil {
+		t.Fatalf("failed to create recovery codes: %v", err)
+	}
+	return user, recoveryCodes
+}
+
+func assertCode(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var codedErr *entities.CodedError
+	if !errors.As(err, &codedErr) || codedErr.Code != code {
+		t.Fatalf("got error %v, want %s", err, code)
+	}
+}
+
+func TestCheckSecondFactorRecoveryCodesAreSingleUse(t *testing.T) {
+	uc := newUserUseCaseFixture(t, lenientThrottling)
+	user, recoveryCodes := newMFAUser(t, uc)
+	ctx := context.Background()
+
+	if err := uc.checkSecondFactor(ctx, user, "", recoveryCodes[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertCode(t, uc.checkSecondFactor(ctx, user, "", recoveryCodes[0]), "invalid_recovery_code")
+
+	if err := uc.checkSecondFactor(ctx, user, "", recoveryCodes[1]); err != nil {
+		t.Errorf("got error %v for another unused code", err)
+	}
+}
+
+func TestCheckSecondFactorThrottlesWrongCodes(t *testing.T) {
+	uc := newUserUseCaseFixture(t, delayThrottling)
+	user, recoveryCodes := newMFAUser(t, uc)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		assertCode(t, uc.checkSecondFactor(ctx, user, wrongTOTPCode, ""), "invalid_code")
+	}
+
+	// A correct password resets the account counter, but not the one of the
+	// second factor.
+	if _, err := uc.verifyCredentials(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := uc.checkSecondFactor(ctx, user, "", recoveryCodes[0])
+	assertThrottled(t, err, time.Minute)
+
+	want := []string{"invalid_mfa_code", "invalid_mfa_code", "invalid_mfa_code", "throttled"}
+	if got := uc.audit.reasons(entities.AuditActionLoginFailed); !slices.Equal(got, want) {
+		t.Errorf("got audited reasons %v, want %v", got, want)
+	}
+}
+
+func TestVerifyMFAChallengeIsSingleUse(t *testing.T) {
+	uc := newUserUseCaseFixture(t, lenientThrottling)
+	user, recoveryCodes := newMFAUser(t, uc)
+	ctx := context.Background()
+
+	challenge, err := uc.jwtService.GenerateMFAChallengeToken(user.ID.String(), user.Email, user.Name, user.Role, "")
+	if err != nil {
+		t.Fatalf("failed to create challenge: %v", err)
+	}
+
+	output, err := uc.VerifyMFA(ctx, VerifyMFAInput{MFAToken: challenge, RecoveryCode: recoveryCodes[0]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Token == "" || output.RefreshToken == "" {
+		t.Error("got no session after a correct second factor")
+	}
+
+	_, err = uc.VerifyMFA(ctx, VerifyMFAInput{MFAToken: challenge, RecoveryCode: recoveryCodes[1]})
+	assertCode(t, err, "invalid_mfa_token")
+}
+
+func TestVerifyMFAChallengeIsRevokedAfterTooManyWrongCodes(t *testing.T) {
+	uc := newUserUseCaseFixture(t, lenientThrottling)
+	user, recoveryCodes := newMFAUser(t, uc)
+	ctx := context.Background()
+
+	challenge, err := uc.jwtService.GenerateMFAChallengeToken(user.ID.String(), user.Email, user.Name, user.Role, "")
+	if err != nil {
+		t.Fatalf("failed to create challenge: %v", err)
+	}
+
+	for i := 0; i < maxMFAChallengeAttempts; i++ {
+		_, err := uc.VerifyMFA(ctx, VerifyMFAInput{MFAToken: challenge, Code: wrongTOTPCode})
+		assertCode(t, err, "invalid_code")
+	}
+
+	_, err = uc.VerifyMFA(ctx, VerifyMFAInput{MFAToken: challenge, RecoveryCode: recoveryCodes[0]})
+	assertCode(t, err, "invalid_mfa_token")
+}
+
+func TestDisableMFAThrottlesWrongPasswords(t *testing.T) {
+	uc := newUserUseCaseFixture(t, delayThrottling)
+	user, _ := newMFAUser(t, uc)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := uc.DisableMFA(ctx, user.ID.String(), DisableMFAInput{Password: "wrong password", Code: wrongTOTPCode})
+		assertCode(t, err, "invalid_password")
+	}
+
+	// The failures count against the account, so logins wait too.
+	_, err := uc.DisableMFA(ctx, user.ID.String(), DisableMFAInput{Password: testPassword, Code: wrongTOTPCode})
+	assertThrottled(t, err, time.Minute)
+	_, err = uc.verifyCredentials(ctx, testEmail, testPassword)
+	assertThrottled(t, err, time.Minute)
+}
+
+func TestMFAChangesThrottleWrongCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(uc *userUseCaseFixture, user *entities.User, code string) error
+	}{
+		{
+			name: "disable",
+			change: func(uc *userUseCaseFixture, user *entities.User, code string) error {
+				_, err := uc.DisableMFA(context.Background(), user.ID.String(), DisableMFAInput{Password: testPassword, Code: code})
+				return err
+			},
+		},
+		{
+			name: "regenerate recovery codes",
+			change: func(uc *userUseCaseFixture, user *entities.User, code string) error {
+				_, err := uc.RegenerateRecoveryCodes(context.Background(), user.ID.String(), ConfirmMFAInput{Code: code})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newUserUseCaseFixture(t, delayThrottling)
+			user, recoveryCodes := newMFAUser(t, uc)
+
+			for i := 0; i < 3; i++ {
+				assertCode(t, tt.change(uc, user, wrongTOTPCode), "invalid_code")
+			}
+
+			assertThrottled(t, tt.change(uc, user, wrongTOTPCode), time.Minute)
+
+			// The counter is the one of login second factors.
+			err := uc.checkSecondFactor(context.Background(), user, "", recoveryCodes[0])
+			assertThrottled(t, err, time.Minute)
+		})
+	}
+}
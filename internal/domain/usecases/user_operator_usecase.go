@@ -0,0 +1,214 @@
+package usecases
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// The operations below back the operator command line. They are run by
+// whoever has access to the database and the configuration, so they are not
+// subject to permission checks, but they are audited like their API
+// counterparts. Users are referenced by id or email.
+
+// FindUser returns a user by id or email.
+func (uc *UserUseCase) FindUser(ctx context.Context, reference string) (*UserOutput, error) {
+	user, err := uc.findUserByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toUserOutput(user)
+	return &output, nil
+}
+
+// SearchUsers lists the users matching the filters. Unlike ListUsers it does
+// not depend on an actor.
+func (uc *UserUseCase) SearchUsers(ctx context.Context, filters *entities.UserFilters) (*ListUsersOutput, error) {
+	if err := entities.ValidateUserFilters(filters); err != nil {
+		return nil, err
+	}
+
+	users, err := uc.userRepo.FindAllWithFilters(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListUsersOutput{Users: make([]UserOutput, 0, len(users)), Page: filters.Page, Limit: filters.Limit}
+	for _, user := range users {
+		output.Users = append(output.Users, toUserOutput(user))
+	}
+	output.Total = len(output.Users)
+	return output, nil
+}
+
+// SetUserRole gives a user another global role and revokes its sessions, like
+// a role change made through the API. The last super admin cannot be demoted.
+func (uc *UserUseCase) SetUserRole(ctx context.Context, reference, roleName string) (*UserOutput, error) {
+	if err := entities.ValidateRole(roleName); err != nil {
+		return nil, err
+	}
+
+	role, err := uc.roleRepo.FindByName(ctx, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, entities.NewCodedError("role_not_found", "role not found")
+	}
+
+	user, err := uc.findUserByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == roleName {
+		output := toUserOutput(user)
+		return &output, nil
+	}
+
+	if err := uc.ensureNotLastAdmin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	before := *user
+	user.Role = roleName
+	user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionUserUpdated, "", user.ID.String())
+	event.Changes = entities.DiffUsers(&before, user)
+	recordAudit(ctx, uc.auditRepo, event)
+
+	output := toUserOutput(user)
+	return &output, nil
+}
+
+// DisableUser blocks a user from logging in and ends its sessions. The
+// account and its data are kept, so it can be enabled again.
+func (uc *UserUseCase) DisableUser(ctx context.Context, reference string) (*UserOutput, error) {
+	user, err := uc.findUserByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsDisabled() {
+		user.Disable()
+		user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+
+		recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionUserDisabled, "", user.ID.String()))
+	}
+
+	// Sessions are revoked even when the user was already disabled, in case a
+	// previous attempt failed half way.
+	if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	output := toUserOutput(user)
+	return &output, nil
+}
+
+func (uc *UserUseCase) EnableUser(ctx context.Context, reference string) (*UserOutput, error) {
+	user, err := uc.findUserByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsDisabled() {
+		user.Enable()
+		user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+
+		recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionUserEnabled, "", user.ID.String()))
+	}
+
+	output := toUserOutput(user)
+	return &output, nil
+}
+
+// SetUserPassword replaces the password of a user under the password policy,
+// revoking its sessions and clearing any login lockout. A temporary password
+// must be changed on the next login.
+func (uc *UserUseCase) SetUserPassword(ctx context.Context, reference, password string, temporary bool) (*UserOutput, error) {
+	user, err := uc.findUserByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.passwordPolicy.Validate(password, user.Name, user.Email); err != nil {
+		return nil, err
+	}
+
+	if err := user.ChangePassword(uc.passwordHasher, password); err != nil {
+		return nil, err
+	}
+	if temporary {
+		user.RequirePasswordChange()
+	}
+	user.RecordWebhookEvent(entities.WebhookEventUserPasswordReset)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.loginThrottleRepo.Reset(ctx, entities.LoginThrottleAccountKey(user.Email)); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionPasswordResetCompleted, "", user.ID.String()))
+
+	output := toUserOutput(user)
+	return &output, nil
+}
+
+// RevokeUserSessions revokes every access and refresh token of a user.
+func (uc *UserUseCase) RevokeUserSessions(ctx context.Context, reference string) (*UserOutput, error) {
+	user, err := uc.findUserByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionLogoutAll, "", user.ID.String()))
+
+	output := toUserOutput(user)
+	return &output, nil
+}
+
+func (uc *UserUseCase) findUserByReference(ctx context.Context, reference string) (*entities.User, error) {
+	var user *entities.User
+	var err error
+	if entities.ValidateUUID(reference) == nil {
+		user, err = uc.userRepo.FindByID(ctx, reference)
+	} else {
+		user, err = uc.userRepo.FindByEmail(ctx, reference)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, entities.NewCodedError("user_not_found", "user not found")
+	}
+	return user, nil
+}
@@ -0,0 +1,95 @@
+package usecases
+
+import (
+	"context"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+var (
+	// ErrPasswordChangeRequired is returned to flows that cannot hand out a
+	// password change token, like refreshing a session.
+	ErrPasswordChangeRequired     = entities.NewCodedError("password_change_required", "password change required")
+	ErrInvalidPasswordChangeToken = entities.NewCodedError("invalid_password_change_token", "invalid or expired password change token")
+)
+
+type ChangeRequiredPasswordInput struct {
+	PasswordChangeToken string `json:"password_change_token" validate:"required"`
+	NewPassword         string `json:"new_password" validate:"required"`
+}
+
+// passwordChangeRequired tells whether the user must choose a new password
+// before getting a session: an admin set it, or it is older than allowed.
+func (uc *UserUseCase) passwordChangeRequired(user *entities.User) bool {
+	return user.MustChangePassword || user.PasswordExpired(uc.passwordPolicy.MaxAge)
+}
+
+// passwordChangeChallenge answers a login whose password must be changed
+// with a token that only ChangeRequiredPassword accepts, instead of a session.
+func (uc *UserUseCase) passwordChangeChallenge(ctx context.Context, user *entities.User, organizationID string) (*LoginOutput, error) {
+	role, err := uc.sessionRole(ctx, user, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := uc.jwtService.GeneratePasswordChangeToken(user.ID.String(), user.Email, user.Name, role, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginOutput{
+		ID:                     user.ID.String(),
+		Name:                   user.Name,
+		Email:                  user.Email,
+		Role:                   role,
+		OrganizationID:         organizationID,
+		CreatedAt:              user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		PasswordChangeRequired: true,
+		PasswordChangeToken:    token,
+	}, nil
+}
+
+// ChangeRequiredPassword sets the new password of a user whose login asked
+// for one and completes that login. The token stops working once the change
+// is no longer required, so it cannot set the password twice.
+func (uc *UserUseCase) ChangeRequiredPassword(ctx context.Context, input ChangeRequiredPasswordInput) (*LoginOutput, error) {
+	if input.PasswordChangeToken == "" {
+		return nil, entities.NewCodedError("password_change_token_required", "password change token is required")
+	}
+
+	claims, err := uc.jwtService.ValidatePasswordChangeToken(input.PasswordChangeToken)
+	if err != nil {
+		return nil, ErrInvalidPasswordChangeToken
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !uc.passwordChangeRequired(user) {
+		return nil, ErrInvalidPasswordChangeToken
+	}
+	if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+
+	if err := uc.passwordPolicy.Validate(input.NewPassword, user.Name, user.Email); err != nil {
+		return nil, err
+	}
+	if user.CheckPassword(uc.passwordHasher, input.NewPassword) {
+		return nil, entities.NewCodedError("password_unchanged", "new password must be different from the current password")
+	}
+
+	if err := user.ChangePassword(uc.passwordHasher, input.NewPassword); err != nil {
+		return nil, err
+	}
+	user.RecordWebhookEvent(entities.WebhookEventUserPasswordChanged)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionPasswordChanged, user.ID.String(), user.ID.String()))
+
+	return uc.openSession(ctx, user, claims.OrganizationID, true)
+}
@@ -0,0 +1,242 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+var ErrVerificationSMSCooldown = entities.NewCodedError("verification_sms_cooldown", "a verification SMS was sent recently, please wait before requesting another one")
+
+type ChangePhoneInput struct {
+	Phone    string `json:"phone" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePhoneOutput struct {
+	Message      string `json:"message"`
+	PendingPhone string `json:"pending_phone"`
+	ExpiresAt    string `json:"expires_at"`
+}
+
+type ConfirmPhoneInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type RemovePhoneInput struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type PhoneOutput struct {
+	Message string `json:"message"`
+	Phone   string `json:"phone,omitempty"`
+}
+
+// RequestPhoneVerification sends a code by SMS to the phone a signed in user
+// wants to add. The phone can receive password resets, so the password is
+// asked for, and it only replaces the current one once the code is
+// confirmed.
+func (uc *UserUseCase) RequestPhoneVerification(ctx context.Context, userID string, input ChangePhoneInput) (*ChangePhoneOutput, error) {
+	phone := strings.TrimSpace(input.Phone)
+	if err := entities.ValidatePhone(phone); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.findAccountUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed, err := uc.confirmPassword(ctx, user, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, entities.NewCodedError("invalid_password", "invalid password")
+	}
+
+	if phone == user.PhoneNumber() {
+		return nil, entities.NewCodedError("phone_already_verified", "phone is already verified")
+	}
+
+	exists, err := uc.userRepo.ExistsByPhone(ctx, phone)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, entities.NewCodedError("phone_already_in_use", "phone already in use")
+	}
+
+	latest, err := uc.phoneVerificationRepo.FindLatestByUserID(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.IsValid() && !latest.CanResend() {
+		return nil, ErrVerificationSMSCooldown
+	}
+
+	// Only the latest verification can be confirmed, so this one replaces
+	// any still pending.
+	verification, code, err := entities.NewPhoneVerification(user.ID, phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.phoneVerificationRepo.Create(ctx, verification); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionPhoneVerificationSent, user.ID.String(), user.ID.String())
+	event.Metadata = map[string]string{"phone": phone}
+	recordAudit(ctx, uc.auditRepo, event)
+
+	locale := userLocale(ctx, user)
+	uc.sendSMS(ctx, "phone verification", func(ctx context.Context) error {
+		return uc.smsService.SendPhoneVerificationSMS(ctx, phone, locale, code)
+	})
+
+	return &ChangePhoneOutput{
+		Message:      localized(ctx, "phone_verification_sent"),
+		PendingPhone: phone,
+		ExpiresAt:    verification.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// ConfirmPhone stores the pending phone once the code sent to it is
+// confirmed, and tells the user by email.
+func (uc *UserUseCase) ConfirmPhone(ctx context.Context, userID string, input ConfirmPhoneInput) (*PhoneOutput, error) {
+	if strings.TrimSpace(input.Code) == "" {
+		return nil, entities.NewCodedError("code_required", "code is required")
+	}
+
+	user, err := uc.findAccountUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := entities.NewCodedError("invalid_or_expired_code", "invalid or expired code")
+
+	verification, err := uc.phoneVerificationRepo.FindLatestByUserID(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if verification == nil || !verification.IsValid() {
+		return nil, invalid
+	}
+	// The attempt is counted before the code is compared, so concurrent
+	// guesses cannot get past the limit.
+	reserved, err := uc.phoneVerificationRepo.ReserveAttempt(ctx, verification.ID.String(), entities.PhoneVerificationMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved || !verification.CheckCode(input.Code) {
+		return nil, invalid
+	}
+
+	// The phone may have been taken since the code was sent.
+	exists, err := uc.userRepo.ExistsByPhone(ctx, verification.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, entities.NewCodedError("phone_already_in_use", "phone already in use")
+	}
+
+	used, err := uc.phoneVerificationRepo.MarkAsUsed(ctx, verification.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, invalid
+	}
+
+	before := *user
+	user.SetVerifiedPhone(verification.Phone)
+	user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionPhoneVerified, user.ID.String(), user.ID.String())
+	event.Changes = entities.DiffUsers(&before, user)
+	recordAudit(ctx, uc.auditRepo, event)
+
+	uc.notifyPhoneChanged(ctx, user)
+
+	return &PhoneOutput{
+		Message: localized(ctx, "phone_confirmed"),
+		Phone:   user.PhoneNumber(),
+	}, nil
+}
+
+// RemovePhone removes the phone of a signed in user, who then no longer
+// receives password resets by SMS.
+func (uc *UserUseCase) RemovePhone(ctx context.Context, userID string, input RemovePhoneInput) (*PhoneOutput, error) {
+	user, err := uc.findAccountUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed, err := uc.confirmPassword(ctx, user, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, entities.NewCodedError("invalid_password", "invalid password")
+	}
+
+	if user.Phone == nil {
+		return nil, entities.NewCodedError("no_phone", "no phone to remove")
+	}
+
+	before := *user
+	user.RemovePhone()
+	user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionPhoneRemoved, user.ID.String(), user.ID.String())
+	event.Changes = entities.DiffUsers(&before, user)
+	recordAudit(ctx, uc.auditRepo, event)
+
+	uc.notifyPhoneChanged(ctx, user)
+
+	return &PhoneOutput{
+		Message: localized(ctx, "phone_removed"),
+	}, nil
+}
+
+// notifyPhoneChanged tells the email address of the user that the phone
+// receiving password resets changed, so the owner notices a hijacked session.
+func (uc *UserUseCase) notifyPhoneChanged(ctx context.Context, user *entities.User) {
+	uc.sendEmail(ctx, user, user.Email, entities.EmailTemplatePhoneChanged, map[string]any{
+		"Name":  user.Name,
+		"Phone": user.PhoneNumber(),
+	})
+}
+
+// sendSMS sends a text message in the background, so the response does not
+// wait for the provider. The send is detached from the request, which ends
+// before it, and is tracked so WaitPendingSMS can wait for it on shutdown.
+func (uc *UserUseCase) sendSMS(ctx context.Context, kind string, send func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+
+	uc.pendingSMS.Add(1)
+	go func() {
+		defer uc.pendingSMS.Done()
+		if err := send(ctx); err != nil {
+			log.Printf("Error sending %s SMS: %v", kind, err)
+		}
+	}()
+}
+
+// WaitPendingSMS waits for the text messages still being sent. It is called
+// on shutdown, once no more requests are served.
+func (uc *UserUseCase) WaitPendingSMS() {
+	uc.pendingSMS.Wait()
+}
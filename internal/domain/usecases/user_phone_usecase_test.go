@@ -0,0 +1,160 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+func TestSendSMSOutlivesRequest(t *testing.T) {
+	uc := newUserUseCaseFixture(t, lenientThrottling)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	var sent atomic.Bool
+	var sendErr error
+
+	uc.sendSMS(ctx, "test", func(ctx context.Context) error {
+		<-release
+		sendErr = ctx.Err()
+		sent.Store(true)
+		return nil
+	})
+
+	// The request ends before the provider answers.
+	cancel()
+	close(release)
+	uc.WaitPendingSMS()
+
+	if !sent.Load() {
+		t.Fatal("WaitPendingSMS returned before the message was sent")
+	}
+	if sendErr != nil {
+		t.Errorf("got context error %v, want the send detached from the request", sendErr)
+	}
+}
+
+func TestWaitPendingSMSWaitsForEverySend(t *testing.T) {
+	uc := newUserUseCaseFixture(t, lenientThrottling)
+
+	var sent atomic.Int32
+	for i := 0; i < 3; i++ {
+		uc.sendSMS(context.Background(), "test", func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			sent.Add(1)
+			return errors.New("provider down")
+		})
+	}
+
+	uc.WaitPendingSMS()
+	if got := sent.Load(); got != 3 {
+		t.Errorf("got %d messages sent, want 3", got)
+	}
+}
+
+const testPhone = "+5511999998888"
+
+func (r *memoryUserRepository) FindByPhone(ctx context.Context, phone string) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.PhoneNumber() == phone && user.PhoneVerifiedAt != nil {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// memorySMSService records the recipients of the messages it sends.
+type memorySMSService struct {
+	mu         sync.Mutex
+	recipients []string
+}
+
+func (s *memorySMSService) SendPasswordResetSMS(ctx context.Context, to, locale, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recipients = append(s.recipients, to)
+	return nil
+}
+
+func (s *memorySMSService) SendPhoneVerificationSMS(ctx context.Context, to, locale, code string) error {
+	return s.SendPasswordResetSMS(ctx, to, locale, code)
+}
+
+func TestPhoneChangesThrottleWrongPasswords(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(uc *userUseCaseFixture, userID, password string) error
+	}{
+		{
+			name: "request verification",
+			change: func(uc *userUseCaseFixture, userID, password string) error {
+				_, err := uc.RequestPhoneVerification(context.Background(), userID, ChangePhoneInput{Phone: testPhone, Password: password})
+				return err
+			},
+		},
+		{
+			name: "remove",
+			change: func(uc *userUseCaseFixture, userID, password string) error {
+				_, err := uc.RemovePhone(context.Background(), userID, RemovePhoneInput{Password: password})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, testEmail, testPassword)
+			uc := newUserUseCaseFixture(t, delayThrottling, user)
+
+			for i := 0; i < 3; i++ {
+				assertCode(t, tt.change(uc, user.ID.String(), "wrong password"), "invalid_password")
+			}
+
+			assertThrottled(t, tt.change(uc, user.ID.String(), testPassword), time.Minute)
+			_, err := uc.verifyCredentials(context.Background(), testEmail, testPassword)
+			assertThrottled(t, err, time.Minute)
+		})
+	}
+}
+
+func TestRequestPasswordResetBySMSAnswersTheSameForEveryOutcome(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	user.SetVerifiedPhone(testPhone)
+	uc := newUserUseCaseFixture(t, lenientThrottling, user)
+	sms := &memorySMSService{}
+	uc.passwordResetRepo = &memoryPasswordResetRepository{}
+	uc.smsService = sms
+	ctx := context.Background()
+
+	phones := []string{"+5511988887777", testPhone, testPhone}
+	var messages []string
+	for _, phone := range phones {
+		output, err := uc.RequestPasswordReset(ctx, RequestPasswordResetInput{Phone: phone})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", phone, err)
+		}
+		messages = append(messages, output.Message)
+	}
+	uc.WaitPendingSMS()
+
+	// Unknown phone, reset sent and reset already pending.
+	for i, message := range messages {
+		if message != messages[0] {
+			t.Errorf("request %d: got message %q, want %q as for every outcome", i+1, message, messages[0])
+		}
+	}
+	if !slices.Equal(sms.recipients, []string{testPhone}) {
+		t.Errorf("got codes sent to %v, want only %s", sms.recipients, testPhone)
+	}
+}
@@ -0,0 +1,274 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+var (
+	ErrRegistrationDisabled      = entities.NewCodedError("registration_disabled", "registration is disabled")
+	ErrEmailNotVerified          = entities.NewCodedError("email_not_verified", "email address is not verified")
+	ErrVerificationEmailCooldown = entities.NewCodedError("verification_email_cooldown", "a verification email was sent recently, please wait before requesting another one")
+)
+
+// RegistrationConfig controls self-service sign up. VerificationURL is the
+// page the verification link points to; the token is appended as a query
+// parameter.
+type RegistrationConfig struct {
+	Enabled                  bool
+	RequireEmailVerification bool
+	VerificationURL          string
+}
+
+type RegisterInput struct {
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+	// Locale is the language of the user's emails and text messages. It
+	// defaults to the language of the request.
+	Locale string `json:"locale"`
+}
+
+type RegisterOutput struct {
+	Message string `json:"message"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" form:"token"`
+	Email string `json:"email" form:"email"`
+	Code  string `json:"code" form:"code"`
+}
+
+type VerifyEmailOutput struct {
+	Message string `json:"message"`
+}
+
+type ResendVerificationEmailInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResendVerificationEmailOutput struct {
+	Message string `json:"message"`
+}
+
+// Register creates a regular account that must confirm its email address. The
+// response is the same whether or not the email is already registered, so the
+// endpoint cannot be used to discover accounts.
+func (uc *UserUseCase) Register(ctx context.Context, input RegisterInput) (*RegisterOutput, error) {
+	if !uc.registration.Enabled {
+		return nil, ErrRegistrationDisabled
+	}
+
+	user, err := entities.NewUser(input.Name, input.Email, input.Password, uc.passwordPolicy, uc.passwordHasher)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := input.Locale
+	if locale == "" {
+		locale = entities.LocaleFromContext(ctx)
+	}
+	if locale != "" {
+		if err := user.SetLocale(locale); err != nil {
+			return nil, err
+		}
+	}
+
+	output := &RegisterOutput{
+		Message: localized(ctx, "registration_received"),
+	}
+
+	exists, err := uc.userRepo.ExistsByEmail(ctx, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return output, nil
+	}
+
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	event := entities.NewAuditEvent(entities.AuditActionUserRegistered, user.ID.String(), user.ID.String())
+	event.Changes = entities.DiffUsers(&entities.User{}, user)
+	recordAudit(ctx, uc.auditRepo, event)
+
+	if err := uc.sendEmailVerification(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+func (uc *UserUseCase) VerifyEmail(ctx context.Context, input VerifyEmailInput) (*VerifyEmailOutput, error) {
+	if err := entities.ValidateVerifyEmailInput(input.Token, input.Email, input.Code); err != nil {
+		return nil, err
+	}
+
+	invalid := entities.NewCodedError("invalid_or_expired_verification", "invalid or expired verification")
+
+	var verification *entities.EmailVerification
+	if input.Token != "" {
+		found, err := uc.emailVerificationRepo.FindByTokenHash(ctx, entities.HashEmailVerificationToken(input.Token))
+		if err != nil {
+			return nil, err
+		}
+		verification = found
+	} else {
+		user, err := uc.userRepo.FindByEmail(ctx, input.Email)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, invalid
+		}
+
+		found, err := uc.emailVerificationRepo.FindLatestByUserID(ctx, user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		if found == nil || !found.IsValid() {
+			return nil, invalid
+		}
+		// The attempt is counted before the code is compared, so concurrent
+		// guesses cannot get past the limit.
+		reserved, err := uc.emailVerificationRepo.ReserveAttempt(ctx, found.ID.String(), entities.EmailVerificationMaxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		if !reserved || !found.CheckCode(input.Code) {
+			return nil, invalid
+		}
+		verification = found
+	}
+
+	if verification == nil || !verification.IsValid() {
+		return nil, invalid
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, verification.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Email != verification.Email {
+		return nil, invalid
+	}
+
+	used, err := uc.emailVerificationRepo.MarkAsUsed(ctx, verification.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, invalid
+	}
+
+	if !user.EmailVerified {
+		user.MarkEmailVerified()
+		user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+
+		recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionEmailVerified, user.ID.String(), user.ID.String()))
+
+		uc.sendEmail(ctx, user, user.Email, entities.EmailTemplateWelcome, map[string]any{
+			"Name": user.Name,
+		})
+	}
+
+	return &VerifyEmailOutput{
+		Message: localized(ctx, "email_verified"),
+	}, nil
+}
+
+// ResendVerificationEmail sends a new verification to an unverified account.
+// The response is the same whether the email is unknown, already verified or
+// asked for another verification too soon, so it cannot be used to discover
+// accounts. Only EmailVerificationMaxPerTTL verifications are sent per
+// account, which bounds how many codes can be guessed.
+func (uc *UserUseCase) ResendVerificationEmail(ctx context.Context, input ResendVerificationEmailInput) (*ResendVerificationEmailOutput, error) {
+	if err := entities.ValidateEmail(input.Email); err != nil {
+		return nil, err
+	}
+
+	output := &ResendVerificationEmailOutput{
+		Message: localized(ctx, "verification_email_resent"),
+	}
+
+	user, err := uc.userRepo.FindByEmail(ctx, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.EmailVerified {
+		return output, nil
+	}
+
+	latest, err := uc.emailVerificationRepo.FindLatestByUserID(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && !latest.CanResend() {
+		return output, nil
+	}
+
+	sent, err := uc.emailVerificationRepo.CountCreatedSince(ctx, user.ID.String(), time.Now().Add(-entities.EmailVerificationTTL))
+	if err != nil {
+		return nil, err
+	}
+	if sent >= entities.EmailVerificationMaxPerTTL {
+		return output, nil
+	}
+
+	if err := uc.sendEmailVerification(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+func (uc *UserUseCase) sendEmailVerification(ctx context.Context, user *entities.User) error {
+	verification, token, code, err := entities.NewEmailVerification(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.emailVerificationRepo.Create(ctx, verification); err != nil {
+		return err
+	}
+
+	uc.sendEmail(ctx, user, user.Email, entities.EmailTemplateEmailVerification, map[string]any{
+		"Name": user.Name,
+		"Code": code,
+		"Link": uc.emailVerificationLink(token),
+	})
+
+	return nil
+}
+
+func (uc *UserUseCase) emailVerificationLink(token string) string {
+	return tokenLink(uc.registration.VerificationURL, token)
+}
+
+// tokenLink appends token as a query parameter to base, the page that
+// handles it. It returns an empty link when base is not configured.
+func tokenLink(base, token string) string {
+	if base == "" {
+		return ""
+	}
+
+	link, err := url.Parse(base)
+	if err != nil {
+		log.Printf("Invalid link URL %q: %v", base, err)
+		return ""
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
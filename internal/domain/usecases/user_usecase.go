@@ -7,14 +7,28 @@ import (
 	"context"
 	"errors"
 	"log"
+	"sync"
+	"time"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrForbidden = entities.NewCodedError("forbidden", "you are not allowed to perform this action")
+	ErrLastAdmin = entities.NewCodedError("last_admin", "the last admin cannot be demoted or deleted")
+	// ErrSharedAccount is returned when an organization tries to change the
+	// account of a user it does not own alone.
+	ErrSharedAccount = entities.NewCodedError("shared_account", "the user belongs to other organizations or manages the platform, so only a super admin can change the account")
 )
 
 type CreateUserInput struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+	// TemporaryPassword makes the user choose a new password on first login.
+	TemporaryPassword bool `json:"temporary_password"`
+	// Locale is the language of the user's emails and text messages.
+	Locale string `json:"locale"`
 }
 
 type CreateUserOutput struct {
@@ -25,22 +39,72 @@ type CreateUserOutput struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// LoginInput optionally names the organization, by id or slug, the session
+// is opened for.
 type LoginInput struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email        string `json:"email" validate:"required,email"`
+	Password     string `json:"password" validate:"required"`
+	Organization string `json:"organization"`
 }
 
 type LoginOutput struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	CreatedAt string `json:"created_at"`
-	Token     string `json:"token"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Role           string `json:"role"`
+	OrganizationID string `json:"organization_id,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	Token          string `json:"token,omitempty"`
+	RefreshToken   string `json:"refresh_token,omitempty"`
+	ExpiresIn      int64  `json:"expires_in,omitempty"`
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	MFAToken       string `json:"mfa_token,omitempty"`
+	// PasswordChangeRequired replaces the session when the password must be
+	// changed first; PasswordChangeToken is then sent with the new password.
+	PasswordChangeRequired bool   `json:"password_change_required,omitempty"`
+	PasswordChangeToken    string `json:"password_change_token,omitempty"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+type RefreshTokenOutput struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type SwitchOrganizationInput struct {
+	Organization string `json:"organization"`
+}
+
+type SwitchOrganizationOutput struct {
+	OrganizationID string `json:"organization_id,omitempty"`
+	Role           string `json:"role"`
+	Token          string `json:"token"`
+	RefreshToken   string `json:"refresh_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+type LogoutInput struct {
+	UserID         string    `json:"-"`
+	TokenID        string    `json:"-"`
+	TokenExpiresAt time.Time `json:"-"`
+	RefreshToken   string    `json:"refresh_token"`
+}
+
+type LogoutOutput struct {
+	Message string `json:"message"`
+}
+
+// ResetPasswordInput identifies the reset by the link token, or by the email
+// address or phone and the code, depending on how it was sent.
 type ResetPasswordInput struct {
-	Token    string `json:"token" validate:"required"`
+	Token    string `json:"token"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Code     string `json:"code"`
 	Password string `json:"password" validate:"required,min=6"`
 }
 
@@ -48,8 +112,19 @@ type ResetPasswordOutput struct {
 	Message string `json:"message"`
 }
 
+// PasswordResetConfig controls how password resets are sent. In link mode
+// URL is the page the reset link points to; the token is appended as a query
+// parameter.
+type PasswordResetConfig struct {
+	Mode string
+	URL  string
+}
+
+// RequestPasswordResetInput takes either the email address or the phone,
+// which also chooses where the reset is sent.
 type RequestPasswordResetInput struct {
-	Email string `json:"email" validate:"required,email"`
+	Email string `json:"email" validate:"omitempty,email"`
+	Phone string `json:"phone"`
 }
 
 type RequestPasswordResetOutput struct {
@@ -64,18 +139,23 @@ type ListUsersOutput struct {
 }
 
 type UserOutput struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Email              string `json:"email"`
+	EmailVerified      bool   `json:"email_verified"`
+	Phone              string `json:"phone,omitempty"`
+	Locale             string `json:"locale,omitempty"`
+	Role               string `json:"role"`
+	MustChangePassword bool   `json:"must_change_password,omitempty"`
+	DisabledAt         string `json:"disabled_at,omitempty"`
+	CreatedAt          string `json:"created_at"`
+	UpdatedAt          string `json:"updated_at"`
 }
 
 type UpdateUserInput struct {
 	Name  string `json:"name" validate:"required,min=2,max=100"`
 	Email string `json:"email" validate:"required,email"`
-	Role  string `json:"role" validate:"required,oneof=admin user"`
+	Role  string `json:"role" validate:"omitempty,oneof=admin user"`
 }
 
 type UpdateUserOutput struct {
@@ -91,23 +171,62 @@ type DeleteUserOutput struct {
 }
 
 type UserUseCase struct {
-	userRepo          repositories.UserRepository
-	passwordResetRepo repositories.PasswordResetRepository
-	jwtService        *services.JWTService
-	emailService      *services.EmailService
+	userRepo              repositories.UserRepository
+	passwordResetRepo     repositories.PasswordResetRepository
+	refreshTokenRepo      repositories.RefreshTokenRepository
+	revocationRepo        repositories.TokenRevocationRepository
+	recoveryCodeRepo      repositories.MFARecoveryCodeRepository
+	emailVerificationRepo repositories.EmailVerificationRepository
+	emailChangeRepo       repositories.EmailChangeRepository
+	phoneVerificationRepo repositories.PhoneVerificationRepository
+	jwtService            *services.JWTService
+	totpService           *services.TOTPService
+	emails                *EmailUseCase
+	smsService            services.SMSService
+	passwordHasher        entities.PasswordHasher
+	passwordPolicy        *entities.PasswordPolicy
+	registration          RegistrationConfig
+	passwordReset         PasswordResetConfig
+	loginThrottleRepo     repositories.LoginThrottleRepository
+	loginThrottling       LoginThrottlingConfig
+	roleRepo              repositories.RoleRepository
+	orgRepo               repositories.OrganizationRepository
+	auditRepo             repositories.AuditEventRepository
+	webhookRepo           repositories.WebhookRepository
+	dummyHashOnce         sync.Once
+	dummyHash             string
+	pendingSMS            sync.WaitGroup
 }
 
-func NewUserUseCase(userRepo repositories.UserRepository, passwordResetRepo repositories.PasswordResetRepository) *UserUseCase {
+func NewUserUseCase(userRepo repositories.UserRepository, passwordResetRepo repositories.PasswordResetRepository, refreshTokenRepo repositories.RefreshTokenRepository, revocationRepo repositories.TokenRevocationRepository, recoveryCodeRepo repositories.MFARecoveryCodeRepository, emailVerificationRepo repositories.EmailVerificationRepository, emailChangeRepo repositories.EmailChangeRepository, phoneVerificationRepo repositories.PhoneVerificationRepository, jwtService *services.JWTService, smsService services.SMSService, emails *EmailUseCase, passwordHasher entities.PasswordHasher, passwordPolicy *entities.PasswordPolicy, registration RegistrationConfig, passwordReset PasswordResetConfig, loginThrottleRepo repositories.LoginThrottleRepository, loginThrottling LoginThrottlingConfig, roleRepo repositories.RoleRepository, orgRepo repositories.OrganizationRepository, auditRepo repositories.AuditEventRepository, webhookRepo repositories.WebhookRepository) *UserUseCase {
 	return &UserUseCase{
-		userRepo:          userRepo,
-		passwordResetRepo: passwordResetRepo,
-		jwtService:        services.NewJWTService(),
-		emailService:      services.NewEmailService(),
+		userRepo:              userRepo,
+		passwordResetRepo:     passwordResetRepo,
+		refreshTokenRepo:      refreshTokenRepo,
+		revocationRepo:        revocationRepo,
+		recoveryCodeRepo:      recoveryCodeRepo,
+		emailVerificationRepo: emailVerificationRepo,
+		emailChangeRepo:       emailChangeRepo,
+		phoneVerificationRepo: phoneVerificationRepo,
+		jwtService:            jwtService,
+		totpService:           services.NewTOTPService(),
+		emails:                emails,
+		smsService:            smsService,
+		passwordHasher:        passwordHasher,
+		passwordPolicy:        passwordPolicy,
+		registration:          registration,
+		passwordReset:         passwordReset,
+		loginThrottleRepo:     loginThrottleRepo,
+		loginThrottling:       loginThrottling,
+		roleRepo:              roleRepo,
+		orgRepo:               orgRepo,
+		auditRepo:             auditRepo,
+		webhookRepo:           webhookRepo,
 	}
 }
 
 func (uc *UserUseCase) CreateUser(ctx context.Context, input CreateUserInput) (*CreateUserOutput, error) {
-	user, err := entities.NewUser(input.Name, input.Email, input.Password)
+	user, err := entities.NewUser(input.Name, input.Email, input.Password, uc.passwordPolicy, uc.passwordHasher)
 	if err != nil {
 		return nil, err
 	}
@@ -117,18 +236,34 @@ func (uc *UserUseCase) CreateUser(ctx context.Context, input CreateUserInput) (*
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("email already exists")
+		return nil, entities.NewCodedError("email_already_exists", "email already exists")
 	}
 
+	// Accounts created by an admin do not go through email verification.
+	user.MarkEmailVerified()
+	if input.TemporaryPassword {
+		user.RequirePasswordChange()
+	}
+	if input.Locale != "" {
+		if err := user.SetLocale(input.Locale); err != nil {
+			return nil, err
+		}
+	}
+
+	// Within an organization the user also becomes one of its members.
 	if err := uc.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
+	event := entities.NewAuditEvent(entities.AuditActionUserCreated, "", user.ID.String())
+	event.Changes = entities.DiffUsers(&entities.User{}, user)
+	recordAudit(ctx, uc.auditRepo, event)
+
 	return &CreateUserOutput{
 		ID:        user.ID.String(),
 		Name:      user.Name,
 		Email:     user.Email,
-		Role:      user.Role,
+		Role:      user.EffectiveRole(),
 		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}, nil
 }
@@ -138,35 +273,437 @@ func (uc *UserUseCase) Login(ctx context.Context, input LoginInput) (*LoginOutpu
 		return nil, err
 	}
 
-	user, err := uc.userRepo.FindByEmail(ctx, input.Email)
+	user, err := uc.verifyCredentials(ctx, input.Email, input.Password)
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
-		return nil, errors.New("invalid email or password")
+
+	if uc.registration.RequireEmailVerification && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	organizationID, err := uc.loginOrganization(ctx, user, input.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := uc.sessionRole(ctx, user, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		mfaToken, err := uc.jwtService.GenerateMFAChallengeToken(user.ID.String(), user.Email, user.Name, role, organizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginOutput{
+			ID:             user.ID.String(),
+			Name:           user.Name,
+			Email:          user.Email,
+			Role:           role,
+			OrganizationID: organizationID,
+			CreatedAt:      user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			MFARequired:    true,
+			MFAToken:       mfaToken,
+		}, nil
+	}
+
+	return uc.completeLogin(ctx, user, organizationID)
+}
+
+// loginOrganization picks the organization a login is for: the requested
+// one, or the only organization of the user when none is requested. Users
+// in several organizations, and super admins, otherwise get a session
+// without organization and can switch to one later.
+func (uc *UserUseCase) loginOrganization(ctx context.Context, user *entities.User, requested string) (string, error) {
+	if requested != "" {
+		organization, err := findOrganization(ctx, uc.orgRepo, requested)
+		if err != nil {
+			return "", err
+		}
+		return organization.ID.String(), nil
+	}
+
+	if user.IsSuperAdmin() {
+		return "", nil
+	}
+
+	memberships, err := uc.orgRepo.FindMembershipsByUserID(ctx, user.ID.String())
+	if err != nil {
+		return "", err
+	}
+	if len(memberships) == 1 {
+		return memberships[0].OrganizationID.String(), nil
+	}
+	return "", nil
+}
+
+// sessionRole returns the role the user acts with: its role in the
+// organization, which it must belong to, or its global role when there is
+// no organization.
+func (uc *UserUseCase) sessionRole(ctx context.Context, user *entities.User, organizationID string) (string, error) {
+	if organizationID == "" {
+		return user.Role, nil
+	}
+
+	membership, err := uc.orgRepo.FindMembership(ctx, organizationID, user.ID.String())
+	if err != nil {
+		return "", err
+	}
+	if membership == nil {
+		return "", ErrNotMember
+	}
+	return membership.Role, nil
+}
+
+// Authenticate checks the credentials of a user in a single step, requiring
+// the TOTP or recovery code up front when two-factor authentication is on. It
+// is used by flows that cannot redirect through the MFA challenge, like the
+// OAuth authorization page.
+func (uc *UserUseCase) Authenticate(ctx context.Context, email, password, mfaCode string) (*entities.User, error) {
+	if err := entities.ValidateLoginData(email, password); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.verifyCredentials(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.registration.RequireEmailVerification && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	if user.MFAEnabled {
+		code, recoveryCode := mfaCode, ""
+		if mfaCode != "" && entities.ValidateMFACode(mfaCode) != nil {
+			code, recoveryCode = "", mfaCode
+		}
+		if err := uc.checkSecondFactor(ctx, user, code, recoveryCode); err != nil {
+			return nil, err
+		}
+	}
+
+	if uc.passwordChangeRequired(user) {
+		return nil, ErrPasswordChangeRequired
+	}
+
+	uc.recordLogin(ctx, user, "")
+	return user, nil
+}
+
+// recordLogin records a successful login in the audit log and notifies the
+// webhooks subscribed to logins.
+func (uc *UserUseCase) recordLogin(ctx context.Context, user *entities.User, organizationID string) {
+	event := entities.NewAuditEvent(entities.AuditActionLoginSucceeded, user.ID.String(), user.ID.String())
+	event.OrganizationID = organizationID
+	recordAudit(ctx, uc.auditRepo, event)
+
+	enqueueUserWebhook(ctx, uc.webhookRepo, entities.WebhookEventUserLoggedIn, user, organizationID)
+}
+
+// checkPassword verifies the password and, when the stored hash uses an
+// outdated algorithm or parameters, replaces it with a fresh hash. The upgrade
+// is best effort and never fails the login.
+func (uc *UserUseCase) checkPassword(ctx context.Context, user *entities.User, password string) bool {
+	if !user.CheckPassword(uc.passwordHasher, password) {
+		return false
+	}
+
+	if uc.passwordHasher.NeedsRehash(user.Password) {
+		if err := user.SetPassword(uc.passwordHasher, password); err != nil {
+			log.Printf("Error rehashing password: %v", err)
+		} else if err := uc.userRepo.Update(ctx, user); err != nil {
+			log.Printf("Error saving rehashed password: %v", err)
+		}
+	}
+
+	return true
+}
+
+func (uc *UserUseCase) completeLogin(ctx context.Context, user *entities.User, organizationID string) (*LoginOutput, error) {
+	return uc.openSession(ctx, user, organizationID, false)
+}
+
+// openSession issues the tokens of a new session for the user, unless the
+// password must be changed first. With replaceSessions, every other session
+// of the user ends.
+func (uc *UserUseCase) openSession(ctx context.Context, user *entities.User, organizationID string, replaceSessions bool) (*LoginOutput, error) {
+	if uc.passwordChangeRequired(user) {
+		if replaceSessions {
+			if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
+				return nil, err
+			}
+		}
+		return uc.passwordChangeChallenge(ctx, user, organizationID)
 	}
 
-	if !user.CheckPassword(input.Password) {
-		return nil, errors.New("invalid email or password")
+	access, err := uc.generateAccessToken(ctx, user, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if replaceSessions {
+		if err := uc.revokeOtherSessions(ctx, user.ID, access.id); err != nil {
+			return nil, err
+		}
 	}
 
-	token, err := uc.jwtService.GenerateToken(user.ID.String(), user.Email, user.Name, user.Role)
+	refreshToken, err := uc.issueRefreshToken(ctx, user.ID, uuid.New(), organizationID)
 	if err != nil {
 		return nil, err
 	}
 
+	uc.recordLogin(ctx, user, organizationID)
+
 	return &LoginOutput{
-		ID:        user.ID.String(),
-		Name:      user.Name,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		Token:     token,
+		ID:             user.ID.String(),
+		Name:           user.Name,
+		Email:          user.Email,
+		Role:           access.role,
+		OrganizationID: organizationID,
+		CreatedAt:      user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Token:          access.token,
+		RefreshToken:   refreshToken,
+		ExpiresIn:      int64(services.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// accessToken is an access token issued by generateAccessToken, with its id
+// and the role it carries.
+type accessToken struct {
+	token string
+	id    string
+	role  string
+}
+
+// generateAccessToken issues an access token for the user, scoped to the
+// organization when one is given. Within an organization only the
+// permissions that apply to it are granted.
+func (uc *UserUseCase) generateAccessToken(ctx context.Context, user *entities.User, organizationID string) (*accessToken, error) {
+	role, err := uc.sessionRole(ctx, user, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := uc.rolePermissions(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	if organizationID != "" {
+		permissions = entities.TenantPermissions(permissions)
+	}
+
+	token, id, err := uc.jwtService.GenerateToken(user.ID.String(), user.Email, user.Name, role, organizationID, permissions)
+	if err != nil {
+		return nil, err
+	}
+	return &accessToken{token: token, id: id, role: role}, nil
+}
+
+// rolePermissions returns the permissions granted to a role. A role that no
+// longer exists grants nothing.
+func (uc *UserUseCase) rolePermissions(ctx context.Context, roleName string) ([]string, error) {
+	role, err := uc.roleRepo.FindByName(ctx, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+	return role.PermissionNames(), nil
+}
+
+func (uc *UserUseCase) RefreshToken(ctx context.Context, input RefreshTokenInput) (*RefreshTokenOutput, error) {
+	if err := entities.ValidateRefreshTokenInput(input.RefreshToken); err != nil {
+		return nil, err
+	}
+
+	stored, err := consumeRefreshToken(ctx, uc.refreshTokenRepo, input.RefreshToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, stored.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.IsDisabled() {
+		if err := uc.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID.String()); err != nil {
+			return nil, err
+		}
+		return nil, entities.NewCodedError("invalid_refresh_token", "invalid refresh token")
+	}
+	// Users who must change their password log in again to do it.
+	if uc.passwordChangeRequired(user) {
+		return nil, ErrPasswordChangeRequired
+	}
+
+	var organizationID string
+	if stored.OrganizationID != nil {
+		organizationID = stored.OrganizationID.String()
+	}
+
+	// Users removed from the organization lose the session.
+	access, err := uc.generateAccessToken(ctx, user, organizationID)
+	if errors.Is(err, ErrNotMember) {
+		if err := uc.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID.String()); err != nil {
+			return nil, err
+		}
+		return nil, entities.NewCodedError("invalid_refresh_token", "invalid refresh token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := uc.issueRefreshToken(ctx, user.ID, stored.FamilyID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshTokenOutput{
+		Token:        access.token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(services.AccessTokenTTL.Seconds()),
 	}, nil
 }
 
-func (uc *UserUseCase) ListUsers(ctx context.Context, currentUserID string, filters *entities.UserFilters) (*ListUsersOutput, error) {
-	if err := entities.ValidateUUID(currentUserID); err != nil {
+// SwitchOrganization opens a new session for another organization the user
+// belongs to, or a session without organization when none is given. The
+// current session stays valid.
+func (uc *UserUseCase) SwitchOrganization(ctx context.Context, userID string, input SwitchOrganizationInput) (*SwitchOrganizationOutput, error) {
+	if err := entities.ValidateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, entities.NewCodedError("user_not_found", "user not found")
+	}
+
+	var organizationID string
+	if input.Organization != "" {
+		organization, err := findOrganization(ctx, uc.orgRepo, input.Organization)
+		if err != nil {
+			return nil, err
+		}
+		organizationID = organization.ID.String()
+	}
+
+	access, err := uc.generateAccessToken(ctx, user, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := uc.issueRefreshToken(ctx, user.ID, uuid.New(), organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SwitchOrganizationOutput{
+		OrganizationID: organizationID,
+		Role:           access.role,
+		Token:          access.token,
+		RefreshToken:   refreshToken,
+		ExpiresIn:      int64(services.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (uc *UserUseCase) Logout(ctx context.Context, input LogoutInput) (*LogoutOutput, error) {
+	userID, err := uuid.Parse(input.UserID)
+	if err != nil {
+		return nil, entities.NewCodedError("invalid_uuid", "invalid UUID format")
+	}
+
+	revokedToken, err := entities.NewRevokedToken(input.TokenID, userID, input.TokenExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.revocationRepo.RevokeToken(ctx, revokedToken); err != nil {
+		return nil, err
+	}
+
+	if input.RefreshToken != "" {
+		stored, err := uc.refreshTokenRepo.FindByTokenHash(ctx, entities.HashRefreshToken(input.RefreshToken))
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil && stored.UserID == userID {
+			if err := uc.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID.String()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &LogoutOutput{
+		Message: localized(ctx, "logged_out"),
+	}, nil
+}
+
+func (uc *UserUseCase) LogoutAll(ctx context.Context, userID string) (*LogoutOutput, error) {
+	if err := entities.ValidateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.revokeUserSessions(ctx, uuid.MustParse(userID)); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionLogoutAll, userID, userID))
+
+	return &LogoutOutput{
+		Message: localized(ctx, "sessions_terminated"),
+	}, nil
+}
+
+func (uc *UserUseCase) revokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return uc.revokeOtherSessions(ctx, userID, "")
+}
+
+// revokeOtherSessions ends every session of the user but the one of the
+// access token with keepTokenID, which replaces them. Refresh tokens are
+// all revoked, so the replacing session must be given a new one.
+func (uc *UserUseCase) revokeOtherSessions(ctx context.Context, userID uuid.UUID, keepTokenID string) error {
+	revocation := entities.NewUserTokenRevocation(userID, keepTokenID, services.AccessTokenTTL)
+	if err := uc.revocationRepo.RevokeAllForUser(ctx, revocation); err != nil {
+		return err
+	}
+
+	return uc.refreshTokenRepo.RevokeByUserID(ctx, userID.String())
+}
+
+func (uc *UserUseCase) issueRefreshToken(ctx context.Context, userID, familyID uuid.UUID, organizationID string) (string, error) {
+	refreshToken, token, err := entities.NewRefreshToken(userID, familyID)
+	if err != nil {
+		return "", err
+	}
+
+	if organizationID != "" {
+		id, err := uuid.Parse(organizationID)
+		if err != nil {
+			return "", err
+		}
+		refreshToken.OrganizationID = &id
+	}
+
+	if err := uc.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ListUsers lists every user matching the filters for actors allowed to read
+// users, and only the actor's own account otherwise. Within an organization
+// only its members are listed, with their role in it.
+func (uc *UserUseCase) ListUsers(ctx context.Context, actor entities.Actor, filters *entities.UserFilters) (*ListUsersOutput, error) {
+	if err := entities.ValidateUUID(actor.UserID); err != nil {
 		return nil, err
 	}
 
@@ -174,15 +711,16 @@ func (uc *UserUseCase) ListUsers(ctx context.Context, currentUserID string, filt
 		return nil, err
 	}
 
-	currentUser, err := uc.userRepo.FindByID(ctx, currentUserID)
+	// Super admins acting on an organization are not members of it.
+	currentUser, err := uc.userRepo.FindByID(entities.WithOrganizationID(ctx, ""), actor.UserID)
 	if err != nil {
 		return nil, err
 	}
 	if currentUser == nil {
-		return nil, errors.New("current user not found")
+		return nil, entities.NewCodedError("current_user_not_found", "current user not found")
 	}
 
-	if currentUser.IsAdmin() {
+	if actor.HasPermission(entities.PermissionUsersRead) {
 		users, err := uc.userRepo.FindAllWithFilters(ctx, filters)
 		if err != nil {
 			return nil, err
@@ -190,14 +728,7 @@ func (uc *UserUseCase) ListUsers(ctx context.Context, currentUserID string, filt
 
 		var userOutputs []UserOutput
 		for _, user := range users {
-			userOutputs = append(userOutputs, UserOutput{
-				ID:        user.ID.String(),
-				Name:      user.Name,
-				Email:     user.Email,
-				Role:      user.Role,
-				CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			})
+			userOutputs = append(userOutputs, toUserOutput(user))
 		}
 
 		return &ListUsersOutput{
@@ -208,17 +739,8 @@ func (uc *UserUseCase) ListUsers(ctx context.Context, currentUserID string, filt
 		}, nil
 	}
 
-	userOutput := UserOutput{
-		ID:        currentUser.ID.String(),
-		Name:      currentUser.Name,
-		Email:     currentUser.Email,
-		Role:      currentUser.Role,
-		CreatedAt: currentUser.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: currentUser.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
 	return &ListUsersOutput{
-		Users: []UserOutput{userOutput},
+		Users: []UserOutput{toUserOutput(currentUser)},
 		Total: 1,
 		Page:  1,
 		Limit: 1,
@@ -235,20 +757,40 @@ func (uc *UserUseCase) GetUserByID(ctx context.Context, userID string) (*UserOut
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, entities.NewCodedError("user_not_found", "user not found")
 	}
 
-	return &UserOutput{
-		ID:        user.ID.String(),
-		Name:      user.Name,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	output := toUserOutput(user)
+	return &output, nil
 }
 
-func (uc *UserUseCase) UpdateUser(ctx context.Context, userID string, input UpdateUserInput) (*UpdateUserOutput, error) {
+func toUserOutput(user *entities.User) UserOutput {
+	output := UserOutput{
+		ID:                 user.ID.String(),
+		Name:               user.Name,
+		Email:              user.Email,
+		EmailVerified:      user.EmailVerified,
+		Phone:              user.PhoneNumber(),
+		Locale:             user.Locale,
+		MustChangePassword: user.MustChangePassword,
+		Role:               user.EffectiveRole(),
+		CreatedAt:          user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:          user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if user.DisabledAt != nil {
+		output.DisabledAt = user.DisabledAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return output
+}
+
+// UpdateUser applies the changes the actor is allowed to make: users may
+// edit their own name, while their own email only changes once the new
+// address is confirmed (see RequestEmailChange), and changing roles needs the
+// roles:assign permission. Changing a role revokes the user's sessions, so tokens carrying
+// the old permissions stop working. Within an organization the role changed
+// is the user's role in it, and the name and email of other users can only be
+// changed when the organization owns the account (see checkAccountOwnership).
+func (uc *UserUseCase) UpdateUser(ctx context.Context, actor entities.Actor, userID string, input UpdateUserInput) (*UpdateUserOutput, error) {
 	if err := entities.ValidateUUID(userID); err != nil {
 		return nil, err
 	}
@@ -262,37 +804,151 @@ func (uc *UserUseCase) UpdateUser(ctx context.Context, userID string, input Upda
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, entities.NewCodedError("user_not_found", "user not found")
 	}
+	before := *user
+
+	nameChanged := input.Name != user.Name
+	emailChanged := input.Email != user.Email
+	roleChanged := input.Role != "" && input.Role != user.EffectiveRole()
 
-	if input.Email != user.Email {
+	if emailChanged && actor.UserID == user.ID.String() {
+		return nil, ErrEmailChangeRequiresConfirmation
+	}
+
+	if (nameChanged && !actor.CanUpdateUserField(user, entities.UserFieldName)) ||
+		(emailChanged && !actor.CanUpdateUserField(user, entities.UserFieldEmail)) ||
+		(roleChanged && !actor.CanUpdateUserField(user, entities.UserFieldRole)) {
+		return nil, ErrForbidden
+	}
+
+	if (nameChanged || emailChanged) && actor.UserID != user.ID.String() {
+		if err := uc.checkAccountOwnership(ctx, actor, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if roleChanged {
+		if err := uc.checkRoleAssignment(ctx, actor, input.Role); err != nil {
+			return nil, err
+		}
+
+		if err := uc.ensureNotLastAdmin(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if emailChanged {
 		exists, err := uc.userRepo.ExistsByEmail(ctx, input.Email)
 		if err != nil {
 			return nil, err
 		}
 		if exists {
-			return nil, errors.New("email already exists")
+			return nil, entities.NewCodedError("email_already_exists", "email already exists")
 		}
+
+		user.EmailVerified = false
+		user.EmailVerifiedAt = nil
 	}
 
+	organizationID := entities.OrganizationIDFromContext(ctx)
+
 	user.Name = input.Name
 	user.Email = input.Email
-	user.Role = input.Role
+	if roleChanged {
+		// Within an organization the role is saved in the membership below,
+		// but the webhook event already carries it.
+		if organizationID == "" {
+			user.Role = input.Role
+		} else {
+			user.OrganizationRole = input.Role
+		}
+	}
+	if nameChanged || emailChanged || roleChanged {
+		user.RecordWebhookEvent(entities.WebhookEventUserUpdated)
+	}
 
 	if err := uc.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
 
+	if roleChanged {
+		if organizationID != "" {
+			if err := uc.updateMembershipRole(ctx, organizationID, user, input.Role); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if changes := entities.DiffUsers(&before, user); changes != nil {
+		event := entities.NewAuditEvent(entities.AuditActionUserUpdated, actor.UserID, user.ID.String())
+		event.Changes = changes
+		recordAudit(ctx, uc.auditRepo, event)
+	}
+
 	return &UpdateUserOutput{
 		ID:        user.ID.String(),
 		Name:      user.Name,
 		Email:     user.Email,
-		Role:      user.Role,
+		Role:      user.EffectiveRole(),
 		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}, nil
 }
 
-func (uc *UserUseCase) DeleteUser(ctx context.Context, userID string) (*DeleteUserOutput, error) {
+// checkAccountOwnership makes sure an actor working within an organization
+// only changes the global account (its name, email, second factor or
+// lockout) of users the organization owns alone: users that belong to no
+// other organization and are not super admins. Otherwise the admin of one
+// organization could take over a member of another, for instance by
+// changing its email and resetting the password. Super admins, and actors
+// outside of any organization, are not restricted.
+func (uc *UserUseCase) checkAccountOwnership(ctx context.Context, actor entities.Actor, user *entities.User) error {
+	organizationID := entities.OrganizationIDFromContext(ctx)
+	if organizationID == "" || actor.IsSuperAdmin() {
+		return nil
+	}
+
+	if user.IsSuperAdmin() {
+		return ErrSharedAccount
+	}
+
+	memberships, err := uc.orgRepo.FindMembershipsByUserID(ctx, user.ID.String())
+	if err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		if membership.OrganizationID.String() != organizationID {
+			return ErrSharedAccount
+		}
+	}
+	return nil
+}
+
+func (uc *UserUseCase) updateMembershipRole(ctx context.Context, organizationID string, user *entities.User, role string) error {
+	membership, err := uc.orgRepo.FindMembership(ctx, organizationID, user.ID.String())
+	if err != nil {
+		return err
+	}
+	if membership == nil {
+		return entities.NewCodedError("user_not_found", "user not found")
+	}
+
+	membership.Role = role
+	if err := uc.orgRepo.UpdateMembership(ctx, membership); err != nil {
+		return err
+	}
+
+	user.OrganizationRole = role
+	return nil
+}
+
+// DeleteUser deletes the user. Within an organization the user is only
+// removed from it, since the account may belong to other organizations.
+func (uc *UserUseCase) DeleteUser(ctx context.Context, actor entities.Actor, userID string) (*DeleteUserOutput, error) {
 	if err := entities.ValidateUUID(userID); err != nil {
 		return nil, err
 	}
@@ -302,105 +958,325 @@ func (uc *UserUseCase) DeleteUser(ctx context.Context, userID string) (*DeleteUs
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, entities.NewCodedError("user_not_found", "user not found")
 	}
 
-	if err := uc.userRepo.Delete(ctx, userID); err != nil {
+	if !actor.CanDeleteUser(user) {
+		return nil, ErrForbidden
+	}
+
+	if err := uc.ensureNotLastAdmin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	message := localized(ctx, "user_deleted")
+	action := entities.AuditActionUserDeleted
+	if organizationID := entities.OrganizationIDFromContext(ctx); organizationID != "" {
+		if err := uc.orgRepo.RemoveMember(ctx, organizationID, userID); err != nil {
+			return nil, err
+		}
+		message = localized(ctx, "user_removed_from_organization")
+		action = entities.AuditActionMemberRemoved
+	} else if err := uc.userRepo.Delete(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// A removed member keeps the account, so only the role it had is recorded.
+	event := entities.NewAuditEvent(action, actor.UserID, user.ID.String())
+	if action == entities.AuditActionMemberRemoved {
+		event.Changes = map[string]entities.AuditChange{"role": {Before: user.EffectiveRole()}}
+	} else {
+		event.Changes = entities.DiffUsers(user, &entities.User{})
+	}
+	recordAudit(ctx, uc.auditRepo, event)
+
+	if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
 		return nil, err
 	}
 
 	return &DeleteUserOutput{
-		Message: "User deleted successfully",
+		Message: message,
 	}, nil
 }
 
+// checkRoleAssignment makes sure the role exists and grants nothing the
+// actor does not hold, so assigning roles cannot be used to escalate. Within
+// an organization only the permissions that apply to it are compared, as the
+// others are not granted there, and super_admin cannot be assigned.
+func (uc *UserUseCase) checkRoleAssignment(ctx context.Context, actor entities.Actor, roleName string) error {
+	role, err := uc.roleRepo.FindByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return entities.NewCodedError("role_not_found", "role not found")
+	}
+
+	permissions := role.PermissionNames()
+	if entities.OrganizationIDFromContext(ctx) != "" {
+		if role.Name == entities.RoleSuperAdmin {
+			return ErrForbidden
+		}
+		permissions = entities.TenantPermissions(permissions)
+	}
+
+	if !actor.HasAllPermissions(permissions) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ensureNotLastAdmin is checked before a user is demoted or deleted, so
+// there is always someone able to manage the platform or, within an
+// organization, the organization.
+func (uc *UserUseCase) ensureNotLastAdmin(ctx context.Context, user *entities.User) error {
+	adminRole := entities.RoleSuperAdmin
+	if entities.OrganizationIDFromContext(ctx) != "" {
+		adminRole = entities.RoleAdmin
+	}
+
+	if user.EffectiveRole() != adminRole {
+		return nil
+	}
+
+	admins, err := uc.userRepo.CountByRole(ctx, adminRole)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// RequestPasswordReset sends a password reset to the email address or, when
+// the phone is given instead, by SMS to the verified phone. Resets sent by
+// SMS are always codes. Every request gets the same answer whether the
+// address or phone is registered, a reset is already pending or one was just
+// sent, so the answer does not reveal accounts.
 func (uc *UserUseCase) RequestPasswordReset(ctx context.Context, input RequestPasswordResetInput) (*RequestPasswordResetOutput, error) {
-	if err := entities.ValidateEmail(input.Email); err != nil {
+	bySMS := input.Phone != ""
+	if bySMS {
+		if input.Email != "" {
+			return nil, entities.NewCodedError("email_or_phone", "use either email or phone")
+		}
+		if err := entities.ValidatePhone(input.Phone); err != nil {
+			return nil, err
+		}
+	} else if err := entities.ValidateEmail(input.Email); err != nil {
 		return nil, err
 	}
 
-	user, err := uc.userRepo.FindByEmail(ctx, input.Email)
-	if err != nil {
+	if err := uc.sendPasswordReset(ctx, input, bySMS); err != nil {
 		return nil, err
 	}
+
+	message := localized(ctx, "password_reset_requested")
+	if bySMS {
+		message = localized(ctx, "password_reset_sms_requested")
+	}
+
+	return &RequestPasswordResetOutput{
+		Message: message,
+	}, nil
+}
+
+// sendPasswordReset sends a new reset unless the user is unknown or a reset
+// is still pending. Which of these happened is only recorded in the audit
+// log.
+func (uc *UserUseCase) sendPasswordReset(ctx context.Context, input RequestPasswordResetInput, bySMS bool) error {
+	var user *entities.User
+	var err error
+	if bySMS {
+		user, err = uc.userRepo.FindByPhone(ctx, input.Phone)
+	} else {
+		user, err = uc.userRepo.FindByEmail(ctx, input.Email)
+	}
+	if err != nil {
+		return err
+	}
 	if user == nil {
-		return &RequestPasswordResetOutput{
-			Message: "Se o email existir, você receberá um código de verificação por email.",
-		}, nil
+		outcome := "unknown_email"
+		if bySMS {
+			outcome = "unknown_phone"
+		}
+		uc.auditPasswordResetRequest(ctx, input, nil, outcome)
+		return nil
 	}
 
-	existingReset, err := uc.passwordResetRepo.FindByUserID(ctx, user.ID.String())
+	existingReset, err := uc.passwordResetRepo.FindLatestByUserID(ctx, user.ID.String())
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if existingReset != nil && existingReset.IsValid() {
-		return &RequestPasswordResetOutput{
-			Message: "Um código de verificação já foi enviado. Aguarde 15 minutos para solicitar um novo.",
-		}, nil
+	if existingReset != nil && existingReset.IsPending() {
+		uc.auditPasswordResetRequest(ctx, input, user, "already_pending")
+		return nil
+	}
+
+	mode := uc.passwordReset.Mode
+	if bySMS {
+		mode = entities.PasswordResetModeCode
 	}
 
-	passwordReset, err := entities.NewPasswordReset(user.ID, user.Email)
+	passwordReset, token, err := entities.NewPasswordReset(user.ID, user.Email, mode)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	code, link := token, ""
+	if mode == entities.PasswordResetModeLink {
+		code, link = "", tokenLink(uc.passwordReset.URL, token)
 	}
 
 	if err := uc.passwordResetRepo.Create(ctx, passwordReset); err != nil {
-		return nil, err
+		return err
 	}
 
-	go func() {
-		if err := uc.emailService.SendPasswordResetEmail(user.Email, user.Name, passwordReset.Token); err != nil {
-			log.Printf("Error sending password reset email: %v", err)
-		}
-	}()
+	uc.auditPasswordResetRequest(ctx, input, user, "sent")
 
-	return &RequestPasswordResetOutput{
-		Message: "Código de verificação enviado por email.",
-	}, nil
+	if bySMS {
+		locale := userLocale(ctx, user)
+		uc.sendSMS(ctx, "password reset", func(ctx context.Context) error {
+			return uc.smsService.SendPasswordResetSMS(ctx, input.Phone, locale, code)
+		})
+		return nil
+	}
+
+	uc.sendEmail(ctx, user, user.Email, entities.EmailTemplatePasswordReset, map[string]any{
+		"Name": user.Name,
+		"Code": code,
+		"Link": link,
+	})
+	return nil
 }
 
+// ResetPassword sets a new password with the link token, or with the email
+// address or phone and the code. Each code submitted counts against the
+// reset, which stops working after a few, and every failure gets the same
+// answer.
 func (uc *UserUseCase) ResetPassword(ctx context.Context, input ResetPasswordInput) (*ResetPasswordOutput, error) {
-	if err := entities.ValidateResetPasswordInput(input.Token, input.Password); err != nil {
+	if err := entities.ValidateResetPasswordInput(input.Token, input.Email, input.Phone, input.Code, input.Password); err != nil {
 		return nil, err
 	}
 
-	passwordReset, err := uc.passwordResetRepo.FindByToken(ctx, input.Token)
-	if err != nil {
-		return nil, err
+	invalid := entities.NewCodedError("invalid_or_expired_token", "invalid or expired token")
+
+	var passwordReset *entities.PasswordReset
+	if input.Token != "" {
+		// Codes would be guessable by a global lookup, so only link tokens
+		// are accepted without the email address.
+		if uc.passwordReset.Mode != entities.PasswordResetModeLink {
+			return nil, invalid
+		}
+
+		found, err := uc.passwordResetRepo.FindByTokenHash(ctx, entities.HashPasswordResetToken(input.Token))
+		if err != nil {
+			return nil, err
+		}
+		passwordReset = found
+	} else {
+		var user *entities.User
+		var err error
+		if input.Phone != "" {
+			user, err = uc.userRepo.FindByPhone(ctx, input.Phone)
+		} else {
+			user, err = uc.userRepo.FindByEmail(ctx, input.Email)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, invalid
+		}
+
+		found, err := uc.passwordResetRepo.FindLatestByUserID(ctx, user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		if found == nil || !found.IsValid() {
+			return nil, invalid
+		}
+		// The attempt is counted before the code is compared, so concurrent
+		// guesses cannot get past the limit.
+		reserved, err := uc.passwordResetRepo.ReserveAttempt(ctx, found.ID.String(), entities.PasswordResetMaxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		if !reserved || !found.CheckToken(input.Code) {
+			return nil, invalid
+		}
+		passwordReset = found
 	}
-	if passwordReset == nil {
-		return nil, errors.New("invalid or expired token")
+
+	if passwordReset == nil || !passwordReset.IsValid() {
+		return nil, invalid
 	}
 
 	user, err := uc.userRepo.FindByID(ctx, passwordReset.UserID.String())
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
-		return nil, errors.New("user not found")
+	// A reset sent before the email changed is not honored.
+	if user == nil || user.Email != passwordReset.Email {
+		return nil, invalid
 	}
 
-	if err := passwordReset.ValidateToken(input.Token); err != nil {
+	// The password is checked before the reset is consumed, so a rejected
+	// password does not burn it.
+	if err := uc.passwordPolicy.Validate(input.Password, user.Name, user.Email); err != nil {
 		return nil, err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	used, err := uc.passwordResetRepo.MarkAsUsed(ctx, passwordReset.ID.String())
 	if err != nil {
 		return nil, err
 	}
+	if !used {
+		return nil, invalid
+	}
+
+	if err := user.ChangePassword(uc.passwordHasher, input.Password); err != nil {
+		return nil, err
+	}
+	user.RecordWebhookEvent(entities.WebhookEventUserPasswordReset)
 
-	user.Password = string(hashedPassword)
 	if err := uc.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
 
-	passwordReset.MarkAsUsed()
-	if err := uc.passwordResetRepo.Update(ctx, passwordReset); err != nil {
+	if err := uc.revokeUserSessions(ctx, user.ID); err != nil {
 		return nil, err
 	}
 
+	recordAudit(ctx, uc.auditRepo, entities.NewAuditEvent(entities.AuditActionPasswordResetCompleted, user.ID.String(), user.ID.String()))
+
 	return &ResetPasswordOutput{
-		Message: "Senha alterada com sucesso.",
+		Message: localized(ctx, "password_changed"),
 	}, nil
 }
+
+// sendEmail queues an email for the user, in the user's language. Failures
+// are logged and do not fail the action.
+func (uc *UserUseCase) sendEmail(ctx context.Context, user *entities.User, to, template string, data map[string]any) {
+	if err := uc.emails.Enqueue(ctx, to, userLocale(ctx, user), template, data); err != nil {
+		log.Printf("Error queueing %s email: %v", template, err)
+	}
+}
+
+// auditPasswordResetRequest records a password reset request and whether a
+// code was sent. Requests for unknown emails are recorded too, as they may
+// be probing for accounts.
+func (uc *UserUseCase) auditPasswordResetRequest(ctx context.Context, input RequestPasswordResetInput, user *entities.User, outcome string) {
+	event := entities.NewAuditEvent(entities.AuditActionPasswordResetRequested, "", "")
+	if user != nil {
+		event.TargetID = user.ID.String()
+	}
+	event.Metadata = map[string]string{"outcome": outcome}
+	if input.Phone != "" {
+		event.Metadata["phone"] = input.Phone
+	} else {
+		event.Metadata["email"] = input.Email
+	}
+	recordAudit(ctx, uc.auditRepo, event)
+}
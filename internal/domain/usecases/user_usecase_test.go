@@ -0,0 +1,573 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+// assertSession checks whether the access token is still accepted, the way
+// the auth middleware does.
+func assertSession(t *testing.T, uc *userUseCaseFixture, token string, wantRevoked bool) {
+	t.Helper()
+
+	claims, err := uc.jwtService.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("got an invalid token: %v", err)
+	}
+	revoked, err := uc.revocations.IsRevoked(context.Background(), claims.ID, claims.UserID, claims.IssuedAt.Time)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revoked != wantRevoked {
+		t.Errorf("got revoked %v, want %v", revoked, wantRevoked)
+	}
+}
+
+func TestLogoutAllRevokesTokensOfTheSameSecond(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, lenientThrottling, user)
+
+	// Issued right before, so almost always within the second of the
+	// revocation.
+	access, err := uc.generateAccessToken(context.Background(), user, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := uc.LogoutAll(context.Background(), user.ID.String()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertSession(t, uc, access.token, true)
+}
+
+func TestChangePasswordKeepsOnlyTheReplacingSession(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, lenientThrottling, user)
+	ctx := context.Background()
+
+	session, err := uc.completeLogin(ctx, user, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output, err := uc.ChangePassword(ctx, user.ID.String(), "", ChangePasswordInput{CurrentPassword: testPassword, NewPassword: "another horse battery"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertSession(t, uc, session.Token, true)
+	assertSession(t, uc, output.Token, false)
+
+	if _, err := uc.RefreshToken(ctx, RefreshTokenInput{RefreshToken: session.RefreshToken}); err == nil {
+		t.Error("the refresh token of the old session still works")
+	}
+	if _, err := uc.RefreshToken(ctx, RefreshTokenInput{RefreshToken: output.RefreshToken}); err != nil {
+		t.Errorf("got error %v for the refresh token of the new session", err)
+	}
+}
+
+func TestChangeRequiredPasswordKeepsOnlyTheReplacingSession(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	user.MustChangePassword = true
+	uc := newUserUseCaseFixture(t, lenientThrottling, user)
+	ctx := context.Background()
+
+	old, err := uc.generateAccessToken(ctx, user, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	challenge, err := uc.jwtService.GeneratePasswordChangeToken(user.ID.String(), user.Email, user.Name, user.Role, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output, err := uc.ChangeRequiredPassword(ctx, ChangeRequiredPasswordInput{PasswordChangeToken: challenge, NewPassword: "another horse battery"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertSession(t, uc, old.token, true)
+	assertSession(t, uc, output.Token, false)
+}
+
+func TestGenerateAccessTokenReturnsTokenID(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, lenientThrottling, user)
+
+	access, err := uc.generateAccessToken(context.Background(), user, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, err := uc.jwtService.ValidateToken(access.token)
+	if err != nil {
+		t.Fatalf("got an invalid token: %v", err)
+	}
+	if claims.ID != access.id || access.role != entities.RoleUser {
+		t.Errorf("got id %q and role %q, want %q and %q", access.id, access.role, claims.ID, entities.RoleUser)
+	}
+}
+
+// memoryPasswordResetRepository keeps the resets in memory. When gate is
+// set, every lookup waits for the others, so concurrent requests all read
+// the reset before any of them goes on.
+type memoryPasswordResetRepository struct {
+	repositories.PasswordResetRepository
+
+	mu     sync.Mutex
+	resets []*entities.PasswordReset
+	gate   *sync.WaitGroup
+}
+
+func (r *memoryPasswordResetRepository) Create(ctx context.Context, passwordReset *entities.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *passwordReset
+	r.resets = append(r.resets, &copied)
+	return nil
+}
+
+func (r *memoryPasswordResetRepository) FindLatestByUserID(ctx context.Context, userID string) (*entities.PasswordReset, error) {
+	if r.gate != nil {
+		r.gate.Done()
+		r.gate.Wait()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := len(r.resets) - 1; i >= 0; i-- {
+		if r.resets[i].UserID.String() == userID {
+			copied := *r.resets[i]
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryPasswordResetRepository) ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, reset := range r.resets {
+		if reset.ID.String() == id && reset.Attempts < maxAttempts && !reset.Used {
+			reset.Attempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryPasswordResetRepository) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, reset := range r.resets {
+		if reset.ID.String() == id && !reset.Used {
+			reset.Used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newPasswordResetFixture returns a fixture with a code reset pending for
+// the user, and the code.
+func newPasswordResetFixture(t *testing.T, user *entities.User) (*userUseCaseFixture, *memoryPasswordResetRepository, string) {
+	t.Helper()
+
+	uc := newUserUseCaseFixture(t, lenientThrottling, user)
+	reset, code, err := entities.NewPasswordReset(user.ID, user.Email, entities.PasswordResetModeCode)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resets := &memoryPasswordResetRepository{resets: []*entities.PasswordReset{reset}}
+	uc.passwordResetRepo = resets
+	return uc, resets, code
+}
+
+// wrongCode returns another code of the same length.
+func wrongCode(code string) string {
+	last := code[len(code)-1]
+	return code[:len(code)-1] + string('0'+(last-'0'+1)%10)
+}
+
+func TestResetPasswordAttemptLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		wrongGuesses int
+		expired      bool
+		used         bool
+		wantErr      bool
+	}{
+		{name: "first attempt", wrongGuesses: 0},
+		{name: "last attempt", wrongGuesses: entities.PasswordResetMaxAttempts - 1},
+		{name: "out of attempts", wrongGuesses: entities.PasswordResetMaxAttempts, wantErr: true},
+		{name: "expired", expired: true, wantErr: true},
+		{name: "already used", used: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, testEmail, testPassword)
+			uc, resets, code := newPasswordResetFixture(t, user)
+			ctx := context.Background()
+
+			if tt.expired {
+				resets.resets[0].ExpiresAt = time.Now().Add(-time.Minute)
+			}
+			resets.resets[0].Used = tt.used
+
+			for i := 0; i < tt.wrongGuesses; i++ {
+				_, err := uc.ResetPassword(ctx, ResetPasswordInput{Email: testEmail, Code: wrongCode(code), Password: "another horse battery"})
+				assertCode(t, err, "invalid_or_expired_token")
+			}
+
+			_, err := uc.ResetPassword(ctx, ResetPasswordInput{Email: testEmail, Code: code, Password: "another horse battery"})
+			if tt.wantErr {
+				assertCode(t, err, "invalid_or_expired_token")
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			stored, _ := uc.users.FindByID(ctx, user.ID.String())
+			if ok, _ := (plainPasswordHasher{}).Verify("another horse battery", stored.Password); !ok {
+				t.Error("the password was not changed")
+			}
+		})
+	}
+}
+
+// TestResetPasswordConcurrentGuessesStayWithinLimit sends a burst of wrong
+// codes that all read the reset before any of them is counted, as a checker
+// that only counted failures after comparing would let through.
+func TestResetPasswordConcurrentGuessesStayWithinLimit(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc, resets, code := newPasswordResetFixture(t, user)
+
+	const guesses = 2 * entities.PasswordResetMaxAttempts
+	resets.gate = &sync.WaitGroup{}
+	resets.gate.Add(guesses)
+
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.ResetPassword(context.Background(), ResetPasswordInput{Email: testEmail, Code: wrongCode(code), Password: "another horse battery"})
+			if err == nil {
+				t.Error("a wrong code was accepted")
+			}
+		}()
+	}
+	wg.Wait()
+	resets.gate = nil
+
+	if got := resets.resets[0].Attempts; got != entities.PasswordResetMaxAttempts {
+		t.Errorf("got %d attempts counted, want %d", got, entities.PasswordResetMaxAttempts)
+	}
+
+	_, err := uc.ResetPassword(context.Background(), ResetPasswordInput{Email: testEmail, Code: code, Password: "another horse battery"})
+	assertCode(t, err, "invalid_or_expired_token")
+}
+
+func TestRequestPasswordResetAnswersTheSameForEveryOutcome(t *testing.T) {
+	user := newTestUser(t, testEmail, testPassword)
+	uc := newUserUseCaseFixture(t, lenientThrottling, user)
+	uc.passwordResetRepo = &memoryPasswordResetRepository{}
+	uc.passwordReset = PasswordResetConfig{Mode: entities.PasswordResetModeLink, URL: "https://app.example.com/reset"}
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		email       string
+		wantOutcome string
+	}{
+		{name: "unknown email", email: "nobody@example.com", wantOutcome: "unknown_email"},
+		{name: "reset sent", email: testEmail, wantOutcome: "sent"},
+		{name: "reset pending", email: testEmail, wantOutcome: "already_pending"},
+	}
+
+	var messages []string
+	for _, tt := range tests {
+		output, err := uc.RequestPasswordReset(ctx, RequestPasswordResetInput{Email: tt.email})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		messages = append(messages, output.Message)
+	}
+
+	for i, tt := range tests {
+		if messages[i] != messages[0] {
+			t.Errorf("%s: got message %q, want %q as for every outcome", tt.name, messages[i], messages[0])
+		}
+	}
+
+	// The outcome is still told apart in the audit log.
+	var got, want []string
+	for _, event := range uc.audit.events {
+		got = append(got, event.Metadata["outcome"])
+	}
+	for _, tt := range tests {
+		want = append(want, tt.wantOutcome)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got outcomes %v, want %v", got, want)
+	}
+	if got := uc.outbox.sent(entities.EmailTemplatePasswordReset); !slices.Equal(got, []string{testEmail}) {
+		t.Errorf("got reset emails sent to %v, want only %s", got, testEmail)
+	}
+}
+
+func TestCheckPasswordUpgradesOutdatedHashes(t *testing.T) {
+	newHasher := func(algorithm string) *services.PasswordHasher {
+		hasher, err := services.NewPasswordHasher(services.PasswordHasherConfig{
+			Algorithm:         algorithm,
+			BcryptCost:        4,
+			Argon2Memory:      64,
+			Argon2Time:        1,
+			Argon2Parallelism: 1,
+		})
+		if err != nil {
+			t.Fatalf("failed to create password hasher: %v", err)
+		}
+		return hasher
+	}
+	bcrypt := newHasher(services.PasswordHashBcrypt)
+	argon2 := newHasher(services.PasswordHashArgon2id)
+
+	tests := []struct {
+		name        string
+		stored      *services.PasswordHasher
+		password    string
+		wantOK      bool
+		wantUpgrade bool
+	}{
+		{name: "outdated hash", stored: bcrypt, password: testPassword, wantOK: true, wantUpgrade: true},
+		{name: "outdated hash, wrong password", stored: bcrypt, password: "wrong password", wantOK: false},
+		{name: "current hash", stored: argon2, password: testPassword, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, testEmail, testPassword)
+			if err := user.SetPassword(tt.stored, testPassword); err != nil {
+				t.Fatalf("failed to hash password: %v", err)
+			}
+			storedHash := user.Password
+			uc := newUserUseCaseFixture(t, lenientThrottling, user)
+			uc.passwordHasher = argon2
+
+			// A copy is checked, as a login does, so only a saved upgrade
+			// reaches the repository.
+			loaded, _ := uc.users.FindByID(context.Background(), user.ID.String())
+			if got := uc.checkPassword(context.Background(), loaded, tt.password); got != tt.wantOK {
+				t.Fatalf("got checkPassword %v, want %v", got, tt.wantOK)
+			}
+
+			saved, _ := uc.users.FindByID(context.Background(), user.ID.String())
+			if upgraded := saved.Password != storedHash; upgraded != tt.wantUpgrade {
+				t.Fatalf("got hash upgraded %v, want %v", upgraded, tt.wantUpgrade)
+			}
+			if tt.wantUpgrade {
+				if argon2.NeedsRehash(saved.Password) {
+					t.Errorf("got %q saved, want a hash in the preferred format", saved.Password)
+				}
+				if !saved.CheckPassword(argon2, testPassword) {
+					t.Error("got the upgraded hash rejecting the password")
+				}
+			}
+		})
+	}
+}
+
+// assignableRoleRepository finds every role asked for, granting nothing, so
+// any actor with roles:assign may assign it.
+type assignableRoleRepository struct {
+	repositories.RoleRepository
+}
+
+func (r *assignableRoleRepository) FindByName(ctx context.Context, name string) (*entities.Role, error) {
+	return &entities.Role{Name: name}, nil
+}
+
+func (r *memoryUserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, user := range r.users {
+		if user.EffectiveRole() == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+	return nil
+}
+
+func TestUpdateUserFieldAuthorization(t *testing.T) {
+	tests := []struct {
+		name     string
+		self     bool
+		perms    []string
+		input    func(target *entities.User) UpdateUserInput
+		wantCode string
+	}{
+		{
+			name: "own name",
+			self: true,
+			input: func(target *entities.User) UpdateUserInput {
+				return UpdateUserInput{Name: "New Name", Email: target.Email}
+			},
+		},
+		{
+			name:  "own email",
+			self:  true,
+			perms: []string{entities.PermissionUsersWrite},
+			input: func(target *entities.User) UpdateUserInput {
+				return UpdateUserInput{Name: target.Name, Email: "new@example.com"}
+			},
+			wantCode: "email_change_requires_confirmation",
+		},
+		{
+			name: "own role",
+			self: true,
+			input: func(target *entities.User) UpdateUserInput {
+				return UpdateUserInput{Name: target.Name, Email: target.Email, Role: "support"}
+			},
+			wantCode: "forbidden",
+		},
+		{
+			name: "name of another user",
+			input: func(target *entities.User) UpdateUserInput {
+				return UpdateUserInput{Name: "New Name", Email: target.Email}
+			},
+			wantCode: "forbidden",
+		},
+		{
+			name:  "name of another user with users:write",
+			perms: []string{entities.PermissionUsersWrite},
+			input: func(target *entities.User) UpdateUserInput {
+				return UpdateUserInput{Name: "New Name", Email: target.Email}
+			},
+		},
+		{
+			name:  "role with users:write",
+			perms: []string{entities.PermissionUsersWrite},
+			input: func(target *entities.User) UpdateUserInput {
+				return UpdateUserInput{Name: target.Name, Email: target.Email, Role: "support"}
+			},
+			wantCode: "forbidden",
+		},
+		{
+			name:  "role with roles:assign",
+			perms: []string{entities.PermissionRolesAssign},
+			input: func(target *entities.User) UpdateUserInput {
+				return UpdateUserInput{Name: target.Name, Email: target.Email, Role: "support"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newTestUser(t, testEmail, testPassword)
+			uc := newUserUseCaseFixture(t, lenientThrottling, target)
+			uc.roleRepo = &assignableRoleRepository{}
+
+			actor := entities.Actor{UserID: uuid.NewString(), Permissions: tt.perms}
+			if tt.self {
+				actor.UserID = target.ID.String()
+			}
+			input := tt.input(target)
+
+			_, err := uc.UpdateUser(context.Background(), actor, target.ID.String(), input)
+			saved, _ := uc.users.FindByID(context.Background(), target.ID.String())
+			if tt.wantCode != "" {
+				assertCode(t, err, tt.wantCode)
+				if saved.Name != target.Name || saved.Email != target.Email || saved.Role != target.Role {
+					t.Errorf("got %s, %s, %s saved, want the user unchanged", saved.Name, saved.Email, saved.Role)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateUser failed: %v", err)
+			}
+			if saved.Name != input.Name || (input.Role != "" && saved.Role != input.Role) {
+				t.Errorf("got %s with role %s saved, want %s with role %s", saved.Name, saved.Role, input.Name, input.Role)
+			}
+		})
+	}
+}
+
+func TestLastAdminCannotBeDemotedOrDeleted(t *testing.T) {
+	actor := entities.Actor{UserID: uuid.NewString(), Permissions: []string{entities.PermissionRolesAssign, entities.PermissionUsersDelete}}
+
+	tests := []struct {
+		name     string
+		admins   int
+		delete   bool
+		wantCode string
+	}{
+		{name: "demote the last admin", admins: 1, wantCode: "last_admin"},
+		{name: "delete the last admin", admins: 1, delete: true, wantCode: "last_admin"},
+		{name: "demote one of two admins", admins: 2},
+		{name: "delete one of two admins", admins: 2, delete: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var admins []*entities.User
+			for i := range tt.admins {
+				admin := newTestUser(t, fmt.Sprintf("admin%d@example.com", i), testPassword)
+				admin.Role = entities.RoleSuperAdmin
+				admins = append(admins, admin)
+			}
+			uc := newUserUseCaseFixture(t, lenientThrottling, admins...)
+			uc.roleRepo = &assignableRoleRepository{}
+			target := admins[0]
+
+			var err error
+			if tt.delete {
+				_, err = uc.DeleteUser(context.Background(), actor, target.ID.String())
+			} else {
+				_, err = uc.UpdateUser(context.Background(), actor, target.ID.String(), UpdateUserInput{Name: target.Name, Email: target.Email, Role: entities.RoleUser})
+			}
+
+			remaining, _ := uc.users.CountByRole(context.Background(), entities.RoleSuperAdmin)
+			if tt.wantCode != "" {
+				assertCode(t, err, tt.wantCode)
+				if remaining != 1 {
+					t.Errorf("got %d admins left, want the last one kept", remaining)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if remaining != 1 {
+				t.Errorf("got %d admins left, want 1", remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,472 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+var (
+	ErrWebhookNotFound         = entities.NewCodedError("webhook_not_found", "webhook not found")
+	ErrWebhookDeliveryNotFound = entities.NewCodedError("webhook_delivery_not_found", "webhook delivery not found")
+)
+
+const (
+	defaultWebhookDeliveriesPage = 50
+	maxWebhookDeliveriesPage     = 200
+)
+
+// WebhookDispatchConfig controls the background dispatcher: how often it
+// runs, how many deliveries it sends at once, how long it waits for each
+// endpoint and how failed deliveries are retried.
+type WebhookDispatchConfig struct {
+	Interval  time.Duration
+	BatchSize int
+	Timeout   time.Duration
+	Retry     entities.WebhookRetryPolicy
+}
+
+type CreateWebhookInput struct {
+	URL         string   `json:"url" validate:"required"`
+	Description string   `json:"description"`
+	Events      []string `json:"events" validate:"required"`
+}
+
+type UpdateWebhookInput struct {
+	URL         string   `json:"url" validate:"required"`
+	Description string   `json:"description"`
+	Events      []string `json:"events" validate:"required"`
+	Active      *bool    `json:"active"`
+}
+
+type WebhookOutput struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	Active      bool     `json:"active"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// WebhookSecretOutput is returned when a secret is generated, the only time
+// it is shown.
+type WebhookSecretOutput struct {
+	WebhookOutput
+	Secret string `json:"secret"`
+}
+
+type ListWebhooksOutput struct {
+	Webhooks []WebhookOutput `json:"webhooks"`
+}
+
+type DeleteWebhookOutput struct {
+	Message string `json:"message"`
+}
+
+type ListWebhookDeliveriesInput struct {
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+}
+
+type WebhookDeliveryOutput struct {
+	ID             string `json:"id"`
+	EventID        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  string `json:"last_attempt_at,omitempty"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type ListWebhookDeliveriesOutput struct {
+	Deliveries []WebhookDeliveryOutput `json:"deliveries"`
+}
+
+type WebhookDeliveryAttemptOutput struct {
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type WebhookDeliveryDetailOutput struct {
+	WebhookDeliveryOutput
+	Payload     json.RawMessage                `json:"payload"`
+	AttemptLogs []WebhookDeliveryAttemptOutput `json:"attempt_logs"`
+}
+
+// WebhookUseCase manages webhook subscriptions and delivers the events
+// written to the outbox. Subscriptions are platform wide: they receive the
+// events of every organization, identified in the payload.
+type WebhookUseCase struct {
+	webhookRepo repositories.WebhookRepository
+	client      *services.WebhookClient
+	dispatch    WebhookDispatchConfig
+}
+
+func NewWebhookUseCase(webhookRepo repositories.WebhookRepository, client *services.WebhookClient, dispatch WebhookDispatchConfig) *WebhookUseCase {
+	return &WebhookUseCase{
+		webhookRepo: webhookRepo,
+		client:      client,
+		dispatch:    dispatch,
+	}
+}
+
+func (uc *WebhookUseCase) ListWebhooks(ctx context.Context) (*ListWebhooksOutput, error) {
+	subscriptions, err := uc.webhookRepo.FindAllSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListWebhooksOutput{Webhooks: make([]WebhookOutput, 0, len(subscriptions))}
+	for _, subscription := range subscriptions {
+		output.Webhooks = append(output.Webhooks, toWebhookOutput(subscription))
+	}
+	return output, nil
+}
+
+func (uc *WebhookUseCase) GetWebhook(ctx context.Context, webhookID string) (*WebhookOutput, error) {
+	subscription, err := uc.findSubscription(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toWebhookOutput(subscription)
+	return &output, nil
+}
+
+func (uc *WebhookUseCase) CreateWebhook(ctx context.Context, input CreateWebhookInput) (*WebhookSecretOutput, error) {
+	subscription, secret, err := entities.NewWebhookSubscription(strings.TrimSpace(input.URL), input.Description, input.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return &WebhookSecretOutput{
+		WebhookOutput: toWebhookOutput(subscription),
+		Secret:        secret,
+	}, nil
+}
+
+// UpdateWebhook replaces the URL, description and events of a subscription,
+// and pauses or resumes it. Deliveries of a paused subscription wait until it
+// is resumed.
+func (uc *WebhookUseCase) UpdateWebhook(ctx context.Context, webhookID string, input UpdateWebhookInput) (*WebhookOutput, error) {
+	subscription, err := uc.findSubscription(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSpace(input.URL)
+	if err := entities.ValidateWebhookSubscriptionData(url, input.Events); err != nil {
+		return nil, err
+	}
+
+	subscription.URL = url
+	subscription.Description = strings.TrimSpace(input.Description)
+	subscription.Events = strings.Join(input.Events, " ")
+	if input.Active != nil {
+		subscription.Active = *input.Active
+	}
+
+	if err := uc.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	output := toWebhookOutput(subscription)
+	return &output, nil
+}
+
+func (uc *WebhookUseCase) DeleteWebhook(ctx context.Context, webhookID string) (*DeleteWebhookOutput, error) {
+	subscription, err := uc.findSubscription(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.webhookRepo.DeleteSubscription(ctx, subscription.ID.String()); err != nil {
+		return nil, err
+	}
+
+	return &DeleteWebhookOutput{
+		Message: localized(ctx, "webhook_deleted"),
+	}, nil
+}
+
+// RotateWebhookSecret replaces the signing secret. Deliveries sent from then
+// on, including retries, are signed with the new one.
+func (uc *WebhookUseCase) RotateWebhookSecret(ctx context.Context, webhookID string) (*WebhookSecretOutput, error) {
+	subscription, err := uc.findSubscription(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := subscription.RotateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return &WebhookSecretOutput{
+		WebhookOutput: toWebhookOutput(subscription),
+		Secret:        secret,
+	}, nil
+}
+
+// ListDeliveries lists the latest deliveries of a subscription, newest first.
+func (uc *WebhookUseCase) ListDeliveries(ctx context.Context, webhookID string, input ListWebhookDeliveriesInput) (*ListWebhookDeliveriesOutput, error) {
+	subscription, err := uc.findSubscription(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch input.Status {
+	case "", entities.WebhookDeliveryPending, entities.WebhookDeliverySucceeded, entities.WebhookDeliveryDead:
+	default:
+		return nil, entities.NewCodedError("invalid_status", "invalid status")
+	}
+
+	limit := input.Limit
+	if limit == 0 {
+		limit = defaultWebhookDeliveriesPage
+	}
+	if limit < 1 || limit > maxWebhookDeliveriesPage {
+		return nil, entities.NewCodedError("invalid_limit", "limit must be between 1 and 200")
+	}
+
+	deliveries, err := uc.webhookRepo.FindDeliveries(ctx, subscription.ID.String(), input.Status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ListWebhookDeliveriesOutput{Deliveries: make([]WebhookDeliveryOutput, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		output.Deliveries = append(output.Deliveries, toWebhookDeliveryOutput(delivery))
+	}
+	return output, nil
+}
+
+// GetDelivery returns a delivery with its payload and every attempt made,
+// with the response code received.
+func (uc *WebhookUseCase) GetDelivery(ctx context.Context, webhookID, deliveryID string) (*WebhookDeliveryDetailOutput, error) {
+	delivery, err := uc.findDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := uc.webhookRepo.FindAttempts(ctx, delivery.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	output := &WebhookDeliveryDetailOutput{
+		WebhookDeliveryOutput: toWebhookDeliveryOutput(delivery),
+		Payload:               json.RawMessage(delivery.Payload),
+		AttemptLogs:           make([]WebhookDeliveryAttemptOutput, 0, len(attempts)),
+	}
+	for _, attempt := range attempts {
+		output.AttemptLogs = append(output.AttemptLogs, WebhookDeliveryAttemptOutput{
+			Attempt:    attempt.Attempt,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMS: attempt.DurationMS,
+			CreatedAt:  attempt.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+	return output, nil
+}
+
+// RetryDelivery sends a dead-lettered or pending delivery again on the next
+// dispatch, with a fresh set of attempts.
+func (uc *WebhookUseCase) RetryDelivery(ctx context.Context, webhookID, deliveryID string) (*WebhookDeliveryOutput, error) {
+	delivery, err := uc.findDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := delivery.Retry(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := uc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	output := toWebhookDeliveryOutput(delivery)
+	return &output, nil
+}
+
+// RunDispatcher delivers webhooks every interval until the context is done.
+// Several instances can run it at once: events and deliveries are claimed
+// with row locks, so each is handled by a single one.
+func (uc *WebhookUseCase) RunDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(uc.dispatch.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error dispatching webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch fans the outbox out into deliveries and sends the deliveries that
+// are due.
+func (uc *WebhookUseCase) Dispatch(ctx context.Context) error {
+	for {
+		processed, err := uc.webhookRepo.FanOut(ctx, uc.dispatch.BatchSize)
+		if err != nil {
+			return err
+		}
+		if processed < uc.dispatch.BatchSize {
+			break
+		}
+	}
+
+	// The lease covers the time to send a whole batch, as deliveries are
+	// sent concurrently and each is bounded by the timeout.
+	lease := 2 * uc.dispatch.Timeout
+	deliveries, err := uc.webhookRepo.ClaimDueDeliveries(ctx, time.Now(), lease, uc.dispatch.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *entities.WebhookDelivery) {
+			defer wg.Done()
+			uc.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (uc *WebhookUseCase) deliver(ctx context.Context, delivery *entities.WebhookDelivery) {
+	started := time.Now()
+	statusCode, err := uc.client.Send(ctx, delivery.Subscription, delivery)
+	duration := time.Since(started)
+
+	var errMessage string
+	if err != nil {
+		errMessage = err.Error()
+	}
+
+	attempt := delivery.RecordAttempt(statusCode, errMessage, duration, uc.dispatch.Retry, time.Now())
+	if err := uc.webhookRepo.SaveAttempt(ctx, delivery, attempt); err != nil {
+		log.Printf("Error saving webhook delivery %s: %v", delivery.ID, err)
+		return
+	}
+
+	if delivery.Status == entities.WebhookDeliveryDead {
+		log.Printf("Webhook delivery %s to %s dead-lettered after %d attempts", delivery.ID, delivery.Subscription.URL, delivery.Attempts)
+	}
+}
+
+func (uc *WebhookUseCase) findSubscription(ctx context.Context, webhookID string) (*entities.WebhookSubscription, error) {
+	if err := entities.ValidateUUID(webhookID); err != nil {
+		return nil, err
+	}
+
+	subscription, err := uc.webhookRepo.FindSubscriptionByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+func (uc *WebhookUseCase) findDelivery(ctx context.Context, webhookID, deliveryID string) (*entities.WebhookDelivery, error) {
+	subscription, err := uc.findSubscription(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := entities.ValidateUUID(deliveryID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := uc.webhookRepo.FindDelivery(ctx, subscription.ID.String(), deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// enqueueUserWebhook writes an event that changes no data, like a login, to
+// the outbox. Failures are logged and do not fail the action.
+func enqueueUserWebhook(ctx context.Context, webhookRepo repositories.WebhookRepository, eventType string, user *entities.User, organizationID string) {
+	event, err := entities.NewUserWebhookEvent(eventType, user, organizationID)
+	if err == nil {
+		err = webhookRepo.Enqueue(ctx, event)
+	}
+	if err != nil {
+		log.Printf("Error enqueuing webhook event %s: %v", eventType, err)
+	}
+}
+
+func toWebhookOutput(subscription *entities.WebhookSubscription) WebhookOutput {
+	return WebhookOutput{
+		ID:          subscription.ID.String(),
+		URL:         subscription.URL,
+		Description: subscription.Description,
+		Events:      subscription.EventList(),
+		Active:      subscription.Active,
+		CreatedAt:   subscription.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   subscription.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func toWebhookDeliveryOutput(delivery *entities.WebhookDelivery) WebhookDeliveryOutput {
+	output := WebhookDeliveryOutput{
+		ID:             delivery.ID.String(),
+		EventID:        delivery.EventID.String(),
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if delivery.Status == entities.WebhookDeliveryPending {
+		output.NextAttemptAt = delivery.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if delivery.LastAttemptAt != nil {
+		output.LastAttemptAt = delivery.LastAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if delivery.DeliveredAt != nil {
+		output.DeliveredAt = delivery.DeliveredAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return output
+}
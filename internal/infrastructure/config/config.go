@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type DatabaseConfig struct {
@@ -15,14 +17,163 @@ type DatabaseConfig struct {
 }
 
 type Config struct {
-	Port      string
-	Database  DatabaseConfig
-	JWTSecret string
+	Port                 string
+	TrustedProxies       string
+	Database             DatabaseConfig
+	JWTSecret            string
+	JWTKeysDir           string
+	JWTSigningAlgorithm  string
+	TokenRevocationStore string
+	IssuerURL            string
+	TenantBaseDomain     string
+	DefaultLocale        string
+	AuditHashChain       bool
+	Registration         RegistrationConfig
+	PasswordReset        PasswordResetConfig
+	PasswordHashing      PasswordHashingConfig
+	PasswordPolicy       PasswordPolicyConfig
+	LoginThrottle        LoginThrottleConfig
+	RateLimit            RateLimitConfig
+	Redis                RedisConfig
+	Webhooks             WebhooksConfig
+	SMS                  SMSConfig
+	Email                EmailConfig
+	Cleanup              CleanupConfig
+	Bootstrap            BootstrapConfig
+}
+
+type PasswordHashingConfig struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      int
+	Argon2Time        int
+	Argon2Parallelism int
+	PepperFile        string
+}
+
+type PasswordPolicyConfig struct {
+	MinLength             int
+	MaxLength             int
+	RequireUppercase      bool
+	RequireLowercase      bool
+	RequireDigit          bool
+	RequireSymbol         bool
+	MaxRepeatedChars      int
+	RejectPersonalInfo    bool
+	MinStrengthScore      int
+	BreachedPasswordsFile string
+	MaxAge                time.Duration
+}
+
+type LoginThrottleConfig struct {
+	Store           string
+	FreeAttempts    int
+	BackoffBase     time.Duration
+	BackoffMax      time.Duration
+	MaxFailures     int
+	LockoutDuration time.Duration
+	FailureWindow   time.Duration
+	IPFreeAttempts  int
+	IPBackoffMax    time.Duration
+}
+
+// RateLimitConfig holds the rate of each route group as "limit/window", like
+// "10/1m". A limit of 0 disables the group's limit.
+type RateLimitConfig struct {
+	Enabled       bool
+	Store         string
+	Login         string
+	PasswordReset string
+	Auth          string
+	OAuth         string
+	API           string
+}
+
+// WebhooksConfig controls the delivery of webhooks. Disabling the dispatcher
+// keeps writing events to the outbox, so another instance can deliver them.
+type WebhooksConfig struct {
+	DispatcherEnabled bool
+	DispatchInterval  time.Duration
+	BatchSize         int
+	Timeout           time.Duration
+	MaxAttempts       int
+	BackoffBase       time.Duration
+	BackoffMax        time.Duration
+}
+
+// SMSConfig selects how text messages are sent: "log" only logs them, for
+// development, and "http" posts them to the provider at BaseURL.
+type SMSConfig struct {
+	Provider string
+	BaseURL  string
+	APIKey   string
+	From     string
+	Timeout  time.Duration
+}
+
+// EmailConfig selects how emails are sent: "smtp" through the SMTP server,
+// "maildir" into the MaildirDir directory, for development, and "memory" only
+// keeps them in memory, for tests. Emails are queued in the database and sent
+// by the worker, which can be disabled like the webhook dispatcher.
+type EmailConfig struct {
+	Sender           string
+	From             string
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPTLS          string
+	MaildirDir       string
+	TemplatesDir     string
+	DefaultLocale    string
+	WorkerEnabled    bool
+	DispatchInterval time.Duration
+	BatchSize        int
+	Timeout          time.Duration
+	MaxAttempts      int
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+}
+
+// CleanupConfig controls the worker deleting expired records. Cleaning is
+// idempotent, so it may run on every instance.
+type CleanupConfig struct {
+	WorkerEnabled bool
+	Interval      time.Duration
+}
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+type RegistrationConfig struct {
+	Enabled                  bool
+	RequireEmailVerification bool
+	VerificationURL          string
+}
+
+// PasswordResetConfig chooses whether password resets are sent as a code or
+// as a link to URL.
+type PasswordResetConfig struct {
+	Mode string
+	URL  string
+}
+
+// BootstrapConfig is the first admin, created at startup while no admin
+// exists. Without an email a one-time setup token is issued instead.
+type BootstrapConfig struct {
+	AdminName         string
+	AdminEmail        string
+	AdminPassword     string
+	AdminPasswordFile string
 }
 
 func Load() *Config {
 	return &Config{
-		Port: getEnv("PORT", "8080"),
+		Port:           getEnv("PORT", "8080"),
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -31,7 +182,114 @@ func Load() *Config {
 			DBName:   getEnv("DB_NAME", "auth_api_dev"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
-		JWTSecret: getEnv("JWT_SECRET", "your-secret-key"),
+		JWTSecret:            getEnv("JWT_SECRET", "your-secret-key"),
+		JWTKeysDir:           getEnv("JWT_KEYS_DIR", ""),
+		JWTSigningAlgorithm:  getEnv("JWT_SIGNING_ALGORITHM", "RS256"),
+		TokenRevocationStore: getEnv("TOKEN_REVOCATION_STORE", "postgres"),
+		IssuerURL:            getEnv("ISSUER_URL", "http://localhost:8080"),
+		TenantBaseDomain:     getEnv("TENANT_BASE_DOMAIN", ""),
+		DefaultLocale:        getEnv("DEFAULT_LOCALE", "pt-BR"),
+		AuditHashChain:       getEnv("AUDIT_HASH_CHAIN", "true") == "true",
+		Registration: RegistrationConfig{
+			Enabled:                  getEnv("REGISTRATION_ENABLED", "false") == "true",
+			RequireEmailVerification: getEnv("REQUIRE_EMAIL_VERIFICATION", "false") == "true",
+			VerificationURL:          getEnv("EMAIL_VERIFICATION_URL", ""),
+		},
+		PasswordReset: PasswordResetConfig{
+			Mode: getEnv("PASSWORD_RESET_MODE", "code"),
+			URL:  getEnv("PASSWORD_RESET_URL", ""),
+		},
+		PasswordHashing: PasswordHashingConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			BcryptCost:        getEnvInt("BCRYPT_COST", 10),
+			Argon2Memory:      getEnvInt("ARGON2_MEMORY", 19456),
+			Argon2Time:        getEnvInt("ARGON2_TIME", 2),
+			Argon2Parallelism: getEnvInt("ARGON2_PARALLELISM", 1),
+			PepperFile:        getEnv("PASSWORD_PEPPER_FILE", ""),
+		},
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:             getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:             getEnvInt("PASSWORD_MAX_LENGTH", 128),
+			RequireUppercase:      getEnv("PASSWORD_REQUIRE_UPPERCASE", "false") == "true",
+			RequireLowercase:      getEnv("PASSWORD_REQUIRE_LOWERCASE", "false") == "true",
+			RequireDigit:          getEnv("PASSWORD_REQUIRE_DIGIT", "false") == "true",
+			RequireSymbol:         getEnv("PASSWORD_REQUIRE_SYMBOL", "false") == "true",
+			MaxRepeatedChars:      getEnvInt("PASSWORD_MAX_REPEATED_CHARS", 3),
+			RejectPersonalInfo:    getEnv("PASSWORD_REJECT_PERSONAL_INFO", "true") == "true",
+			MinStrengthScore:      getEnvInt("PASSWORD_MIN_STRENGTH_SCORE", 2),
+			BreachedPasswordsFile: getEnv("BREACHED_PASSWORDS_FILE", ""),
+			MaxAge:                getEnvDuration("PASSWORD_MAX_AGE", 0),
+		},
+		LoginThrottle: LoginThrottleConfig{
+			Store:           getEnv("LOGIN_THROTTLE_STORE", "postgres"),
+			FreeAttempts:    getEnvInt("LOGIN_FREE_ATTEMPTS", 3),
+			BackoffBase:     getEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+			BackoffMax:      getEnvDuration("LOGIN_BACKOFF_MAX", time.Minute),
+			MaxFailures:     getEnvInt("LOGIN_MAX_FAILURES", 10),
+			LockoutDuration: getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			FailureWindow:   getEnvDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+			IPFreeAttempts:  getEnvInt("LOGIN_IP_FREE_ATTEMPTS", 20),
+			IPBackoffMax:    getEnvDuration("LOGIN_IP_BACKOFF_MAX", 15*time.Minute),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:       getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+			Store:         getEnv("RATE_LIMIT_STORE", "memory"),
+			Login:         getEnv("RATE_LIMIT_LOGIN", "10/1m"),
+			PasswordReset: getEnv("RATE_LIMIT_PASSWORD_RESET", "5/15m"),
+			Auth:          getEnv("RATE_LIMIT_AUTH", "30/1m"),
+			OAuth:         getEnv("RATE_LIMIT_OAUTH", "60/1m"),
+			API:           getEnv("RATE_LIMIT_API", "300/1m"),
+		},
+		Redis: RedisConfig{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       getEnvInt("REDIS_DB", 0),
+		},
+		Webhooks: WebhooksConfig{
+			DispatcherEnabled: getEnv("WEBHOOK_DISPATCHER_ENABLED", "true") == "true",
+			DispatchInterval:  getEnvDuration("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second),
+			BatchSize:         getEnvInt("WEBHOOK_BATCH_SIZE", 50),
+			Timeout:           getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:       getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			BackoffBase:       getEnvDuration("WEBHOOK_BACKOFF_BASE", 30*time.Second),
+			BackoffMax:        getEnvDuration("WEBHOOK_BACKOFF_MAX", 6*time.Hour),
+		},
+		SMS: SMSConfig{
+			Provider: getEnv("SMS_PROVIDER", "log"),
+			BaseURL:  getEnv("SMS_BASE_URL", ""),
+			APIKey:   getEnv("SMS_API_KEY", ""),
+			From:     getEnv("SMS_FROM", ""),
+			Timeout:  getEnvDuration("SMS_TIMEOUT", 10*time.Second),
+		},
+		Email: EmailConfig{
+			Sender:           getEnv("EMAIL_SENDER", "smtp"),
+			From:             getEnv("EMAIL_FROM", ""),
+			SMTPHost:         getEnv("SMTP_HOST", ""),
+			SMTPPort:         getEnv("SMTP_PORT", "587"),
+			SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:     getEnv("EMAIL_PASSWORD", ""),
+			SMTPTLS:          getEnv("SMTP_TLS", "starttls"),
+			MaildirDir:       getEnv("EMAIL_MAILDIR", "./tmp/maildir"),
+			TemplatesDir:     getEnv("EMAIL_TEMPLATES_DIR", ""),
+			DefaultLocale:    getEnv("EMAIL_DEFAULT_LOCALE", "pt-BR"),
+			WorkerEnabled:    getEnv("EMAIL_WORKER_ENABLED", "true") == "true",
+			DispatchInterval: getEnvDuration("EMAIL_DISPATCH_INTERVAL", 5*time.Second),
+			BatchSize:        getEnvInt("EMAIL_BATCH_SIZE", 20),
+			Timeout:          getEnvDuration("EMAIL_SEND_TIMEOUT", 30*time.Second),
+			MaxAttempts:      getEnvInt("EMAIL_MAX_ATTEMPTS", 8),
+			BackoffBase:      getEnvDuration("EMAIL_BACKOFF_BASE", 30*time.Second),
+			BackoffMax:       getEnvDuration("EMAIL_BACKOFF_MAX", time.Hour),
+		},
+		Cleanup: CleanupConfig{
+			WorkerEnabled: getEnv("CLEANUP_WORKER_ENABLED", "true") == "true",
+			Interval:      getEnvDuration("CLEANUP_INTERVAL", time.Hour),
+		},
+		Bootstrap: BootstrapConfig{
+			AdminName:         getEnv("BOOTSTRAP_ADMIN_NAME", "Admin"),
+			AdminEmail:        getEnv("BOOTSTRAP_ADMIN_EMAIL", ""),
+			AdminPassword:     getEnv("BOOTSTRAP_ADMIN_PASSWORD", ""),
+			AdminPasswordFile: getEnv("BOOTSTRAP_ADMIN_PASSWORD_FILE", ""),
+		},
 	}
 }
 
@@ -52,3 +310,17 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -1,28 +1,53 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
-
-	"api-auth-go/internal/domain/entities"
 )
 
-func NewConnection(databaseURL string) (*gorm.DB, error) {
+// Open connects to the database without checking its schema, for the
+// migrate command. Queries are logged with dbLogger.
+func Open(databaseURL string, dbLogger logger.Interface) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: dbLogger,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	return db, nil
+}
+
+// NewConnection connects to the database and seeds the roles. The schema is
+// not changed: it fails if migrations are pending, which are applied with the
+// migrate up command.
+func NewConnection(databaseURL string, dbLogger logger.Interface) (*gorm.DB, error) {
+	db, err := Open(databaseURL, dbLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check migrations: %w", err)
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("database schema is behind: %d pending migration(s), starting with %04d_%s; run the migrate up command first", len(pending), pending[0].Version, pending[0].Name)
+	}
 
-	if err := db.AutoMigrate(&entities.User{}, &entities.PasswordReset{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	if err := seedRoles(db); err != nil {
+		return nil, err
 	}
 
-	log.Println("Database connected and migrated successfully")
+	log.Println("Database connected successfully")
 	return db, nil
 }
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// baselineColumns are the columns AutoMigrate created in the first version,
+// before versioned migrations, for the only tables it had.
+var baselineColumns = map[string][]string{
+	"users":           {"id", "name", "email", "password", "role", "created_at", "updated_at"},
+	"password_resets": {"id", "user_id", "token", "email", "used", "expires_at", "created_at", "updated_at"},
+}
+
+var (
+	createTableStatement = regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS "(\w+)" \((.*?)\n\);`)
+	columnDefinition     = regexp.MustCompile(`(?m)^\s+"(\w+)" `)
+)
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("got version %d at position %d, want versions without gaps", migration.Version, i+1)
+		}
+	}
+}
+
+// TestInitialSchemaUpgradesBaselineTables checks that every column the
+// initial schema creates on a table of the baseline is also added to that
+// table when it already exists, since CREATE TABLE IF NOT EXISTS leaves it
+// as it is.
+func TestInitialSchemaUpgradesBaselineTables(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var upgrades strings.Builder
+	for _, migration := range migrations {
+		upgrades.WriteString(migration.Up)
+	}
+
+	tables := map[string]bool{}
+	for _, match := range createTableStatement.FindAllStringSubmatch(migrations[0].Up, -1) {
+		table, body := match[1], match[2]
+		baseline, ok := baselineColumns[table]
+		if !ok {
+			continue
+		}
+		tables[table] = true
+
+		for _, column := range columnDefinition.FindAllStringSubmatch(body, -1) {
+			if slices.Contains(baseline, column[1]) {
+				continue
+			}
+
+			alter := `ALTER TABLE "` + table + `" ADD COLUMN IF NOT EXISTS "` + column[1] + `"`
+			if !strings.Contains(upgrades.String(), alter) {
+				t.Errorf("%s.%s is never added to databases of the baseline", table, column[1])
+			}
+		}
+	}
+
+	for table := range baselineColumns {
+		if !tables[table] {
+			t.Errorf("the initial schema does not create %s", table)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS "webhook_delivery_attempts";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_events";
+DROP TABLE IF EXISTS "webhook_subscriptions";
+DROP TABLE IF EXISTS "audit_events";
+DROP TABLE IF EXISTS "memberships";
+DROP TABLE IF EXISTS "organizations";
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "login_throttles";
+DROP TABLE IF EXISTS "email_verifications";
+DROP TABLE IF EXISTS "o_auth_authorization_codes";
+DROP TABLE IF EXISTS "o_auth_clients";
+DROP TABLE IF EXISTS "mfa_recovery_codes";
+DROP TABLE IF EXISTS "user_token_revocations";
+DROP TABLE IF EXISTS "revoked_tokens";
+DROP TABLE IF EXISTS "refresh_tokens";
+DROP TABLE IF EXISTS "password_resets";
+DROP TABLE IF EXISTS "users";
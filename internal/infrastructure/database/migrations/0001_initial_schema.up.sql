@@ -0,0 +1,300 @@
+-- Schema as created by AutoMigrate before versioned migrations were
+-- introduced. Every statement is idempotent: tables that do not exist are
+-- created, and the users table of databases created by the first version,
+-- which only had users and password_resets, gets the columns added since.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    "role" text NOT NULL DEFAULT 'user',
+    "email_verified" boolean NOT NULL DEFAULT false,
+    "email_verified_at" timestamptz,
+    "mfa_enabled" boolean NOT NULL DEFAULT false,
+    "mfa_secret" text,
+    "mfa_pending_secret" text,
+    "mfa_last_used_step" bigint NOT NULL DEFAULT 0,
+    "mfa_enabled_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+
+-- Accounts created before email verification existed were never asked to
+-- verify, so they are taken as verified rather than locked out.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_verified" boolean NOT NULL DEFAULT true;
+ALTER TABLE "users" ALTER COLUMN "email_verified" SET DEFAULT false;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_verified_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_enabled" boolean NOT NULL DEFAULT false;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_secret" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_pending_secret" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_last_used_step" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "mfa_enabled_at" timestamptz;
+
+CREATE TABLE IF NOT EXISTS "password_resets" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "token" text NOT NULL,
+    "email" text NOT NULL,
+    "used" boolean DEFAULT false,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_resets_token" ON "password_resets" ("token");
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "family_id" uuid NOT NULL,
+    "token_hash" text NOT NULL,
+    "client_id" text,
+    "scope" text,
+    "used" boolean DEFAULT false,
+    "used_at" timestamptz,
+    "revoked" boolean DEFAULT false,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "organization_id" uuid,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_organization_id" ON "refresh_tokens" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_client_id" ON "refresh_tokens" ("client_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+    "jti" text,
+    "user_id" uuid NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("jti")
+);
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_user_id" ON "revoked_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_token_revocations" (
+    "user_id" uuid,
+    "revoked_before" timestamptz NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("user_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_token_revocations_expires_at" ON "user_token_revocations" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "mfa_recovery_codes" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "code_hash" text NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_mfa_recovery_codes_user_id" ON "mfa_recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "o_auth_clients" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "client_id" text NOT NULL,
+    "secret_hash" text,
+    "name" text NOT NULL,
+    "redirect_uris" text NOT NULL DEFAULT '',
+    "post_logout_redirect_uris" text NOT NULL DEFAULT '',
+    "grant_types" text NOT NULL,
+    "scopes" text NOT NULL DEFAULT '',
+    "confidential" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_o_auth_clients_client_id" ON "o_auth_clients" ("client_id");
+
+CREATE TABLE IF NOT EXISTS "o_auth_authorization_codes" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "code_hash" text NOT NULL,
+    "client_id" text NOT NULL,
+    "user_id" uuid NOT NULL,
+    "redirect_uri" text NOT NULL,
+    "scope" text,
+    "code_challenge" text,
+    "code_challenge_method" text,
+    "nonce" text,
+    "auth_time" timestamptz NOT NULL,
+    "used" boolean DEFAULT false,
+    "token_family_id" uuid,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_o_auth_authorization_codes_client_id" ON "o_auth_authorization_codes" ("client_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_o_auth_authorization_codes_code_hash" ON "o_auth_authorization_codes" ("code_hash");
+
+CREATE TABLE IF NOT EXISTS "email_verifications" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "email" text NOT NULL,
+    "token_hash" text NOT NULL,
+    "code_hash" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "used_at" timestamptz,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_email_verifications_expires_at" ON "email_verifications" ("expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_email_verifications_token_hash" ON "email_verifications" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_email_verifications_user_id" ON "email_verifications" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "login_throttles" (
+    "key" text,
+    "failures" bigint NOT NULL DEFAULT 0,
+    "last_failure_at" timestamptz NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("key")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_throttles_expires_at" ON "login_throttles" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "permissions" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "description" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_permissions_name" ON "permissions" ("name");
+
+CREATE TABLE IF NOT EXISTS "roles" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "description" text,
+    "system" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+    "role_id" uuid DEFAULT gen_random_uuid(),
+    "permission_id" uuid DEFAULT gen_random_uuid(),
+    PRIMARY KEY ("role_id","permission_id"),
+    CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "organizations" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" text NOT NULL,
+    "slug" text NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_organizations_slug" ON "organizations" ("slug");
+
+CREATE TABLE IF NOT EXISTS "memberships" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "organization_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "role" text NOT NULL DEFAULT 'user',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_memberships_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_memberships_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_memberships_user_id" ON "memberships" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_memberships_organization_user" ON "memberships" ("organization_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "audit_events" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "sequence" bigserial NOT NULL,
+    "action" text NOT NULL,
+    "actor_id" text,
+    "target_type" text,
+    "target_id" text,
+    "organization_id" text,
+    "changes" jsonb,
+    "metadata" jsonb,
+    "ip_address" text,
+    "user_agent" text,
+    "request_id" text,
+    "created_at" timestamptz NOT NULL,
+    "prev_hash" text,
+    "hash" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_events_created_at" ON "audit_events" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_request_id" ON "audit_events" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_organization_id" ON "audit_events" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_target_id" ON "audit_events" ("target_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_actor_id" ON "audit_events" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_action" ON "audit_events" ("action");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_audit_events_sequence" ON "audit_events" ("sequence");
+
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "url" text NOT NULL,
+    "description" text,
+    "events" text NOT NULL,
+    "secret" text NOT NULL,
+    "active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_events" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "type" text NOT NULL,
+    "organization_id" text,
+    "payload" jsonb NOT NULL,
+    "created_at" timestamptz,
+    "dispatched_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_events_dispatched_at" ON "webhook_events" ("dispatched_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_events_type" ON "webhook_events" ("type");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "subscription_id" uuid NOT NULL,
+    "event_id" uuid NOT NULL,
+    "event_type" text NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_attempt_at" timestamptz,
+    "last_status_code" bigint,
+    "last_error" text,
+    "delivered_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_subscription" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_next_attempt_at" ON "webhook_deliveries" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_status" ON "webhook_deliveries" ("status");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_event_id" ON "webhook_deliveries" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_subscription_id" ON "webhook_deliveries" ("subscription_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_delivery_attempts" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "delivery_id" uuid NOT NULL,
+    "attempt" bigint NOT NULL,
+    "status_code" bigint,
+    "error" text,
+    "duration_ms" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_delivery_attempts_delivery" FOREIGN KEY ("delivery_id") REFERENCES "webhook_deliveries"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_attempts_delivery_id" ON "webhook_delivery_attempts" ("delivery_id");
@@ -0,0 +1,9 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "must_change_password" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "setup_tokens" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "token_hash" text NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_setup_tokens_token_hash" ON "setup_tokens" ("token_hash");
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "password_changed_at" timestamptz;
+
+-- The last update is the latest the password can have changed, so existing
+-- passwords never expire earlier than they should.
+UPDATE "users" SET "password_changed_at" = "updated_at" WHERE "password_changed_at" IS NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "email_changes" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "new_email" text NOT NULL,
+    "code_hash" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "used_at" timestamptz,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_email_changes_expires_at" ON "email_changes" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_email_changes_user_id" ON "email_changes" ("user_id");
@@ -0,0 +1,7 @@
+DELETE FROM "password_resets";
+
+DROP INDEX IF EXISTS "idx_password_resets_user_id";
+DROP INDEX IF EXISTS "idx_password_resets_token_hash";
+ALTER TABLE "password_resets" DROP COLUMN IF EXISTS "attempts";
+ALTER TABLE "password_resets" RENAME COLUMN "token_hash" TO "token";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_resets_token" ON "password_resets" ("token");
@@ -0,0 +1,9 @@
+-- Pending resets hold plaintext codes, so they are discarded; users request
+-- a new one.
+DELETE FROM "password_resets";
+
+DROP INDEX IF EXISTS "idx_password_resets_token";
+ALTER TABLE "password_resets" RENAME COLUMN "token" TO "token_hash";
+ALTER TABLE "password_resets" ADD COLUMN IF NOT EXISTS "attempts" bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_password_resets_token_hash" ON "password_resets" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_password_resets_user_id" ON "password_resets" ("user_id");
@@ -0,0 +1,17 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "phone" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "phone_verified_at" timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_phone" ON "users" ("phone");
+
+CREATE TABLE IF NOT EXISTS "phone_verifications" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "user_id" uuid NOT NULL,
+    "phone" text NOT NULL,
+    "code_hash" text NOT NULL,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "used_at" timestamptz,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_phone_verifications_expires_at" ON "phone_verifications" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_phone_verifications_user_id" ON "phone_verifications" ("user_id");
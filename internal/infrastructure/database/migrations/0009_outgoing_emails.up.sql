@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "outgoing_emails" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "template" text NOT NULL,
+    "recipient" text NOT NULL,
+    "subject" text NOT NULL,
+    "text_body" text NOT NULL,
+    "html_body" text NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_attempt_at" timestamptz,
+    "last_error" text,
+    "sent_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outgoing_emails_next_attempt_at" ON "outgoing_emails" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_outgoing_emails_status" ON "outgoing_emails" ("status");
@@ -0,0 +1,3 @@
+-- The session that replaces the revoked ones, after a password change for
+-- example, is kept by the id of its access token.
+ALTER TABLE "user_token_revocations" ADD COLUMN IF NOT EXISTS "exempt_token_id" text NOT NULL DEFAULT '';
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// seedRoles creates the permissions known to the API and the system roles.
+// Users already carry their role by name, so the system roles only need to
+// exist for existing accounts to keep working. The super_admin role is
+// granted every permission and the admin role every organization permission
+// on each start, picking up new ones; the permissions of other roles are left
+// as configured.
+//
+// Before organizations existed, admin was the platform administrator. When
+// the super_admin role is first created, existing admins are promoted to it so
+// they keep managing the platform.
+func seedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions := entities.DefaultPermissions()
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description"}),
+		}).Create(&permissions).Error; err != nil {
+			return fmt.Errorf("failed to seed permissions: %w", err)
+		}
+
+		var allPermissions []entities.Permission
+		if err := tx.Find(&allPermissions).Error; err != nil {
+			return err
+		}
+
+		var tenantPermissions []entities.Permission
+		for _, permission := range allPermissions {
+			if entities.IsTenantPermission(permission.Name) {
+				tenantPermissions = append(tenantPermissions, permission)
+			}
+		}
+
+		var superAdmins int64
+		if err := tx.Model(&entities.Role{}).Where("name = ?", entities.RoleSuperAdmin).Count(&superAdmins).Error; err != nil {
+			return err
+		}
+		if superAdmins == 0 {
+			if err := tx.Model(&entities.User{}).Where("role = ?", entities.RoleAdmin).Update("role", entities.RoleSuperAdmin).Error; err != nil {
+				return fmt.Errorf("failed to promote admins to super_admin: %w", err)
+			}
+		}
+
+		systemRoles := []entities.Role{
+			{Name: entities.RoleSuperAdmin, Description: "Administrador da plataforma, com todas as permissões em todas as organizações", System: true},
+			{Name: entities.RoleAdmin, Description: "Administrador de uma organização, gerencia apenas os seus membros", System: true},
+			{Name: entities.RoleUser, Description: "Usuário comum, gerencia apenas a própria conta", System: true},
+		}
+		for i := range systemRoles {
+			role := &systemRoles[i]
+			if err := tx.Where("name = ?", role.Name).Attrs(role).FirstOrCreate(role).Error; err != nil {
+				return fmt.Errorf("failed to seed role %s: %w", role.Name, err)
+			}
+			if err := tx.Model(role).Update("system", true).Error; err != nil {
+				return err
+			}
+
+			switch role.Name {
+			case entities.RoleSuperAdmin:
+				if err := tx.Model(role).Association("Permissions").Replace(allPermissions); err != nil {
+					return fmt.Errorf("failed to grant super_admin permissions: %w", err)
+				}
+			case entities.RoleAdmin:
+				if err := tx.Model(role).Association("Permissions").Replace(tenantPermissions); err != nil {
+					return fmt.Errorf("failed to grant admin permissions: %w", err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,152 @@
+// Package i18n translates the messages of the API. Each supported locale has
+// a catalog in locales/LOCALE.json mapping stable message codes to the text,
+// where placeholders like {min} are filled by the params of the message.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// fallbackLocale is used for unknown locales.
+const fallbackLocale = entities.LocalePortuguese
+
+// catalogs holds the messages of each supported locale by code. The catalogs
+// are built into the binary, so they are checked once at startup.
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[string]map[string]string {
+	catalogs := map[string]map[string]string{}
+	for _, locale := range entities.SupportedLocales() {
+		data, err := localeFiles.ReadFile("locales/" + locale + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", locale, err))
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", locale, err))
+		}
+		catalogs[locale] = messages
+	}
+
+	// Every catalog must have the same codes, so no message falls back to
+	// another language.
+	for locale, messages := range catalogs {
+		for code := range catalogs[fallbackLocale] {
+			if _, ok := messages[code]; !ok {
+				panic(fmt.Sprintf("i18n: catalog %s has no message %s", locale, code))
+			}
+		}
+		for code := range messages {
+			if _, ok := catalogs[fallbackLocale][code]; !ok {
+				panic(fmt.Sprintf("i18n: catalog %s has unknown message %s", locale, code))
+			}
+		}
+	}
+
+	return catalogs
+}
+
+// Lookup returns the message with the code in the locale, reporting whether
+// the code exists.
+func Lookup(locale, code string, params map[string]string) (string, bool) {
+	messages, ok := catalogs[locale]
+	if !ok {
+		messages = catalogs[fallbackLocale]
+	}
+
+	message, ok := messages[code]
+	if !ok {
+		return "", false
+	}
+
+	for name, value := range params {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+	return message, true
+}
+
+// Text returns the message with the code in the locale, or the code itself
+// when there is no such message.
+func Text(locale, code string, params map[string]string) string {
+	if message, ok := Lookup(locale, code, params); ok {
+		return message
+	}
+	return code
+}
+
+// codedError is implemented by errors with a stable code, like
+// entities.CodedError.
+type codedError interface {
+	error
+	ErrorCode() string
+	ErrorParams() map[string]string
+}
+
+// Error returns the code of err and its message in the locale. Errors without
+// a code, or with a code the catalogs do not know, keep their own message.
+func Error(locale string, err error) (code, message string) {
+	var coded codedError
+	if !errors.As(err, &coded) {
+		return "", err.Error()
+	}
+
+	if message, ok := Lookup(locale, coded.ErrorCode(), coded.ErrorParams()); ok {
+		return coded.ErrorCode(), message
+	}
+	return coded.ErrorCode(), err.Error()
+}
+
+// Negotiate picks the supported locale the client prefers from an
+// Accept-Language header, or fallback when it accepts none of them.
+func Negotiate(acceptLanguage, fallback string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, options, _ := strings.Cut(part, ";")
+		quality := 1.0
+		for _, option := range strings.Split(options, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(option), "=")
+			if !found || strings.TrimSpace(name) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+			quality = parsed
+		}
+
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" || quality <= 0 {
+			continue
+		}
+		preferences = append(preferences, preference{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, preference := range preferences {
+		if locale, ok := entities.NormalizeLocale(preference.tag); ok {
+			return locale
+		}
+	}
+	return fallback
+}
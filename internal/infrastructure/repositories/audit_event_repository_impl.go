@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+// auditChainLockID identifies the advisory lock serializing chained appends.
+const auditChainLockID = 0x61756469
+
+type AuditEventRepositoryImpl struct {
+	db        *gorm.DB
+	hashChain bool
+}
+
+// NewAuditEventRepository stores audit events in Postgres. With hashChain,
+// every event is linked to the previous one by its hash; appends are then
+// serialized so the chain never forks.
+func NewAuditEventRepository(db *gorm.DB, hashChain bool) repositories.AuditEventRepository {
+	return &AuditEventRepositoryImpl{db: db, hashChain: hashChain}
+}
+
+func (r *AuditEventRepositoryImpl) Append(ctx context.Context, event *entities.AuditEvent) error {
+	if !r.hashChain {
+		return r.db.WithContext(ctx).Create(event).Error
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockID).Error; err != nil {
+			return err
+		}
+
+		var last entities.AuditEvent
+		err := tx.Select("hash").Order("sequence DESC").Take(&last).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		event.PrevHash = last.Hash
+		event.Hash = event.ComputeHash()
+		return tx.Create(event).Error
+	})
+}
+
+// Find returns the events matching the filter, newest first. Within an
+// organization only its events are returned.
+func (r *AuditEventRepositoryImpl) Find(ctx context.Context, filter *entities.AuditEventFilter) ([]*entities.AuditEvent, error) {
+	query := r.db.WithContext(ctx).Model(&entities.AuditEvent{})
+
+	if organizationID := entities.OrganizationIDFromContext(ctx); organizationID != "" {
+		query = query.Where("organization_id = ?", organizationID)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("sequence < ?", filter.Cursor)
+	}
+
+	var events []*entities.AuditEvent
+	err := query.Order("sequence DESC").Limit(filter.Limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// FindAfter returns events in chain order, starting after the given sequence.
+func (r *AuditEventRepositoryImpl) FindAfter(ctx context.Context, sequence int64, limit int) ([]*entities.AuditEvent, error) {
+	var events []*entities.AuditEvent
+	err := r.db.WithContext(ctx).Where("sequence > ?", sequence).Order("sequence ASC").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
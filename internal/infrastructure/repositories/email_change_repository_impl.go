@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type EmailChangeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEmailChangeRepository(db *gorm.DB) repositories.EmailChangeRepository {
+	return &EmailChangeRepositoryImpl{db: db}
+}
+
+func (r *EmailChangeRepositoryImpl) Create(ctx context.Context, change *entities.EmailChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+func (r *EmailChangeRepositoryImpl) FindLatestByUserID(ctx context.Context, userID string) (*entities.EmailChange, error) {
+	var change entities.EmailChange
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&change).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+// ReserveAttempt counts an attempt at the code of the change before it is
+// checked, and reports false once the change is used or out of attempts, so
+// concurrent guesses cannot go past the limit.
+func (r *EmailChangeRepositoryImpl) ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.EmailChange{}).
+		Where("id = ? AND attempts < ? AND used_at IS NULL", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkAsUsed consumes the change only once, even if it is confirmed
+// concurrently.
+func (r *EmailChangeRepositoryImpl) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.EmailChange{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *EmailChangeRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entities.EmailChange{}).Error
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type EmailOutboxRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEmailOutboxRepository(db *gorm.DB) repositories.EmailOutboxRepository {
+	return &EmailOutboxRepositoryImpl{db: db}
+}
+
+func (r *EmailOutboxRepositoryImpl) Enqueue(ctx context.Context, email *entities.OutgoingEmail) error {
+	return r.db.WithContext(ctx).Create(email).Error
+}
+
+func (r *EmailOutboxRepositoryImpl) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.OutgoingEmail, error) {
+	var emails []*entities.OutgoingEmail
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", entities.OutgoingEmailPending, now).
+			Order("next_attempt_at ASC").Limit(limit).Find(&emails).Error
+		if err != nil || len(emails) == 0 {
+			return err
+		}
+
+		ids := make([]string, 0, len(emails))
+		for _, email := range emails {
+			ids = append(ids, email.ID.String())
+		}
+		return tx.Model(&entities.OutgoingEmail{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+func (r *EmailOutboxRepositoryImpl) Update(ctx context.Context, email *entities.OutgoingEmail) error {
+	return r.db.WithContext(ctx).Save(email).Error
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type EmailVerificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) repositories.EmailVerificationRepository {
+	return &EmailVerificationRepositoryImpl{db: db}
+}
+
+func (r *EmailVerificationRepositoryImpl) Create(ctx context.Context, verification *entities.EmailVerification) error {
+	return r.db.WithContext(ctx).Create(verification).Error
+}
+
+func (r *EmailVerificationRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.EmailVerification, error) {
+	var verification entities.EmailVerification
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *EmailVerificationRepositoryImpl) FindLatestByUserID(ctx context.Context, userID string) (*entities.EmailVerification, error) {
+	var verification entities.EmailVerification
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *EmailVerificationRepositoryImpl) CountCreatedSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entities.EmailVerification{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ReserveAttempt counts an attempt at the code of the verification before it is
+// checked, and reports false once the verification is used or out of attempts, so
+// concurrent guesses cannot go past the limit.
+func (r *EmailVerificationRepositoryImpl) ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.EmailVerification{}).
+		Where("id = ? AND attempts < ? AND used_at IS NULL", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkAsUsed consumes the verification only once, even if the link and the
+// code are submitted concurrently.
+func (r *EmailVerificationRepositoryImpl) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.EmailVerification{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *EmailVerificationRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entities.EmailVerification{}).Error
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type LoginThrottleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) repositories.LoginThrottleRepository {
+	return &LoginThrottleRepositoryImpl{db: db}
+}
+
+func (r *LoginThrottleRepositoryImpl) Find(ctx context.Context, key string) (*entities.LoginThrottle, error) {
+	var throttle entities.LoginThrottle
+	err := r.db.WithContext(ctx).Where("key = ? AND expires_at > ?", key, time.Now()).First(&throttle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RegisterFailure increments the counter in a single statement, so concurrent
+// attempts cannot overwrite each other's failures. An expired counter starts
+// over from one.
+func (r *LoginThrottleRepositoryImpl) RegisterFailure(ctx context.Context, key string, retention time.Duration) (*entities.LoginThrottle, error) {
+	now := time.Now()
+
+	var throttle entities.LoginThrottle
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO login_throttles (key, failures, last_failure_at, expires_at, created_at, updated_at)
+		VALUES (?, 1, ?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.expires_at <= EXCLUDED.last_failure_at THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`,
+		key, now, now.Add(retention), now, now,
+	).Scan(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *LoginThrottleRepositoryImpl) Reset(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&entities.LoginThrottle{}).Error
+}
+
+func (r *LoginThrottleRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entities.LoginThrottle{}).Error
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+// InMemoryLoginThrottleRepository keeps failed login counters in process
+// memory. It is meant for tests and single-instance deployments: counters are
+// lost on restart and are not shared between replicas.
+type InMemoryLoginThrottleRepository struct {
+	mu        sync.Mutex
+	throttles map[string]entities.LoginThrottle
+}
+
+func NewInMemoryLoginThrottleRepository() repositories.LoginThrottleRepository {
+	return &InMemoryLoginThrottleRepository{
+		throttles: make(map[string]entities.LoginThrottle),
+	}
+}
+
+func (r *InMemoryLoginThrottleRepository) Find(ctx context.Context, key string) (*entities.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	throttle, exists := r.throttles[key]
+	if !exists || !time.Now().Before(throttle.ExpiresAt) {
+		return nil, nil
+	}
+	return &throttle, nil
+}
+
+func (r *InMemoryLoginThrottleRepository) RegisterFailure(ctx context.Context, key string, retention time.Duration) (*entities.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	throttle, exists := r.throttles[key]
+	if !exists || !now.Before(throttle.ExpiresAt) {
+		throttle = entities.LoginThrottle{Key: key, CreatedAt: now}
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	throttle.ExpiresAt = now.Add(retention)
+	throttle.UpdatedAt = now
+	r.throttles[key] = throttle
+
+	return &throttle, nil
+}
+
+func (r *InMemoryLoginThrottleRepository) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.throttles, key)
+	return nil
+}
+
+func (r *InMemoryLoginThrottleRepository) DeleteExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, throttle := range r.throttles {
+		if throttle.ExpiresAt.Before(now) {
+			delete(r.throttles, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type MFARecoveryCodeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMFARecoveryCodeRepository(db *gorm.DB) repositories.MFARecoveryCodeRepository {
+	return &MFARecoveryCodeRepositoryImpl{db: db}
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) ReplaceForUser(ctx context.Context, userID string, codes []*entities.MFARecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) FindUnusedByHash(ctx context.Context, userID, codeHash string) (*entities.MFARecoveryCode, error) {
+	var code entities.MFARecoveryCode
+	err := r.db.WithContext(ctx).Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).First(&code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.MFARecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) CountUnused(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entities.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *MFARecoveryCodeRepositoryImpl) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.MFARecoveryCode{}).Error
+}
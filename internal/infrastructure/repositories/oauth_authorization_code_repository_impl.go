@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type OAuthAuthorizationCodeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOAuthAuthorizationCodeRepository(db *gorm.DB) repositories.OAuthAuthorizationCodeRepository {
+	return &OAuthAuthorizationCodeRepositoryImpl{db: db}
+}
+
+func (r *OAuthAuthorizationCodeRepositoryImpl) Create(ctx context.Context, code *entities.OAuthAuthorizationCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+func (r *OAuthAuthorizationCodeRepositoryImpl) FindByCodeHash(ctx context.Context, codeHash string) (*entities.OAuthAuthorizationCode, error) {
+	var code entities.OAuthAuthorizationCode
+	err := r.db.WithContext(ctx).Where("code_hash = ?", codeHash).First(&code).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+// MarkAsUsed consumes the code and records the token family issued for it, so
+// that a replayed code can revoke those tokens.
+func (r *OAuthAuthorizationCodeRepositoryImpl) MarkAsUsed(ctx context.Context, id string, tokenFamilyID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.OAuthAuthorizationCode{}).
+		Where("id = ? AND used = false", id).
+		Updates(map[string]interface{}{"used": true, "token_family_id": tokenFamilyID})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *OAuthAuthorizationCodeRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entities.OAuthAuthorizationCode{}).Error
+}
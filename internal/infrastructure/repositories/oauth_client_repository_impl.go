@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type OAuthClientRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOAuthClientRepository(db *gorm.DB) repositories.OAuthClientRepository {
+	return &OAuthClientRepositoryImpl{db: db}
+}
+
+func (r *OAuthClientRepositoryImpl) Create(ctx context.Context, client *entities.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *OAuthClientRepositoryImpl) FindByClientID(ctx context.Context, clientID string) (*entities.OAuthClient, error) {
+	var client entities.OAuthClient
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *OAuthClientRepositoryImpl) FindAll(ctx context.Context) ([]*entities.OAuthClient, error) {
+	var clients []*entities.OAuthClient
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&clients).Error
+	if err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (r *OAuthClientRepositoryImpl) Delete(ctx context.Context, clientID string) error {
+	return r.db.WithContext(ctx).Where("client_id = ?", clientID).Delete(&entities.OAuthClient{}).Error
+}
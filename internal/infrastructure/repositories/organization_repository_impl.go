@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type OrganizationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) repositories.OrganizationRepository {
+	return &OrganizationRepositoryImpl{db: db}
+}
+
+func (r *OrganizationRepositoryImpl) Create(ctx context.Context, organization *entities.Organization) error {
+	return r.db.WithContext(ctx).Create(organization).Error
+}
+
+func (r *OrganizationRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.Organization, error) {
+	var organization entities.Organization
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&organization).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *OrganizationRepositoryImpl) FindBySlug(ctx context.Context, slug string) (*entities.Organization, error) {
+	var organization entities.Organization
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&organization).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *OrganizationRepositoryImpl) FindAll(ctx context.Context) ([]*entities.Organization, error) {
+	var organizations []*entities.Organization
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&organizations).Error
+	if err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+func (r *OrganizationRepositoryImpl) Update(ctx context.Context, organization *entities.Organization) error {
+	return r.db.WithContext(ctx).Save(organization).Error
+}
+
+// Delete removes the organization together with its memberships. The users
+// themselves are kept, since they may belong to other organizations.
+func (r *OrganizationRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&entities.Membership{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&entities.Organization{}).Error
+	})
+}
+
+func (r *OrganizationRepositoryImpl) AddMember(ctx context.Context, membership *entities.Membership) error {
+	return r.db.WithContext(ctx).Create(membership).Error
+}
+
+func (r *OrganizationRepositoryImpl) FindMembership(ctx context.Context, organizationID, userID string) (*entities.Membership, error) {
+	var membership entities.Membership
+	err := r.db.WithContext(ctx).Preload("Organization").
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *OrganizationRepositoryImpl) FindMembershipsByUserID(ctx context.Context, userID string) ([]*entities.Membership, error) {
+	var memberships []*entities.Membership
+	err := r.db.WithContext(ctx).Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *OrganizationRepositoryImpl) FindMembers(ctx context.Context, organizationID string) ([]*entities.Membership, error) {
+	var memberships []*entities.Membership
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("organization_id = ?", organizationID).
+		Order("created_at ASC").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *OrganizationRepositoryImpl) UpdateMembership(ctx context.Context, membership *entities.Membership) error {
+	return r.db.WithContext(ctx).Omit("Organization", "User").Save(membership).Error
+}
+
+func (r *OrganizationRepositoryImpl) RemoveMember(ctx context.Context, organizationID, userID string) error {
+	return r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Delete(&entities.Membership{}).Error
+}
+
+// CountMembersByRole counts the memberships granting a role, across every
+// organization.
+func (r *OrganizationRepositoryImpl) CountMembersByRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entities.Membership{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+// FindMemberIDsByRole returns the ids of the users granted a role by a
+// membership, across every organization.
+func (r *OrganizationRepositoryImpl) FindMemberIDsByRole(ctx context.Context, role string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&entities.Membership{}).Where("role = ?", role).Distinct().Pluck("user_id", &ids).Error
+	return ids, err
+}
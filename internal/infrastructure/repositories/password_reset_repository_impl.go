@@ -22,9 +22,9 @@ func (r *PasswordResetRepositoryImpl) Create(ctx context.Context, passwordReset
 	return r.db.WithContext(ctx).Create(passwordReset).Error
 }
 
-func (r *PasswordResetRepositoryImpl) FindByToken(ctx context.Context, token string) (*entities.PasswordReset, error) {
+func (r *PasswordResetRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.PasswordReset, error) {
 	var passwordReset entities.PasswordReset
-	err := r.db.WithContext(ctx).Where("token = ?", token).First(&passwordReset).Error
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&passwordReset).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -34,9 +34,9 @@ func (r *PasswordResetRepositoryImpl) FindByToken(ctx context.Context, token str
 	return &passwordReset, nil
 }
 
-func (r *PasswordResetRepositoryImpl) FindByUserID(ctx context.Context, userID string) (*entities.PasswordReset, error) {
+func (r *PasswordResetRepositoryImpl) FindLatestByUserID(ctx context.Context, userID string) (*entities.PasswordReset, error) {
 	var passwordReset entities.PasswordReset
-	err := r.db.WithContext(ctx).Where("user_id = ? AND used = false AND expires_at > ?", userID, time.Now()).First(&passwordReset).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&passwordReset).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -46,8 +46,31 @@ func (r *PasswordResetRepositoryImpl) FindByUserID(ctx context.Context, userID s
 	return &passwordReset, nil
 }
 
-func (r *PasswordResetRepositoryImpl) Update(ctx context.Context, passwordReset *entities.PasswordReset) error {
-	return r.db.WithContext(ctx).Save(passwordReset).Error
+// ReserveAttempt counts an attempt at the code of the reset before it is
+// checked, and reports false once the reset is used or out of attempts, so
+// concurrent guesses cannot go past the limit.
+func (r *PasswordResetRepositoryImpl) ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.PasswordReset{}).
+		Where("id = ? AND attempts < ? AND used = false", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkAsUsed consumes the reset only once, even if it is submitted
+// concurrently.
+func (r *PasswordResetRepositoryImpl) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.PasswordReset{}).
+		Where("id = ? AND used = false", id).
+		Update("used", true)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
 
 func (r *PasswordResetRepositoryImpl) DeleteExpired(ctx context.Context) error {
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type PhoneVerificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPhoneVerificationRepository(db *gorm.DB) repositories.PhoneVerificationRepository {
+	return &PhoneVerificationRepositoryImpl{db: db}
+}
+
+func (r *PhoneVerificationRepositoryImpl) Create(ctx context.Context, verification *entities.PhoneVerification) error {
+	return r.db.WithContext(ctx).Create(verification).Error
+}
+
+func (r *PhoneVerificationRepositoryImpl) FindLatestByUserID(ctx context.Context, userID string) (*entities.PhoneVerification, error) {
+	var verification entities.PhoneVerification
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// ReserveAttempt counts an attempt at the code of the verification before it is
+// checked, and reports false once the verification is used or out of attempts, so
+// concurrent guesses cannot go past the limit.
+func (r *PhoneVerificationRepositoryImpl) ReserveAttempt(ctx context.Context, id string, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.PhoneVerification{}).
+		Where("id = ? AND attempts < ? AND used_at IS NULL", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkAsUsed consumes the verification only once, even if it is confirmed
+// concurrently.
+func (r *PhoneVerificationRepositoryImpl) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.PhoneVerification{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *PhoneVerificationRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entities.PhoneVerification{}).Error
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"api-auth-go/internal/domain/repositories"
+)
+
+const rateLimitPruneInterval = time.Minute
+
+type rateLimitCounter struct {
+	window   int64
+	current  int64
+	previous int64
+	expires  time.Time
+}
+
+// InMemoryRateLimitStore keeps request counters in process memory. Limits are
+// enforced per instance, so with several replicas each one allows the full
+// rate; use the Redis store to share counters between them.
+type InMemoryRateLimitStore struct {
+	mu        sync.Mutex
+	counters  map[string]*rateLimitCounter
+	lastPrune time.Time
+}
+
+func NewInMemoryRateLimitStore() repositories.RateLimitStore {
+	return &InMemoryRateLimitStore{
+		counters: make(map[string]*rateLimitCounter),
+	}
+}
+
+func (s *InMemoryRateLimitStore) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	index := now.UnixNano() / int64(window)
+	counter, exists := s.counters[key]
+	switch {
+	case !exists:
+		counter = &rateLimitCounter{window: index}
+		s.counters[key] = counter
+	case counter.window == index-1:
+		counter.window, counter.previous, counter.current = index, counter.current, 0
+	case counter.window < index-1:
+		counter.window, counter.previous, counter.current = index, 0, 0
+	}
+
+	counter.current++
+	counter.expires = time.Unix(0, (index+2)*int64(window))
+
+	return counter.current, counter.previous, nil
+}
+
+// prune drops counters that no longer affect any window, at most once per
+// rateLimitPruneInterval.
+func (s *InMemoryRateLimitStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < rateLimitPruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, counter := range s.counters {
+		if !now.Before(counter.expires) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRateLimitStoreIncrement(t *testing.T) {
+	const window = time.Minute
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type hit struct {
+		offset       time.Duration
+		wantCurrent  int64
+		wantPrevious int64
+	}
+
+	tests := []struct {
+		name string
+		hits []hit
+	}{
+		{
+			name: "hits in the same window add up",
+			hits: []hit{
+				{0, 1, 0},
+				{10 * time.Second, 2, 0},
+				{59 * time.Second, 3, 0},
+			},
+		},
+		{
+			name: "the next window starts over and remembers the previous one",
+			hits: []hit{
+				{0, 1, 0},
+				{30 * time.Second, 2, 0},
+				{window, 1, 2},
+				{window + 59*time.Second, 2, 2},
+			},
+		},
+		{
+			name: "a skipped window forgets the older hits",
+			hits: []hit{
+				{0, 1, 0},
+				{2 * window, 1, 0},
+			},
+		},
+		{
+			name: "an empty previous window counts as zero",
+			hits: []hit{
+				{0, 1, 0},
+				{window, 1, 1},
+				{3 * window, 1, 0},
+				{4 * window, 1, 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewInMemoryRateLimitStore()
+			for i, hit := range tt.hits {
+				current, previous, err := store.Increment(context.Background(), "key", window, start.Add(hit.offset))
+				if err != nil {
+					t.Fatalf("hit %d: unexpected error: %v", i, err)
+				}
+				if current != hit.wantCurrent || previous != hit.wantPrevious {
+					t.Errorf("hit %d: got (%d, %d), want (%d, %d)", i, current, previous, hit.wantCurrent, hit.wantPrevious)
+				}
+			}
+		})
+	}
+}
+
+func TestInMemoryRateLimitStoreKeysAreIndependent(t *testing.T) {
+	store := NewInMemoryRateLimitStore()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store.Increment(context.Background(), "a", time.Minute, now)
+	store.Increment(context.Background(), "a", time.Minute, now)
+	current, _, _ := store.Increment(context.Background(), "b", time.Minute, now)
+
+	if current != 1 {
+		t.Errorf("got %d hits for b, want 1", current)
+	}
+}
+
+func TestInMemoryRateLimitStorePrunesExpiredCounters(t *testing.T) {
+	store := NewInMemoryRateLimitStore().(*InMemoryRateLimitStore)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store.Increment(context.Background(), "old", time.Second, now)
+	store.Increment(context.Background(), "new", time.Hour, now.Add(2*rateLimitPruneInterval))
+
+	if _, ok := store.counters["old"]; ok {
+		t.Error("expired counter was not pruned")
+	}
+	if _, ok := store.counters["new"]; !ok {
+		t.Error("live counter was pruned")
+	}
+}
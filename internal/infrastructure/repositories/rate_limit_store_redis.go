@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"api-auth-go/internal/domain/repositories"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+// RedisRateLimitStore keeps request counters in a Redis-compatible server so
+// that every replica enforces the same limits. Each window is a counter key
+// that expires once it can no longer be the previous window.
+type RedisRateLimitStore struct {
+	client *services.RedisClient
+	prefix string
+}
+
+func NewRedisRateLimitStore(client *services.RedisClient) repositories.RateLimitStore {
+	return &RedisRateLimitStore{client: client, prefix: "ratelimit:"}
+}
+
+func (s *RedisRateLimitStore) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int64, int64, error) {
+	index := now.UnixNano() / int64(window)
+	currentKey := fmt.Sprintf("%s%s:%d", s.prefix, key, index)
+	previousKey := fmt.Sprintf("%s%s:%d", s.prefix, key, index-1)
+	ttl := strconv.FormatInt((2 * window).Milliseconds(), 10)
+
+	replies, err := s.client.Pipeline(ctx, [][]string{
+		{"INCR", currentKey},
+		{"PEXPIRE", currentKey, ttl},
+		{"GET", previousKey},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	current, ok := replies[0].(int64)
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected INCR reply %v", replies[0])
+	}
+
+	var previous int64
+	if value, ok := replies[2].(string); ok {
+		previous, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid rate limit counter %q", value)
+		}
+	}
+
+	return current, previous, nil
+}
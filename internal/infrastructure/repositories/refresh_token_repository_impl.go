@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type RefreshTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) repositories.RefreshTokenRepository {
+	return &RefreshTokenRepositoryImpl{db: db}
+}
+
+func (r *RefreshTokenRepositoryImpl) Create(ctx context.Context, refreshToken *entities.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(refreshToken).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	var refreshToken entities.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&refreshToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &refreshToken, nil
+}
+
+// MarkAsUsed flags the token as consumed only if nobody else did it first, so
+// two concurrent refreshes with the same token cannot both succeed.
+func (r *RefreshTokenRepositoryImpl) MarkAsUsed(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("id = ? AND used = false AND revoked = false", id).
+		Updates(map[string]interface{}{"used": true, "used_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("family_id = ? AND revoked = false", familyID).
+		Update("revoked", true).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND revoked = false", userID).
+		Update("revoked", true).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) RevokeByUserIDAndClientID(ctx context.Context, userID, clientID string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND client_id = ? AND revoked = false", userID, clientID).
+		Update("revoked", true).Error
+}
+
+func (r *RefreshTokenRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entities.RefreshToken{}).Error
+}
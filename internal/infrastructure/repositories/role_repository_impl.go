@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type RoleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) repositories.RoleRepository {
+	return &RoleRepositoryImpl{db: db}
+}
+
+func (r *RoleRepositoryImpl) Create(ctx context.Context, role *entities.Role) error {
+	return r.db.WithContext(ctx).Create(role).Error
+}
+
+func (r *RoleRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.Role, error) {
+	var role entities.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("id = ?", id).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepositoryImpl) FindByName(ctx context.Context, name string) (*entities.Role, error) {
+	var role entities.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepositoryImpl) FindAll(ctx context.Context) ([]*entities.Role, error) {
+	var roles []*entities.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name ASC").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Update saves the role and replaces its permissions with role.Permissions.
+func (r *RoleRepositoryImpl) Update(ctx context.Context, role *entities.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+		return tx.Model(role).Association("Permissions").Replace(role.Permissions)
+	})
+}
+
+func (r *RoleRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role := entities.Role{}
+		if err := tx.Where("id = ?", id).First(&role).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+}
+
+func (r *RoleRepositoryImpl) FindAllPermissions(ctx context.Context) ([]*entities.Permission, error) {
+	var permissions []*entities.Permission
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (r *RoleRepositoryImpl) FindPermissionsByNames(ctx context.Context, names []string) ([]entities.Permission, error) {
+	var permissions []entities.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Order("name ASC").Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
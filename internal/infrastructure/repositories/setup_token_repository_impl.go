@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type SetupTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSetupTokenRepository(db *gorm.DB) repositories.SetupTokenRepository {
+	return &SetupTokenRepositoryImpl{db: db}
+}
+
+// Issue locks the table, so instances starting together cannot both issue a
+// token.
+func (r *SetupTokenRepositoryImpl) Issue(ctx context.Context, token *entities.SetupToken, replace bool) (bool, error) {
+	issued := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE setup_tokens IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		if !replace {
+			var count int64
+			if err := tx.Model(&entities.SetupToken{}).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+		}
+
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&entities.SetupToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(token).Error; err != nil {
+			return err
+		}
+
+		issued = true
+		return nil
+	})
+
+	return issued, err
+}
+
+// Consume deletes the token, so that of concurrent requests only one can
+// redeem it.
+func (r *SetupTokenRepositoryImpl) Consume(ctx context.Context, tokenHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&entities.SetupToken{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *SetupTokenRepositoryImpl) DeleteAll(ctx context.Context) error {
+	return r.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&entities.SetupToken{}).Error
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type TokenRevocationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTokenRevocationRepository(db *gorm.DB) repositories.TokenRevocationRepository {
+	return &TokenRevocationRepositoryImpl{db: db}
+}
+
+func (r *TokenRevocationRepositoryImpl) RevokeToken(ctx context.Context, revokedToken *entities.RevokedToken) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(revokedToken).Error
+}
+
+func (r *TokenRevocationRepositoryImpl) RevokeAllForUser(ctx context.Context, revocation *entities.UserTokenRevocation) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "exempt_token_id", "expires_at", "updated_at"}),
+		}).
+		Create(revocation).Error
+}
+
+func (r *TokenRevocationRepositoryImpl) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entities.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	err = r.db.WithContext(ctx).
+		Model(&entities.UserTokenRevocation{}).
+		Where("user_id = ? AND revoked_before >= ? AND exempt_token_id <> ? AND expires_at > ?", userID, issuedAt, jti, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *TokenRevocationRepositoryImpl) DeleteExpired(ctx context.Context) error {
+	now := time.Now()
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entities.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&entities.UserTokenRevocation{}).Error
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+// InMemoryTokenRevocationRepository keeps revocations in process memory. It is
+// meant for tests and single-instance deployments: entries are lost on restart
+// and are not shared between replicas.
+type InMemoryTokenRevocationRepository struct {
+	mu          sync.RWMutex
+	tokens      map[string]entities.RevokedToken
+	revocations map[string]entities.UserTokenRevocation
+}
+
+func NewInMemoryTokenRevocationRepository() repositories.TokenRevocationRepository {
+	return &InMemoryTokenRevocationRepository{
+		tokens:      make(map[string]entities.RevokedToken),
+		revocations: make(map[string]entities.UserTokenRevocation),
+	}
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeToken(ctx context.Context, revokedToken *entities.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[revokedToken.JTI]; !exists {
+		revokedToken.CreatedAt = time.Now()
+		r.tokens[revokedToken.JTI] = *revokedToken
+	}
+	return nil
+}
+
+func (r *InMemoryTokenRevocationRepository) RevokeAllForUser(ctx context.Context, revocation *entities.UserTokenRevocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if existing, exists := r.revocations[revocation.UserID.String()]; exists {
+		revocation.CreatedAt = existing.CreatedAt
+	} else {
+		revocation.CreatedAt = now
+	}
+	revocation.UpdatedAt = now
+	r.revocations[revocation.UserID.String()] = *revocation
+	return nil
+}
+
+func (r *InMemoryTokenRevocationRepository) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.tokens[jti]; exists {
+		return true, nil
+	}
+
+	if revocation, exists := r.revocations[userID]; exists && revocation.Covers(jti, issuedAt) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *InMemoryTokenRevocationRepository) DeleteExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for jti, token := range r.tokens {
+		if token.ExpiresAt.Before(now) {
+			delete(r.tokens, jti)
+		}
+	}
+	for userID, revocation := range r.revocations {
+		if revocation.ExpiresAt.Before(now) {
+			delete(r.revocations, userID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+func TestInMemoryTokenRevocationRepositoryIsRevoked(t *testing.T) {
+	repo := NewInMemoryTokenRevocationRepository()
+	ctx := context.Background()
+	userID := uuid.New()
+
+	revokedToken, _ := entities.NewRevokedToken("logged-out", userID, time.Now().Add(time.Hour))
+	repo.RevokeToken(ctx, revokedToken)
+	revocation := entities.NewUserTokenRevocation(userID, "replacement", time.Hour)
+	repo.RevokeAllForUser(ctx, revocation)
+	sameSecond := revocation.RevokedBefore.Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		jti      string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "logged out token", jti: "logged-out", userID: uuid.NewString(), issuedAt: time.Now().Add(time.Hour), want: true},
+		{name: "token of the same second", jti: "old", userID: userID.String(), issuedAt: sameSecond, want: true},
+		{name: "replacing session", jti: "replacement", userID: userID.String(), issuedAt: sameSecond},
+		{name: "later token", jti: "new", userID: userID.String(), issuedAt: sameSecond.Add(time.Second)},
+		{name: "other user", jti: "other", userID: uuid.NewString(), issuedAt: sameSecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := repo.IsRevoked(ctx, tt.jti, tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if revoked != tt.want {
+				t.Errorf("got revoked %v, want %v", revoked, tt.want)
+			}
+		})
+	}
+}
+
+func TestInMemoryTokenRevocationRepositoryDeleteExpired(t *testing.T) {
+	repo := NewInMemoryTokenRevocationRepository().(*InMemoryTokenRevocationRepository)
+	ctx := context.Background()
+
+	expired, _ := entities.NewRevokedToken("expired", uuid.New(), time.Now().Add(-time.Second))
+	alive, _ := entities.NewRevokedToken("alive", uuid.New(), time.Now().Add(time.Hour))
+	repo.RevokeToken(ctx, expired)
+	repo.RevokeToken(ctx, alive)
+
+	expiredRevocation := entities.NewUserTokenRevocation(uuid.New(), "", -time.Second)
+	repo.RevokeAllForUser(ctx, expiredRevocation)
+	repo.RevokeAllForUser(ctx, entities.NewUserTokenRevocation(uuid.New(), "", time.Hour))
+
+	if err := repo.DeleteExpired(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.tokens) != 1 || len(repo.revocations) != 1 {
+		t.Errorf("got %d tokens and %d revocations left, want 1 of each", len(repo.tokens), len(repo.revocations))
+	}
+	if _, ok := repo.tokens["alive"]; !ok {
+		t.Error("a token still alive was deleted")
+	}
+}
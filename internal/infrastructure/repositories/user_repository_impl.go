@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"api-auth-go/internal/domain/entities"
 	"api-auth-go/internal/domain/repositories"
@@ -22,8 +24,56 @@ func NewUserRepository(db *gorm.DB) repositories.UserRepository {
 	}
 }
 
+// members starts a query on users restricted to the members of the
+// organization the context is scoped to, if any. Every query by id goes
+// through it, so an organization can never reach the users of another one.
+func (r *UserRepositoryImpl) members(ctx context.Context) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&entities.User{})
+
+	organizationID := entities.OrganizationIDFromContext(ctx)
+	if organizationID == "" {
+		return query
+	}
+
+	return query.Joins("JOIN memberships ON memberships.user_id = users.id AND memberships.organization_id = ?", organizationID)
+}
+
+// scoped is members for queries loading users, also loading their role in
+// the organization.
+func (r *UserRepositoryImpl) scoped(ctx context.Context) *gorm.DB {
+	query := r.members(ctx)
+	if entities.OrganizationIDFromContext(ctx) != "" {
+		query = query.Select("users.*, memberships.role AS organization_role")
+	}
+	return query
+}
+
+// Create adds the user, also making it a member of the organization the
+// context is scoped to. The user.created webhook event is written in the same
+// transaction, with any other event recorded on the user.
 func (r *UserRepositoryImpl) Create(ctx context.Context, user *entities.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	organizationID := entities.OrganizationIDFromContext(ctx)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		if organizationID != "" {
+			membership, err := entities.NewMembership(uuid.MustParse(organizationID), user.ID, entities.RoleUser)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(membership).Error; err != nil {
+				return err
+			}
+
+			user.OrganizationRole = membership.Role
+		}
+
+		events := append([]string{entities.WebhookEventUserCreated}, user.TakeWebhookEvents()...)
+		return writeUserWebhookEvents(tx, user, organizationID, events...)
+	})
 }
 
 func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
@@ -40,7 +90,7 @@ func (r *UserRepositoryImpl) FindByEmail(ctx context.Context, email string) (*en
 
 func (r *UserRepositoryImpl) FindByID(ctx context.Context, id string) (*entities.User, error) {
 	var user entities.User
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	err := r.scoped(ctx).Where("users.id = ?", id).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -56,13 +106,55 @@ func (r *UserRepositoryImpl) ExistsByEmail(ctx context.Context, email string) (b
 	return count > 0, err
 }
 
+func (r *UserRepositoryImpl) FindByPhone(ctx context.Context, phone string) (*entities.User, error) {
+	var user entities.User
+	err := r.db.WithContext(ctx).Where("phone = ?", phone).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepositoryImpl) ExistsByPhone(ctx context.Context, phone string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entities.User{}).Where("phone = ?", phone).Count(&count).Error
+	return count > 0, err
+}
+
+// Update saves the user, writing the webhook events recorded on it in the
+// same transaction. Within an organization the user must be one of its
+// members, otherwise gorm.ErrRecordNotFound is returned.
 func (r *UserRepositoryImpl) Update(ctx context.Context, user *entities.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	organizationID := entities.OrganizationIDFromContext(ctx)
+	if organizationID != "" {
+		var count int64
+		if err := r.members(ctx).Where("users.id = ?", user.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+
+	events := user.TakeWebhookEvents()
+	if len(events) == 0 {
+		return r.db.WithContext(ctx).Save(user).Error
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return writeUserWebhookEvents(tx, user, organizationID, events...)
+	})
 }
 
 func (r *UserRepositoryImpl) FindAll(ctx context.Context) ([]*entities.User, error) {
 	var users []*entities.User
-	err := r.db.WithContext(ctx).Find(&users).Error
+	err := r.scoped(ctx).Find(&users).Error
 	if err != nil {
 		return nil, err
 	}
@@ -70,18 +162,23 @@ func (r *UserRepositoryImpl) FindAll(ctx context.Context) ([]*entities.User, err
 }
 
 func (r *UserRepositoryImpl) FindAllWithFilters(ctx context.Context, filters *entities.UserFilters) ([]*entities.User, error) {
-	query := r.db.WithContext(ctx).Model(&entities.User{})
+	query := r.scoped(ctx)
 	
 	if filters.Name != "" {
-		query = query.Where("LOWER(name) LIKE LOWER(?)", "%"+filters.Name+"%")
+		query = query.Where("LOWER(users.name) LIKE LOWER(?)", "%"+filters.Name+"%")
 	}
 	
 	if filters.Email != "" {
-		query = query.Where("LOWER(email) LIKE LOWER(?)", "%"+filters.Email+"%")
+		query = query.Where("LOWER(users.email) LIKE LOWER(?)", "%"+filters.Email+"%")
 	}
 	
 	if filters.Role != "" {
-		query = query.Where("role = ?", filters.Role)
+		// Within an organization users are filtered by their role in it.
+		if entities.OrganizationIDFromContext(ctx) != "" {
+			query = query.Where("memberships.role = ?", filters.Role)
+		} else {
+			query = query.Where("users.role = ?", filters.Role)
+		}
 	}
 	
 	sortField := filters.SortBy
@@ -94,7 +191,7 @@ func (r *UserRepositoryImpl) FindAllWithFilters(ctx context.Context, filters *en
 		sortOrder = "desc"
 	}
 	
-	query = query.Order(fmt.Sprintf("%s %s", sortField, strings.ToUpper(sortOrder)))
+	query = query.Order(fmt.Sprintf("users.%s %s", sortField, strings.ToUpper(sortOrder)))
 	
 	offset := (filters.Page - 1) * filters.Limit
 	query = query.Offset(offset).Limit(filters.Limit)
@@ -108,6 +205,53 @@ func (r *UserRepositoryImpl) FindAllWithFilters(ctx context.Context, filters *en
 	return users, nil
 }
 
+// Delete removes the user and writes the user.deleted webhook event in the
+// same transaction. Within an organization only its members can be deleted.
 func (r *UserRepositoryImpl) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.User{}).Error
+	organizationID := entities.OrganizationIDFromContext(ctx)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Returning{}).Where("id = ?", id)
+		if organizationID != "" {
+			query = query.Where("EXISTS (SELECT 1 FROM memberships WHERE memberships.user_id = users.id AND memberships.organization_id = ?)", organizationID)
+		}
+
+		var deleted []entities.User
+		if err := query.Delete(&deleted).Error; err != nil {
+			return err
+		}
+
+		for i := range deleted {
+			if err := writeUserWebhookEvents(tx, &deleted[i], organizationID, entities.WebhookEventUserDeleted); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CountByRole counts the users with a global role or, within an organization,
+// the members with that role in it.
+func (r *UserRepositoryImpl) CountByRole(ctx context.Context, role string) (int64, error) {
+	column := "users.role"
+	if entities.OrganizationIDFromContext(ctx) != "" {
+		column = "memberships.role"
+	}
+
+	var count int64
+	err := r.members(ctx).Where(column+" = ?", role).Count(&count).Error
+	return count, err
+}
+
+// FindIDsByRole returns the ids of the users with a global role or, within an
+// organization, of the members with that role in it.
+func (r *UserRepositoryImpl) FindIDsByRole(ctx context.Context, role string) ([]string, error) {
+	column := "users.role"
+	if entities.OrganizationIDFromContext(ctx) != "" {
+		column = "memberships.role"
+	}
+
+	var ids []string
+	err := r.members(ctx).Where(column+" = ?", role).Pluck("users.id", &ids).Error
+	return ids, err
 }
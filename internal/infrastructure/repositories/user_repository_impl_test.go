@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// dryRunConn stands in for the database of a gorm DryRun session, which
+// builds statements without running them. Only transactions reach it.
+type dryRunConn struct {
+	gorm.ConnPool
+}
+
+func (c *dryRunConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return c, nil
+}
+
+func (c *dryRunConn) Commit() error   { return nil }
+func (c *dryRunConn) Rollback() error { return nil }
+
+// statementRecorder is a gorm logger keeping the SQL of every statement.
+type statementRecorder struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r *statementRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Error(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	statement, _ := fc()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, statement)
+}
+
+func (r *statementRecorder) sql() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.statements, "\n")
+}
+
+// newDryRunUserRepository returns a user repository whose statements are
+// recorded instead of run.
+func newDryRunUserRepository(t *testing.T) (*UserRepositoryImpl, *statementRecorder) {
+	t.Helper()
+
+	recorder := &statementRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &dryRunConn{}}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               recorder,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run database: %v", err)
+	}
+	return &UserRepositoryImpl{db: db}, recorder
+}
+
+func TestUserRepositoryScopesQueriesToTheOrganization(t *testing.T) {
+	organizationID := uuid.NewString()
+	membershipScope := "memberships.organization_id = '" + organizationID + "'"
+
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, repo *UserRepositoryImpl) error
+		// Statements of a global request must not touch memberships; within
+		// an organization they must contain the scoped text.
+		scoped string
+	}{
+		{
+			name: "FindByID",
+			run: func(ctx context.Context, repo *UserRepositoryImpl) error {
+				_, err := repo.FindByID(ctx, uuid.NewString())
+				return err
+			},
+			scoped: membershipScope,
+		},
+		{
+			name: "FindAll",
+			run: func(ctx context.Context, repo *UserRepositoryImpl) error {
+				_, err := repo.FindAll(ctx)
+				return err
+			},
+			scoped: membershipScope,
+		},
+		{
+			name: "FindAllWithFilters by role",
+			run: func(ctx context.Context, repo *UserRepositoryImpl) error {
+				_, err := repo.FindAllWithFilters(ctx, &entities.UserFilters{Role: "support", Page: 1, Limit: 10})
+				return err
+			},
+			scoped: "memberships.role = 'support'",
+		},
+		{
+			name: "CountByRole",
+			run: func(ctx context.Context, repo *UserRepositoryImpl) error {
+				_, err := repo.CountByRole(ctx, entities.RoleAdmin)
+				return err
+			},
+			scoped: "memberships.role = 'admin'",
+		},
+		{
+			name: "FindIDsByRole",
+			run: func(ctx context.Context, repo *UserRepositoryImpl) error {
+				_, err := repo.FindIDsByRole(ctx, entities.RoleAdmin)
+				return err
+			},
+			scoped: membershipScope,
+		},
+		{
+			name: "Delete",
+			run: func(ctx context.Context, repo *UserRepositoryImpl) error {
+				return repo.Delete(ctx, uuid.NewString())
+			},
+			scoped: "memberships.organization_id = '" + organizationID + "')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, recorder := newDryRunUserRepository(t)
+			if err := tt.run(context.Background(), repo); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if global := recorder.sql(); strings.Contains(global, "memberships") {
+				t.Errorf("got global statement %q, want it across every organization", global)
+			}
+
+			repo, recorder = newDryRunUserRepository(t)
+			ctx := entities.WithOrganizationID(context.Background(), organizationID)
+			if err := tt.run(ctx, repo); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if scoped := recorder.sql(); !strings.Contains(scoped, tt.scoped) || !strings.Contains(scoped, organizationID) {
+				t.Errorf("got statement %q, want it scoped with %s", scoped, tt.scoped)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryUpdateRefusesUsersOfOtherOrganizations(t *testing.T) {
+	repo, recorder := newDryRunUserRepository(t)
+	ctx := entities.WithOrganizationID(context.Background(), uuid.NewString())
+	user := &entities.User{ID: uuid.New(), Name: "Other", Email: "other@example.com"}
+
+	// Nothing is found in a dry run, as for a user who is not a member.
+	if err := repo.Update(ctx, user); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("got error %v, want gorm.ErrRecordNotFound", err)
+	}
+	if statements := recorder.sql(); strings.Contains(statements, "UPDATE") {
+		t.Errorf("got %q, want the user left as it is", statements)
+	}
+}
+
+func TestUserRepositoryCreateAddsTheUserToTheOrganization(t *testing.T) {
+	organizationID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		wantMembership bool
+	}{
+		{name: "global", ctx: context.Background()},
+		{name: "within an organization", ctx: entities.WithOrganizationID(context.Background(), organizationID), wantMembership: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, recorder := newDryRunUserRepository(t)
+			user := &entities.User{ID: uuid.New(), Name: "New", Email: "new@example.com", Role: entities.RoleUser}
+
+			if err := repo.Create(tt.ctx, user); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			statements := recorder.sql()
+			if got := strings.Contains(statements, `INSERT INTO "memberships"`); got != tt.wantMembership {
+				t.Errorf("got membership created %v, want %v in %q", got, tt.wantMembership, statements)
+			}
+			if tt.wantMembership && !strings.Contains(statements, organizationID) {
+				t.Errorf("got %q, want the membership of the organization", statements)
+			}
+		})
+	}
+}
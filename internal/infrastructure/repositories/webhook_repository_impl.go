@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+)
+
+type WebhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) repositories.WebhookRepository {
+	return &WebhookRepositoryImpl{db: db}
+}
+
+func (r *WebhookRepositoryImpl) CreateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r *WebhookRepositoryImpl) FindSubscriptionByID(ctx context.Context, id string) (*entities.WebhookSubscription, error) {
+	var subscription entities.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&subscription).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookRepositoryImpl) FindAllSubscriptions(ctx context.Context) ([]*entities.WebhookSubscription, error) {
+	var subscriptions []*entities.WebhookSubscription
+	err := r.db.WithContext(ctx).Order("created_at ASC").Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookRepositoryImpl) UpdateSubscription(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Save(subscription).Error
+}
+
+// DeleteSubscription removes the subscription with its deliveries and their
+// attempts.
+func (r *WebhookRepositoryImpl) DeleteSubscription(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.WebhookSubscription{}).Error
+}
+
+func (r *WebhookRepositoryImpl) Enqueue(ctx context.Context, event *entities.WebhookEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *WebhookRepositoryImpl) FanOut(ctx context.Context, limit int) (int, error) {
+	var processed int
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []*entities.WebhookEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL").Order("created_at ASC").Limit(limit).Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		var subscriptions []*entities.WebhookSubscription
+		if err := tx.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var deliveries []*entities.WebhookDelivery
+		eventIDs := make([]string, 0, len(events))
+		for _, event := range events {
+			eventIDs = append(eventIDs, event.ID.String())
+			for _, subscription := range subscriptions {
+				if subscription.Subscribes(event.Type) {
+					deliveries = append(deliveries, entities.NewWebhookDelivery(subscription, event, now))
+				}
+			}
+		}
+
+		if len(deliveries) > 0 {
+			if err := tx.Omit("Subscription").Create(&deliveries).Error; err != nil {
+				return err
+			}
+		}
+
+		processed = len(events)
+		return tx.Model(&entities.WebhookEvent{}).Where("id IN ?", eventIDs).Update("dispatched_at", now).Error
+	})
+
+	return processed, err
+}
+
+func (r *WebhookRepositoryImpl) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entities.WebhookDelivery, error) {
+	var deliveries []*entities.WebhookDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Joins("Subscription").
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "webhook_deliveries"}, Options: "SKIP LOCKED"}).
+			Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", entities.WebhookDeliveryPending, now).
+			Where(`"Subscription".active = ?`, true).
+			Order("webhook_deliveries.next_attempt_at ASC").Limit(limit).Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]string, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID.String())
+		}
+		return tx.Model(&entities.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepositoryImpl) SaveAttempt(ctx context.Context, delivery *entities.WebhookDelivery, attempt *entities.WebhookDeliveryAttempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Subscription").Save(delivery).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Delivery").Create(attempt).Error
+	})
+}
+
+func (r *WebhookRepositoryImpl) UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Omit("Subscription").Save(delivery).Error
+}
+
+// FindDeliveries lists the latest deliveries of a subscription, optionally
+// only those with the given status.
+func (r *WebhookRepositoryImpl) FindDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*entities.WebhookDelivery, error) {
+	query := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []*entities.WebhookDelivery
+	err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepositoryImpl) FindDelivery(ctx context.Context, subscriptionID, deliveryID string) (*entities.WebhookDelivery, error) {
+	var delivery entities.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepositoryImpl) FindAttempts(ctx context.Context, deliveryID string) ([]*entities.WebhookDeliveryAttempt, error) {
+	var attempts []*entities.WebhookDeliveryAttempt
+	err := r.db.WithContext(ctx).Where("delivery_id = ?", deliveryID).Order("created_at ASC").Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// writeUserWebhookEvents adds events about a user to the outbox. It is called
+// by the UserRepository within the transaction saving the user.
+func writeUserWebhookEvents(tx *gorm.DB, user *entities.User, organizationID string, eventTypes ...string) error {
+	for _, eventType := range eventTypes {
+		event, err := entities.NewUserWebhookEvent(eventType, user, organizationID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
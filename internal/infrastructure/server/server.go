@@ -1,44 +1,461 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
 	"api-auth-go/internal/domain/usecases"
 	"api-auth-go/internal/infrastructure/config"
 	infraRepos "api-auth-go/internal/infrastructure/repositories"
+	"api-auth-go/internal/infrastructure/services"
 	"api-auth-go/internal/presentation/handlers"
+	"api-auth-go/internal/presentation/middleware"
 	"api-auth-go/internal/presentation/routes"
 )
 
+// shutdownTimeout bounds how long requests in flight may take to finish once
+// the server is asked to stop.
+const shutdownTimeout = 15 * time.Second
+
 type Server struct {
-	config *config.Config
-	db     *gorm.DB
-	router *gin.Engine
+	config         *config.Config
+	db             *gorm.DB
+	router         *gin.Engine
+	userUseCase    *usecases.UserUseCase
+	webhookUseCase *usecases.WebhookUseCase
+	emailUseCase   *usecases.EmailUseCase
+	cleanupUseCase *usecases.CleanupUseCase
 }
 
-func NewServer(cfg *config.Config, db *gorm.DB) *Server {
-	userRepo := infraRepos.NewUserRepository(db)
-	passwordResetRepo := infraRepos.NewPasswordResetRepositoryImpl(db)
+func NewServer(cfg *config.Config, db *gorm.DB, useCases *UseCases) (*Server, error) {
+	userHandler := handlers.NewUserHandler(useCases.User)
+	keyHandler := handlers.NewKeyHandler(useCases.Key)
+	oauthHandler := handlers.NewOAuthHandler(useCases.OAuth)
+	roleHandler := handlers.NewRoleHandler(useCases.Role)
+	organizationHandler := handlers.NewOrganizationHandler(useCases.Organization)
+	auditHandler := handlers.NewAuditHandler(useCases.Audit)
+	webhookHandler := handlers.NewWebhookHandler(useCases.Webhook)
+	setupHandler := handlers.NewSetupHandler(useCases.Setup)
 
-	userUseCase := usecases.NewUserUseCase(userRepo, passwordResetRepo)
+	rateLimiter, rateLimits, err := newRateLimits(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	userHandler := handlers.NewUserHandler(userUseCase)
+	defaultLocale, err := entities.ValidateLocale(cfg.DefaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEFAULT_LOCALE: %w", err)
+	}
 
-	router := routes.SetupRoutes(userHandler)
+	router := routes.SetupRoutes(userHandler, keyHandler, oauthHandler, roleHandler, organizationHandler, auditHandler, webhookHandler, setupHandler, useCases.jwtService, useCases.tokenRevocationRepo, useCases.organizationRepo, cfg.TenantBaseDomain, defaultLocale, rateLimiter, rateLimits)
+	if err := router.SetTrustedProxies(newTrustedProxies(cfg)); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
 
 	return &Server{
-		config: cfg,
-		db:     db,
-		router: router,
+		config:         cfg,
+		db:             db,
+		router:         router,
+		userUseCase:    useCases.User,
+		webhookUseCase: useCases.Webhook,
+		emailUseCase:   useCases.Email,
+		cleanupUseCase: useCases.Cleanup,
+	}, nil
+}
+
+func newJWTService(cfg *config.Config) (*services.JWTService, error) {
+	if cfg.JWTKeysDir == "" {
+		return services.NewJWTService(), nil
+	}
+
+	keyManager, err := services.NewKeyManager(cfg.JWTKeysDir, cfg.JWTSigningAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	log.Printf("Signing tokens with keys from %s", cfg.JWTKeysDir)
+	return services.NewJWTServiceWithKeyManager(keyManager), nil
+}
+
+// NewInitialAdmin reads the first admin from the configuration, with its
+// password given directly or in a file.
+func NewInitialAdmin(cfg *config.Config) (usecases.InitialAdmin, error) {
+	bootstrap := cfg.Bootstrap
+	admin := usecases.InitialAdmin{
+		Name:     bootstrap.AdminName,
+		Email:    strings.TrimSpace(bootstrap.AdminEmail),
+		Password: bootstrap.AdminPassword,
+	}
+
+	if bootstrap.AdminPasswordFile != "" {
+		if admin.Password != "" {
+			return usecases.InitialAdmin{}, fmt.Errorf("set only one of BOOTSTRAP_ADMIN_PASSWORD and BOOTSTRAP_ADMIN_PASSWORD_FILE")
+		}
+		content, err := os.ReadFile(bootstrap.AdminPasswordFile)
+		if err != nil {
+			return usecases.InitialAdmin{}, fmt.Errorf("failed to read initial admin password: %w", err)
+		}
+		admin.Password = strings.TrimRight(string(content), "\r\n")
+	}
+
+	if admin.Email != "" && admin.Password == "" {
+		return usecases.InitialAdmin{}, fmt.Errorf("BOOTSTRAP_ADMIN_EMAIL requires BOOTSTRAP_ADMIN_PASSWORD or BOOTSTRAP_ADMIN_PASSWORD_FILE")
+	}
+
+	return admin, nil
+}
+
+// NewPasswordHasher builds the hasher from the configuration.
+func NewPasswordHasher(cfg *config.Config) (*services.PasswordHasher, error) {
+	hashing := cfg.PasswordHashing
+	if hashing.Argon2Memory < 0 || hashing.Argon2Time < 0 || hashing.Argon2Parallelism < 0 || hashing.Argon2Parallelism > 255 {
+		return nil, fmt.Errorf("invalid argon2id parameters")
+	}
+
+	hasher, err := services.NewPasswordHasher(services.PasswordHasherConfig{
+		Algorithm:         hashing.Algorithm,
+		BcryptCost:        hashing.BcryptCost,
+		Argon2Memory:      uint32(hashing.Argon2Memory),
+		Argon2Time:        uint32(hashing.Argon2Time),
+		Argon2Parallelism: uint8(hashing.Argon2Parallelism),
+		PepperFile:        hashing.PepperFile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure password hashing: %w", err)
+	}
+
+	return hasher, nil
+}
+
+func NewPasswordPolicy(cfg *config.Config) (*entities.PasswordPolicy, error) {
+	rules := cfg.PasswordPolicy
+	if rules.MinLength < 1 || rules.MaxLength < rules.MinLength {
+		return nil, fmt.Errorf("invalid password length limits: %d-%d", rules.MinLength, rules.MaxLength)
+	}
+	if rules.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid password max age: %s", rules.MaxAge)
+	}
+
+	policy := &entities.PasswordPolicy{
+		MinLength:          rules.MinLength,
+		MaxLength:          rules.MaxLength,
+		RequireUppercase:   rules.RequireUppercase,
+		RequireLowercase:   rules.RequireLowercase,
+		RequireDigit:       rules.RequireDigit,
+		RequireSymbol:      rules.RequireSymbol,
+		MaxRepeatedChars:   rules.MaxRepeatedChars,
+		RejectPersonalInfo: rules.RejectPersonalInfo,
+		MinStrengthScore:   rules.MinStrengthScore,
+		MaxAge:             rules.MaxAge,
+	}
+
+	if rules.BreachedPasswordsFile != "" {
+		checker, err := services.NewBreachedPasswordChecker(rules.BreachedPasswordsFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.BreachChecker = checker
+	}
+
+	return policy, nil
+}
+
+func newTokenRevocationRepository(cfg *config.Config, db *gorm.DB) repositories.TokenRevocationRepository {
+	if cfg.TokenRevocationStore == "memory" {
+		log.Println("Using in-memory token revocation store")
+		return infraRepos.NewInMemoryTokenRevocationRepository()
+	}
+	return infraRepos.NewTokenRevocationRepository(db)
+}
+
+func newLoginThrottleRepository(cfg *config.Config, db *gorm.DB) repositories.LoginThrottleRepository {
+	if cfg.LoginThrottle.Store == "memory" {
+		log.Println("Using in-memory login throttle store")
+		return infraRepos.NewInMemoryLoginThrottleRepository()
+	}
+	return infraRepos.NewLoginThrottleRepository(db)
+}
+
+// newLoginThrottlingConfig builds the throttling policies. Source IPs get the
+// same backoff as accounts but are never locked out, since many users can
+// share an address.
+func newLoginThrottlingConfig(cfg *config.Config) usecases.LoginThrottlingConfig {
+	throttle := cfg.LoginThrottle
+	return usecases.LoginThrottlingConfig{
+		Account: entities.LoginThrottlePolicy{
+			FreeAttempts:    throttle.FreeAttempts,
+			BaseDelay:       throttle.BackoffBase,
+			MaxDelay:        throttle.BackoffMax,
+			MaxFailures:     throttle.MaxFailures,
+			LockoutDuration: throttle.LockoutDuration,
+			FailureWindow:   throttle.FailureWindow,
+		},
+		IP: entities.LoginThrottlePolicy{
+			FreeAttempts:  throttle.IPFreeAttempts,
+			BaseDelay:     throttle.BackoffBase,
+			MaxDelay:      throttle.IPBackoffMax,
+			FailureWindow: throttle.FailureWindow,
+		},
+	}
+}
+
+func newPasswordResetConfig(cfg *config.Config) (usecases.PasswordResetConfig, error) {
+	reset := cfg.PasswordReset
+	if err := entities.ValidatePasswordResetMode(reset.Mode); err != nil {
+		return usecases.PasswordResetConfig{}, err
+	}
+	if reset.Mode == entities.PasswordResetModeLink && reset.URL == "" {
+		return usecases.PasswordResetConfig{}, fmt.Errorf("PASSWORD_RESET_URL is required when PASSWORD_RESET_MODE is %s", entities.PasswordResetModeLink)
 	}
+
+	return usecases.PasswordResetConfig{
+		Mode: reset.Mode,
+		URL:  reset.URL,
+	}, nil
 }
 
+func newSMSService(cfg *config.Config) (services.SMSService, error) {
+	sms := cfg.SMS
+	switch sms.Provider {
+	case services.SMSProviderLog:
+		return services.NewLogSMSService(), nil
+	case services.SMSProviderHTTP:
+		if sms.BaseURL == "" || sms.APIKey == "" {
+			return nil, fmt.Errorf("SMS_BASE_URL and SMS_API_KEY are required when SMS_PROVIDER is %s", services.SMSProviderHTTP)
+		}
+		if sms.Timeout <= 0 {
+			return nil, fmt.Errorf("invalid SMS timeout")
+		}
+		return services.NewHTTPSMSService(sms.BaseURL, sms.APIKey, sms.From, sms.Timeout), nil
+	default:
+		return nil, fmt.Errorf("invalid SMS provider %q: use %s or %s", sms.Provider, services.SMSProviderLog, services.SMSProviderHTTP)
+	}
+}
+
+func newEmailSender(cfg *config.Config) (services.EmailSender, error) {
+	email := cfg.Email
+	switch email.Sender {
+	case services.EmailSenderSMTP:
+		return services.NewSMTPEmailSender(services.SMTPEmailSenderConfig{
+			Host:     email.SMTPHost,
+			Port:     email.SMTPPort,
+			Username: email.SMTPUsername,
+			Password: email.SMTPPassword,
+			From:     email.From,
+			TLS:      email.SMTPTLS,
+		})
+	case services.EmailSenderMaildir:
+		log.Printf("Writing emails to the maildir at %s", email.MaildirDir)
+		return services.NewMaildirEmailSender(email.MaildirDir, email.From)
+	case services.EmailSenderMemory:
+		log.Println("Keeping emails in memory, they are not delivered")
+		return services.NewMemoryEmailSender(), nil
+	default:
+		return nil, fmt.Errorf("invalid email sender %q: use %s, %s or %s", email.Sender, services.EmailSenderSMTP, services.EmailSenderMaildir, services.EmailSenderMemory)
+	}
+}
+
+func newEmailDispatchConfig(cfg *config.Config) (usecases.EmailDispatchConfig, error) {
+	email := cfg.Email
+	if email.DispatchInterval <= 0 || email.BatchSize < 1 || email.Timeout <= 0 {
+		return usecases.EmailDispatchConfig{}, fmt.Errorf("invalid email dispatch settings")
+	}
+	if email.MaxAttempts < 1 || email.BackoffBase <= 0 || email.BackoffMax < email.BackoffBase {
+		return usecases.EmailDispatchConfig{}, fmt.Errorf("invalid email retry settings")
+	}
+
+	return usecases.EmailDispatchConfig{
+		Interval:  email.DispatchInterval,
+		BatchSize: email.BatchSize,
+		Timeout:   email.Timeout,
+		Retry: entities.EmailRetryPolicy{
+			MaxAttempts: email.MaxAttempts,
+			BaseDelay:   email.BackoffBase,
+			MaxDelay:    email.BackoffMax,
+		},
+	}, nil
+}
+
+func newWebhookDispatchConfig(cfg *config.Config) (usecases.WebhookDispatchConfig, error) {
+	webhooks := cfg.Webhooks
+	if webhooks.DispatchInterval <= 0 || webhooks.BatchSize < 1 || webhooks.Timeout <= 0 {
+		return usecases.WebhookDispatchConfig{}, fmt.Errorf("invalid webhook dispatch settings")
+	}
+	if webhooks.MaxAttempts < 1 || webhooks.BackoffBase <= 0 || webhooks.BackoffMax < webhooks.BackoffBase {
+		return usecases.WebhookDispatchConfig{}, fmt.Errorf("invalid webhook retry settings")
+	}
+
+	return usecases.WebhookDispatchConfig{
+		Interval:  webhooks.DispatchInterval,
+		BatchSize: webhooks.BatchSize,
+		Timeout:   webhooks.Timeout,
+		Retry: entities.WebhookRetryPolicy{
+			MaxAttempts: webhooks.MaxAttempts,
+			BaseDelay:   webhooks.BackoffBase,
+			MaxDelay:    webhooks.BackoffMax,
+		},
+	}, nil
+}
+
+// newTrustedProxies reads the IPs or CIDRs of the proxies in front of the API.
+// Only requests coming from them may set the client IP through
+// X-Forwarded-For, which the rate limits, the login throttling and the audit
+// log rely on. By default no proxy is trusted and the client IP is the
+// address of the connection.
+func newTrustedProxies(cfg *config.Config) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(cfg.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// newRateLimits builds the rate limiter and the rate of each route group. A nil
+// limiter disables rate limiting.
+func newRateLimits(cfg *config.Config) (*middleware.RateLimiter, routes.RateLimits, error) {
+	if !cfg.RateLimit.Enabled {
+		return nil, routes.RateLimits{}, nil
+	}
+
+	var limits routes.RateLimits
+	for _, group := range []struct {
+		name  string
+		value string
+		rate  *middleware.RateLimit
+	}{
+		{"RATE_LIMIT_LOGIN", cfg.RateLimit.Login, &limits.Login},
+		{"RATE_LIMIT_PASSWORD_RESET", cfg.RateLimit.PasswordReset, &limits.PasswordReset},
+		{"RATE_LIMIT_AUTH", cfg.RateLimit.Auth, &limits.Auth},
+		{"RATE_LIMIT_OAUTH", cfg.RateLimit.OAuth, &limits.OAuth},
+		{"RATE_LIMIT_API", cfg.RateLimit.API, &limits.API},
+	} {
+		rate, err := parseRateLimit(group.value)
+		if err != nil {
+			return nil, routes.RateLimits{}, fmt.Errorf("invalid %s: %w", group.name, err)
+		}
+		*group.rate = rate
+	}
+
+	var store repositories.RateLimitStore
+	switch cfg.RateLimit.Store {
+	case "memory":
+		store = infraRepos.NewInMemoryRateLimitStore()
+	case "redis":
+		log.Printf("Using Redis rate limit store at %s", cfg.Redis.Addr)
+		store = infraRepos.NewRedisRateLimitStore(services.NewRedisClient(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB))
+	default:
+		return nil, routes.RateLimits{}, fmt.Errorf("unsupported rate limit store: %s", cfg.RateLimit.Store)
+	}
+
+	return middleware.NewRateLimiter(store), limits, nil
+}
+
+// parseRateLimit reads a rate written as "limit/window", like "10/1m".
+func parseRateLimit(value string) (middleware.RateLimit, error) {
+	limitPart, windowPart, found := strings.Cut(value, "/")
+	if !found {
+		return middleware.RateLimit{}, fmt.Errorf("expected limit/window, got %q", value)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(limitPart))
+	if err != nil || limit < 0 {
+		return middleware.RateLimit{}, fmt.Errorf("invalid limit %q", limitPart)
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(windowPart))
+	if err != nil || window <= 0 {
+		return middleware.RateLimit{}, fmt.Errorf("invalid window %q", windowPart)
+	}
+
+	return middleware.RateLimit{Limit: limit, Window: window}, nil
+}
+
+// Run serves the API and runs the background workers until SIGINT or SIGTERM.
+// It then stops accepting requests, waits for the ones in flight and for the
+// text messages they started, and lets the workers finish what they are
+// sending before returning.
 func (s *Server) Run() error {
-	addr := fmt.Sprintf(":%s", s.config.Port)
-	log.Printf("Server starting on port %s", s.config.Port)
-	return s.router.Run(addr)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	workersCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+
+	var workers sync.WaitGroup
+	if s.config.Webhooks.DispatcherEnabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.webhookUseCase.RunDispatcher(workersCtx)
+		}()
+	} else {
+		log.Println("Webhook dispatcher disabled on this instance")
+	}
+	if s.config.Email.WorkerEnabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.emailUseCase.RunWorker(workersCtx)
+		}()
+	} else {
+		log.Println("Email worker disabled on this instance")
+	}
+	if s.config.Cleanup.WorkerEnabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.cleanupUseCase.RunWorker(workersCtx)
+		}()
+	} else {
+		log.Println("Cleanup worker disabled on this instance")
+	}
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%s", s.config.Port),
+		Handler: s.router,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %s", s.config.Port)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		log.Println("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		err = httpServer.Shutdown(shutdownCtx)
+		cancel()
+	}
+
+	// Requests are no longer served, so no new text messages are sent.
+	s.userUseCase.WaitPendingSMS()
+
+	cancelWorkers()
+	workers.Wait()
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
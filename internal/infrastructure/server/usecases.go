@@ -0,0 +1,136 @@
+package server
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/config"
+	infraRepos "api-auth-go/internal/infrastructure/repositories"
+	"api-auth-go/internal/infrastructure/services"
+)
+
+// UseCases holds the use cases wired to their repositories and services. The
+// operator commands share it with the API, so both apply the same rules.
+type UseCases struct {
+	User         *usecases.UserUseCase
+	Key          *usecases.KeyUseCase
+	Role         *usecases.RoleUseCase
+	Organization *usecases.OrganizationUseCase
+	Audit        *usecases.AuditUseCase
+	Webhook      *usecases.WebhookUseCase
+	Email        *usecases.EmailUseCase
+	OAuth        *usecases.OAuthUseCase
+	Setup        *usecases.SetupUseCase
+	Cleanup      *usecases.CleanupUseCase
+
+	jwtService          *services.JWTService
+	tokenRevocationRepo repositories.TokenRevocationRepository
+	organizationRepo    repositories.OrganizationRepository
+}
+
+func NewUseCases(cfg *config.Config, db *gorm.DB) (*UseCases, error) {
+	jwtService, err := newJWTService(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHasher, err := NewPasswordHasher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordPolicy, err := NewPasswordPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepo := infraRepos.NewUserRepository(db)
+	passwordResetRepo := infraRepos.NewPasswordResetRepositoryImpl(db)
+	refreshTokenRepo := infraRepos.NewRefreshTokenRepository(db)
+	tokenRevocationRepo := newTokenRevocationRepository(cfg, db)
+	recoveryCodeRepo := infraRepos.NewMFARecoveryCodeRepository(db)
+	oauthClientRepo := infraRepos.NewOAuthClientRepository(db)
+	oauthCodeRepo := infraRepos.NewOAuthAuthorizationCodeRepository(db)
+	emailVerificationRepo := infraRepos.NewEmailVerificationRepository(db)
+	emailChangeRepo := infraRepos.NewEmailChangeRepository(db)
+	phoneVerificationRepo := infraRepos.NewPhoneVerificationRepository(db)
+	loginThrottleRepo := newLoginThrottleRepository(cfg, db)
+	roleRepo := infraRepos.NewRoleRepository(db)
+	organizationRepo := infraRepos.NewOrganizationRepository(db)
+	auditRepo := infraRepos.NewAuditEventRepository(db, cfg.AuditHashChain)
+	webhookRepo := infraRepos.NewWebhookRepository(db)
+	setupTokenRepo := infraRepos.NewSetupTokenRepository(db)
+	emailOutboxRepo := infraRepos.NewEmailOutboxRepository(db)
+
+	passwordReset, err := newPasswordResetConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	smsService, err := newSMSService(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	emailSender, err := newEmailSender(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure email sender: %w", err)
+	}
+
+	emailTemplates, err := services.LoadEmailTemplates(cfg.Email.TemplatesDir, cfg.Email.DefaultLocale, entities.EmailTemplateNames())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load email templates: %w", err)
+	}
+
+	emailDispatch, err := newEmailDispatchConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	emailUseCase := usecases.NewEmailUseCase(emailOutboxRepo, emailSender, emailTemplates, emailDispatch)
+
+	webhookDispatch, err := newWebhookDispatchConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Cleanup.Interval <= 0 {
+		return nil, fmt.Errorf("invalid cleanup interval: %s", cfg.Cleanup.Interval)
+	}
+
+	userUseCase := usecases.NewUserUseCase(userRepo, passwordResetRepo, refreshTokenRepo, tokenRevocationRepo, recoveryCodeRepo, emailVerificationRepo, emailChangeRepo, phoneVerificationRepo, jwtService, smsService, emailUseCase, passwordHasher, passwordPolicy, usecases.RegistrationConfig{
+		Enabled:                  cfg.Registration.Enabled,
+		RequireEmailVerification: cfg.Registration.RequireEmailVerification,
+		VerificationURL:          cfg.Registration.VerificationURL,
+	}, passwordReset, loginThrottleRepo, newLoginThrottlingConfig(cfg), roleRepo, organizationRepo, auditRepo, webhookRepo)
+
+	return &UseCases{
+		User:         userUseCase,
+		Key:          usecases.NewKeyUseCase(jwtService),
+		Role:         usecases.NewRoleUseCase(roleRepo, userRepo, organizationRepo, userUseCase),
+		Organization: usecases.NewOrganizationUseCase(organizationRepo, userRepo, roleRepo, auditRepo),
+		Audit:        usecases.NewAuditUseCase(auditRepo),
+		Webhook:      usecases.NewWebhookUseCase(webhookRepo, services.NewWebhookClient(webhookDispatch.Timeout), webhookDispatch),
+		Email:        emailUseCase,
+		OAuth:        usecases.NewOAuthUseCase(oauthClientRepo, oauthCodeRepo, userRepo, refreshTokenRepo, userUseCase, jwtService, cfg.IssuerURL),
+		Setup:        usecases.NewSetupUseCase(userRepo, setupTokenRepo, passwordHasher, passwordPolicy, auditRepo),
+		Cleanup: usecases.NewCleanupUseCase(cfg.Cleanup.Interval,
+			usecases.CleanupTarget{Name: "refresh tokens", Store: refreshTokenRepo},
+			usecases.CleanupTarget{Name: "token revocations", Store: tokenRevocationRepo},
+			usecases.CleanupTarget{Name: "password resets", Store: passwordResetRepo},
+			usecases.CleanupTarget{Name: "email verifications", Store: emailVerificationRepo},
+			usecases.CleanupTarget{Name: "email changes", Store: emailChangeRepo},
+			usecases.CleanupTarget{Name: "phone verifications", Store: phoneVerificationRepo},
+			usecases.CleanupTarget{Name: "oauth authorization codes", Store: oauthCodeRepo},
+			usecases.CleanupTarget{Name: "login throttles", Store: loginThrottleRepo},
+		),
+
+		jwtService:          jwtService,
+		tokenRevocationRepo: tokenRevocationRepo,
+		organizationRepo:    organizationRepo,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedPasswordChecker looks passwords up in a local copy of the Have I
+// Been Pwned password list: a text file with one "SHA1:COUNT" line per
+// password, sorted by hash, as produced by the official downloader. Like the
+// k-anonymity range API, only the 5 character hash prefix is used to locate
+// the candidate lines, which are then compared by suffix. The file is binary
+// searched on every lookup, so it is never loaded in memory.
+type BreachedPasswordChecker struct {
+	path string
+}
+
+func NewBreachedPasswordChecker(path string) (*BreachedPasswordChecker, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, errors.New("breached passwords file must be a file")
+	}
+	return &BreachedPasswordChecker{path: path}, nil
+}
+
+func (c *BreachedPasswordChecker) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(c.path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	// Find the smallest offset whose next line is not before the prefix.
+	low, high := int64(0), info.Size()
+	for low < high {
+		mid := low + (high-low)/2
+		_, line, err := lineAtOrAfter(file, mid)
+		if err != nil {
+			return false, err
+		}
+		if line == "" || hashPrefix(line) >= prefix {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+
+	start, _, err := lineAtOrAfter(file, low)
+	if err != nil {
+		return false, err
+	}
+
+	scanner := bufio.NewScanner(io.NewSectionReader(file, start, info.Size()-start))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if hashPrefix(line) != prefix {
+			break
+		}
+		lineHash, _, _ := strings.Cut(line, ":")
+		if strings.EqualFold(lineHash[5:], suffix) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// lineAtOrAfter returns the start offset and content of the first line that
+// begins at or after offset. An empty line means the end of the file.
+func lineAtOrAfter(file *os.File, offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		reader := bufio.NewReader(io.NewSectionReader(file, offset-1, 1<<62))
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return offset, "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, start, 1<<62))
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, strings.TrimSpace(line), nil
+}
+
+func hashPrefix(line string) string {
+	if len(line) < 5 {
+		return strings.ToUpper(line)
+	}
+	return strings.ToUpper(line[:5])
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email senders selectable by configuration.
+const (
+	EmailSenderSMTP    = "smtp"
+	EmailSenderMaildir = "maildir"
+	EmailSenderMemory  = "memory"
+)
+
+// SMTP connection security: STARTTLS upgrades a plain connection, usually on
+// port 587; implicit TLS connects over TLS, usually on port 465; none sends in
+// the clear and is only meant for local development servers.
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
+)
+
+// EmailMessage is a rendered email, sent as multipart/alternative with a
+// plain text and an HTML part.
+type EmailMessage struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// EmailSender delivers rendered emails.
+type EmailSender interface {
+	Send(ctx context.Context, message *EmailMessage) error
+}
+
+// buildEmail returns the message in RFC 5322 format, ready to be sent or
+// stored.
+func buildEmail(from *mail.Address, message *EmailMessage) ([]byte, error) {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", message.TextBody},
+		{"text/html; charset=UTF-8", message.HTMLBody},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var email bytes.Buffer
+	fmt.Fprintf(&email, "From: %s\r\n", from.String())
+	fmt.Fprintf(&email, "To: %s\r\n", to.String())
+	fmt.Fprintf(&email, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(&email, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&email, "Message-ID: <%s@%s>\r\n", uuid.New().String(), domain)
+	fmt.Fprintf(&email, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&email, "Content-Type: multipart/alternative; boundary=%q\r\n", parts.Boundary())
+	fmt.Fprintf(&email, "\r\n")
+	email.Write(body.Bytes())
+
+	return email.Bytes(), nil
+}
+
+func parseSender(from string) (*mail.Address, error) {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+	return address, nil
+}
+
+// SMTPEmailSenderConfig describes the SMTP server. Username defaults to the
+// sender address, and no authentication is done without a password.
+type SMTPEmailSenderConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	TLS      string
+}
+
+// SMTPEmailSender sends emails through an SMTP server, one connection per
+// email.
+type SMTPEmailSender struct {
+	config SMTPEmailSenderConfig
+	from   *mail.Address
+}
+
+func NewSMTPEmailSender(config SMTPEmailSenderConfig) (*SMTPEmailSender, error) {
+	if config.Host == "" || config.Port == "" {
+		return nil, errors.New("SMTP host and port are required")
+	}
+	switch config.TLS {
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("invalid SMTP TLS mode %q: use %s, %s or %s", config.TLS, SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone)
+	}
+
+	from, err := parseSender(config.From)
+	if err != nil {
+		return nil, err
+	}
+	if config.Username == "" {
+		config.Username = from.Address
+	}
+
+	return &SMTPEmailSender{config: config, from: from}, nil
+}
+
+func (s *SMTPEmailSender) Send(ctx context.Context, message *EmailMessage) error {
+	data, err := buildEmail(s.from, message)
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.config.TLS == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if s.config.Password != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (s *SMTPEmailSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.config.Host, s.config.Port)
+	if s.config.TLS == SMTPTLSImplicit {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.config.Host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// MaildirEmailSender stores emails in a Maildir instead of sending them, for
+// development. Any mail client that reads Maildirs can open it.
+type MaildirEmailSender struct {
+	dir  string
+	from *mail.Address
+}
+
+func NewMaildirEmailSender(dir, from string) (*MaildirEmailSender, error) {
+	address, err := parseSender(from)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+
+	return &MaildirEmailSender{dir: dir, from: address}, nil
+}
+
+// Send writes the email to tmp and then moves it to new, so readers never
+// see a partial file.
+func (s *MaildirEmailSender) Send(_ context.Context, message *EmailMessage) error {
+	data, err := buildEmail(s.from, message)
+	if err != nil {
+		return err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	name := fmt.Sprintf("%d.%s.%s", time.Now().UnixNano(), uuid.New().String(), strings.ReplaceAll(hostname, "/", "_"))
+
+	tmp := filepath.Join(s.dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "new", name))
+}
+
+// MemoryEmailSender keeps the emails in memory, for tests and local runs.
+type MemoryEmailSender struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+func NewMemoryEmailSender() *MemoryEmailSender {
+	return &MemoryEmailSender{}
+}
+
+func (s *MemoryEmailSender) Send(_ context.Context, message *EmailMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, *message)
+	return nil
+}
+
+// Messages returns the emails sent so far, oldest first.
+func (s *MemoryEmailSender) Messages() []EmailMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]EmailMessage(nil), s.messages...)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/email
+var embeddedEmailTemplates embed.FS
+
+// emailTemplate is one email in one locale: NAME.txt is the plain text body
+// and defines the "subject" template, NAME.html is the HTML body.
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// EmailTemplates renders emails from a directory with one subdirectory per
+// locale, like pt-BR or en. Locales other than the default may provide only
+// some templates; the others fall back to the default locale.
+type EmailTemplates struct {
+	defaultLocale string
+	locales       map[string]map[string]*emailTemplate
+}
+
+// LoadEmailTemplates reads the templates from dir, or the ones built into
+// the binary when dir is empty. Every template must exist in the default
+// locale.
+func LoadEmailTemplates(dir, defaultLocale string, names []string) (*EmailTemplates, error) {
+	var fsys fs.FS
+	if dir == "" {
+		sub, err := fs.Sub(embeddedEmailTemplates, "templates/email")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	} else {
+		fsys = os.DirFS(dir)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email templates: %w", err)
+	}
+
+	templates := &EmailTemplates{
+		defaultLocale: defaultLocale,
+		locales:       map[string]map[string]*emailTemplate{},
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		locale := entry.Name()
+		key := strings.ToLower(locale)
+		templates.locales[key] = map[string]*emailTemplate{}
+		for _, name := range names {
+			template, err := parseEmailTemplate(fsys, locale, name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			templates.locales[key][name] = template
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := templates.locales[strings.ToLower(defaultLocale)][name]; !ok {
+			return nil, fmt.Errorf("email template %s is missing for the default locale %s", name, defaultLocale)
+		}
+	}
+
+	return templates, nil
+}
+
+func parseEmailTemplate(fsys fs.FS, locale, name string) (*emailTemplate, error) {
+	textFile := locale + "/" + name + ".txt"
+	htmlFile := locale + "/" + name + ".html"
+
+	textSource, err := fs.ReadFile(fsys, textFile)
+	if err != nil {
+		return nil, err
+	}
+	htmlSource, err := fs.ReadFile(fsys, htmlFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("email template %s has no HTML body", textFile)
+		}
+		return nil, err
+	}
+
+	text, err := texttemplate.New(name).Option("missingkey=error").Parse(string(textSource))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email template %s: %w", textFile, err)
+	}
+	if text.Lookup("subject") == nil {
+		return nil, fmt.Errorf("email template %s does not define a subject", textFile)
+	}
+
+	html, err := htmltemplate.New(name).Option("missingkey=error").Parse(string(htmlSource))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email template %s: %w", htmlFile, err)
+	}
+
+	return &emailTemplate{text: text, html: html}, nil
+}
+
+// Render builds the email from the template in the given locale, falling
+// back to its language (en for en-US) and then to the default locale.
+// Locales are matched ignoring case.
+func (t *EmailTemplates) Render(name, locale string, data map[string]any) (*EmailMessage, error) {
+	template := t.lookup(name, locale)
+	if template == nil {
+		return nil, fmt.Errorf("unknown email template %s", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := template.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := template.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := template.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &EmailMessage{
+		Subject:  strings.Join(strings.Fields(subject.String()), " "),
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
+
+func (t *EmailTemplates) lookup(name, locale string) *emailTemplate {
+	candidates := []string{locale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, language)
+	}
+	candidates = append(candidates, t.defaultLocale)
+
+	for _, candidate := range candidates {
+		if template, ok := t.locales[strings.ToLower(candidate)][name]; ok {
+			return template
+		}
+	}
+	return nil
+}
@@ -3,20 +3,54 @@ package services
 import (
 	"errors"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AccessTokenTTL         = 15 * time.Minute
+	MFAChallengeTokenTTL   = 5 * time.Minute
+	PasswordChangeTokenTTL = 10 * time.Minute
+)
+
+const (
+	TokenUseAccess         = "access"
+	TokenUseOAuthAccess    = "oauth_access"
+	TokenUseClientAccess   = "client_access"
+	TokenUseMFAChallenge   = "mfa_challenge"
+	TokenUsePasswordChange = "password_change"
 )
 
 type JWTService struct {
-	secretKey []byte
+	secretKey  []byte
+	keyManager *KeyManager
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
-	Role   string `json:"role"`
+	UserID         string   `json:"user_id"`
+	Email          string   `json:"email"`
+	Name           string   `json:"name"`
+	Role           string   `json:"role"`
+	OrganizationID string   `json:"org_id,omitempty"`
+	Permissions    []string `json:"permissions,omitempty"`
+	TokenUse       string   `json:"token_use"`
+	ClientID       string   `json:"client_id,omitempty"`
+	Scope          string   `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IDTokenClaims are the OpenID Connect claims of an ID token. The caller fills
+// in the registered claims, since issuer and audience depend on the request.
+type IDTokenClaims struct {
+	Email           string `json:"email,omitempty"`
+	EmailVerified   *bool  `json:"email_verified,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Nonce           string `json:"nonce,omitempty"`
+	AuthTime        int64  `json:"auth_time,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -30,40 +64,193 @@ func NewJWTService() *JWTService {
 	}
 }
 
-func (j *JWTService) GenerateToken(userID, email, name, role string) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Name:   name,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "api-auth-go",
-			Subject:   userID,
-		},
+// NewJWTServiceWithKeyManager signs tokens with the active asymmetric key of
+// keyManager instead of the shared HMAC secret.
+func NewJWTServiceWithKeyManager(keyManager *KeyManager) *JWTService {
+	return &JWTService{
+		keyManager: keyManager,
+	}
+}
+
+func (j *JWTService) KeyManager() *KeyManager {
+	return j.keyManager
+}
+
+// GenerateToken issues an access token carrying the permissions of the
+// user's role, so routes can be authorized without a database lookup.
+// Permission changes apply to new tokens only. Tokens issued for an
+// organization carry its id and the role of the user in it. The id of the
+// token is returned with it, so a session can be told apart from the others
+// of the user.
+func (j *JWTService) GenerateToken(userID, email, name, role, organizationID string, permissions []string) (string, string, error) {
+	return j.issue(Claims{UserID: userID, Email: email, Name: name, Role: role, OrganizationID: organizationID, Permissions: permissions, TokenUse: TokenUseAccess}, userID, AccessTokenTTL)
+}
+
+// GenerateOAuthAccessToken issues an access token on behalf of a user to an
+// OAuth client, limited to the granted scope. It is rejected by ValidateToken,
+// so clients cannot act as the user on the first-party routes, and is only
+// accepted by ValidateUserInfoToken.
+func (j *JWTService) GenerateOAuthAccessToken(userID, email, name, role, clientID, scope string) (string, error) {
+	return j.generate(Claims{UserID: userID, Email: email, Name: name, Role: role, TokenUse: TokenUseOAuthAccess, ClientID: clientID, Scope: scope}, userID, AccessTokenTTL)
+}
+
+// GenerateClientAccessToken issues a token for the client_credentials grant.
+// It carries no user, so it is not accepted by ValidateToken and is meant for
+// services verifying tokens through the JWKS.
+func (j *JWTService) GenerateClientAccessToken(clientID, scope string) (string, error) {
+	return j.generate(Claims{TokenUse: TokenUseClientAccess, ClientID: clientID, Scope: scope}, clientID, AccessTokenTTL)
+}
+
+// GenerateMFAChallengeToken issues the short-lived token returned by the first
+// login step of users with two-factor authentication. It is rejected by
+// ValidateToken and can only be exchanged for an access token for the same
+// organization.
+func (j *JWTService) GenerateMFAChallengeToken(userID, email, name, role, organizationID string) (string, error) {
+	return j.generate(Claims{UserID: userID, Email: email, Name: name, Role: role, OrganizationID: organizationID, TokenUse: TokenUseMFAChallenge}, userID, MFAChallengeTokenTTL)
+}
+
+// GeneratePasswordChangeToken issues the token returned by logins of users
+// who must change their password. It is rejected by ValidateToken and can
+// only be used to set a new password, which completes the login.
+func (j *JWTService) GeneratePasswordChangeToken(userID, email, name, role, organizationID string) (string, error) {
+	return j.generate(Claims{UserID: userID, Email: email, Name: name, Role: role, OrganizationID: organizationID, TokenUse: TokenUsePasswordChange}, userID, PasswordChangeTokenTTL)
+}
+
+func (j *JWTService) SignIDToken(claims IDTokenClaims) (string, error) {
+	return j.sign(claims)
+}
+
+// ParseIDTokenHint verifies the signature of an ID token we issued but skips
+// expiry checks, as required for the id_token_hint of RP-initiated logout.
+func (j *JWTService) ParseIDTokenHint(tokenString string) (*IDTokenClaims, error) {
+	token, err := j.parse(tokenString, &IDTokenClaims{}, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*IDTokenClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// SigningAlgorithm returns the JWS algorithm currently used to sign tokens.
+func (j *JWTService) SigningAlgorithm() string {
+	if j.keyManager == nil {
+		return jwt.SigningMethodHS256.Alg()
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	key, err := j.keyManager.ActiveKey()
+	if err != nil {
+		return ""
+	}
+	return key.Algorithm
 }
 
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return j.secretKey, nil
-	})
+	return j.validate(tokenString, TokenUseAccess)
+}
+
+// ValidateUserInfoToken accepts the access tokens of both first-party
+// sessions and OAuth clients, for the OpenID Connect userinfo endpoint.
+func (j *JWTService) ValidateUserInfoToken(tokenString string) (*Claims, error) {
+	return j.validate(tokenString, TokenUseAccess, TokenUseOAuthAccess)
+}
+
+func (j *JWTService) ValidateMFAChallengeToken(tokenString string) (*Claims, error) {
+	return j.validate(tokenString, TokenUseMFAChallenge)
+}
+
+func (j *JWTService) ValidatePasswordChangeToken(tokenString string) (*Claims, error) {
+	return j.validate(tokenString, TokenUsePasswordChange)
+}
+
+func (j *JWTService) generate(claims Claims, subject string, ttl time.Duration) (string, error) {
+	token, _, err := j.issue(claims, subject, ttl)
+	return token, err
+}
 
+// issue signs the claims with a new token id and returns the token and its
+// id.
+func (j *JWTService) issue(claims Claims, subject string, ttl time.Duration) (string, string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Issuer:    "api-auth-go",
+		Subject:   subject,
+		ID:        uuid.New().String(),
+	}
+
+	token, err := j.sign(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return token, claims.ID, nil
+}
+
+func (j *JWTService) validate(tokenString string, tokenUses ...string) (*Claims, error) {
+	token, err := j.parse(tokenString, &Claims{})
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if claims.ID == "" || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+			return nil, errors.New("invalid token")
+		}
+		if !slices.Contains(tokenUses, claims.TokenUse) {
+			return nil, errors.New("invalid token")
+		}
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
+
+func (j *JWTService) sign(claims jwt.Claims) (string, error) {
+	if j.keyManager == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(j.secretKey)
+	}
+
+	key, err := j.keyManager.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(signingMethodFor(key.Algorithm), claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.privateKey)
+}
+
+func (j *JWTService) parse(tokenString string, claims jwt.Claims, options ...jwt.ParserOption) (*jwt.Token, error) {
+	if j.keyManager == nil {
+		return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("unexpected signing method")
+			}
+			return j.secretKey, nil
+		}, options...)
+	}
+
+	options = append(options, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA}))
+
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, errors.New("missing key id")
+		}
+
+		key, publicKey, err := j.keyManager.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unexpected signing method")
+		}
+		return publicKey, nil
+	}, options...)
+}
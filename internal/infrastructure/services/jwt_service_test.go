@@ -0,0 +1,55 @@
+package services
+
+import "testing"
+
+func TestJWTServiceValidatorsCheckTokenUse(t *testing.T) {
+	service := NewJWTService()
+
+	access, _, _ := service.GenerateToken("user", "user@example.com", "User", "user", "", nil)
+	oauthAccess, _ := service.GenerateOAuthAccessToken("user", "user@example.com", "User", "user", "client", "openid")
+	clientAccess, _ := service.GenerateClientAccessToken("client", "")
+	challenge, _ := service.GenerateMFAChallengeToken("user", "user@example.com", "User", "user", "")
+	passwordChange, _ := service.GeneratePasswordChangeToken("user", "user@example.com", "User", "user", "")
+
+	validators := map[string]func(string) (*Claims, error){
+		"ValidateToken":               service.ValidateToken,
+		"ValidateUserInfoToken":       service.ValidateUserInfoToken,
+		"ValidateMFAChallengeToken":   service.ValidateMFAChallengeToken,
+		"ValidatePasswordChangeToken": service.ValidatePasswordChangeToken,
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid []string
+	}{
+		{name: "access", token: access, valid: []string{"ValidateToken", "ValidateUserInfoToken"}},
+		{name: "oauth access", token: oauthAccess, valid: []string{"ValidateUserInfoToken"}},
+		{name: "client access", token: clientAccess},
+		{name: "mfa challenge", token: challenge, valid: []string{"ValidateMFAChallengeToken"}},
+		{name: "password change", token: passwordChange, valid: []string{"ValidatePasswordChangeToken"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, validate := range validators {
+				want := false
+				for _, valid := range tt.valid {
+					want = want || valid == name
+				}
+
+				if _, err := validate(tt.token); (err == nil) != want {
+					t.Errorf("%s: got error %v, want accepted %v", name, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTServiceRejectsTokensSignedWithAnotherKey(t *testing.T) {
+	token, _, _ := (&JWTService{secretKey: []byte("another secret")}).GenerateToken("user", "user@example.com", "User", "user", "", nil)
+
+	if _, err := NewJWTService().ValidateToken(token); err == nil {
+		t.Error("got no error for a token signed with another key")
+	}
+}
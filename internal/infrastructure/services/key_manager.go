@@ -0,0 +1,509 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Key lifecycle: a pending key is published in the JWKS but not used for
+// signing yet, so verifiers can fetch it before the switch. Promoting a key
+// makes it active and demotes the previous active key to inactive, which still
+// verifies tokens until it is retired.
+const (
+	KeyStatusPending  = "pending"
+	KeyStatusActive   = "active"
+	KeyStatusInactive = "inactive"
+	KeyStatusRetired  = "retired"
+)
+
+const (
+	keyManifestFile     = "keys.json"
+	keyReloadInterval   = time.Minute
+	rsaKeyBits          = 2048
+	privateKeyFileMode  = 0600
+	keyManifestFileMode = 0644
+)
+
+type SigningKey struct {
+	KID        string    `json:"kid"`
+	Algorithm  string    `json:"algorithm"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	privateKey crypto.Signer
+}
+
+type JSONWebKey struct {
+	KID string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type keyManifest struct {
+	Keys []SigningKey `json:"keys"`
+}
+
+// KeyManager keeps the asymmetric signing keys stored in a directory: one
+// PKCS#8 PEM file per key named <kid>.pem plus a keys.json manifest holding
+// their status. The manifest is re-read periodically so that a rotation done
+// by another replica or by an operator is picked up without a restart.
+type KeyManager struct {
+	dir              string
+	defaultAlgorithm string
+
+	mu       sync.RWMutex
+	keys     map[string]*SigningKey
+	activeID string
+	loadedAt time.Time
+	// unknownKIDReloadAt is when a token with an unknown kid last caused a
+	// reload.
+	unknownKIDReloadAt time.Time
+}
+
+func NewKeyManager(dir, defaultAlgorithm string) (*KeyManager, error) {
+	if err := ValidateSigningAlgorithm(defaultAlgorithm); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keys directory: %w", err)
+	}
+
+	km := &KeyManager{
+		dir:              dir,
+		defaultAlgorithm: defaultAlgorithm,
+		keys:             make(map[string]*SigningKey),
+	}
+
+	if err := km.Reload(); err != nil {
+		return nil, err
+	}
+
+	if km.activeID == "" {
+		key, err := km.GenerateKey(defaultAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		if err := km.PromoteKey(key.KID); err != nil {
+			return nil, err
+		}
+	}
+
+	return km, nil
+}
+
+func ValidateSigningAlgorithm(algorithm string) error {
+	switch algorithm {
+	case AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA:
+		return nil
+	default:
+		return errors.New("algorithm must be 'RS256', 'ES256' or 'EdDSA'")
+	}
+}
+
+func (km *KeyManager) Reload() error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	return km.reloadLocked()
+}
+
+func (km *KeyManager) reloadLocked() error {
+	manifest, err := km.readManifest()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*SigningKey, len(manifest.Keys))
+	activeID := ""
+	for i := range manifest.Keys {
+		key := manifest.Keys[i]
+		if key.Status != KeyStatusRetired {
+			privateKey, err := km.readPrivateKey(key.KID)
+			if err != nil {
+				return err
+			}
+			key.privateKey = privateKey
+		}
+		if key.Status == KeyStatusActive {
+			activeID = key.KID
+		}
+		keys[key.KID] = &key
+	}
+
+	km.keys = keys
+	km.activeID = activeID
+	km.loadedAt = time.Now()
+	return nil
+}
+
+func (km *KeyManager) refreshIfStale() {
+	km.mu.RLock()
+	stale := time.Since(km.loadedAt) > keyReloadInterval
+	km.mu.RUnlock()
+
+	if stale {
+		if err := km.Reload(); err != nil {
+			km.mu.Lock()
+			km.loadedAt = time.Now()
+			km.mu.Unlock()
+		}
+	}
+}
+
+func (km *KeyManager) ActiveKey() (*SigningKey, error) {
+	km.refreshIfStale()
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	key, ok := km.keys[km.activeID]
+	if !ok {
+		return nil, errors.New("no active signing key")
+	}
+	return key, nil
+}
+
+// VerificationKey returns the public key for kid if it may still be used to
+// verify tokens. An unknown kid triggers a reload, since it may belong to a key
+// promoted by another replica, but at most once per keyReloadInterval, so
+// tokens with made-up kids cannot make every request read the keys directory.
+func (km *KeyManager) VerificationKey(kid string) (*SigningKey, crypto.PublicKey, error) {
+	km.refreshIfStale()
+
+	km.mu.RLock()
+	key, ok := km.keys[kid]
+	km.mu.RUnlock()
+
+	if !ok && km.allowUnknownKIDReload() {
+		if err := km.Reload(); err != nil {
+			return nil, nil, err
+		}
+		km.mu.RLock()
+		key, ok = km.keys[kid]
+		km.mu.RUnlock()
+	}
+
+	if !ok || key.Status == KeyStatusRetired {
+		return nil, nil, errors.New("unknown signing key")
+	}
+
+	return key, key.privateKey.Public(), nil
+}
+
+// allowUnknownKIDReload reports whether an unknown kid may reload the keys,
+// and if so counts the reload against the interval.
+func (km *KeyManager) allowUnknownKIDReload() bool {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if time.Since(km.unknownKIDReloadAt) < keyReloadInterval {
+		return false
+	}
+	km.unknownKIDReloadAt = time.Now()
+	return true
+}
+
+func (km *KeyManager) ListKeys() []SigningKey {
+	km.refreshIfStale()
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	keys := make([]SigningKey, 0, len(km.keys))
+	for _, key := range km.keys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+func (km *KeyManager) GenerateKey(algorithm string) (*SigningKey, error) {
+	if algorithm == "" {
+		algorithm = km.defaultAlgorithm
+	}
+	if err := ValidateSigningAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+
+	privateKey, err := generatePrivateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if err := km.reloadLocked(); err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		KID:        uuid.New().String(),
+		Algorithm:  algorithm,
+		Status:     KeyStatusPending,
+		CreatedAt:  time.Now().UTC(),
+		privateKey: privateKey,
+	}
+
+	if err := km.writePrivateKey(key.KID, privateKey); err != nil {
+		return nil, err
+	}
+
+	km.keys[key.KID] = key
+	if err := km.writeManifestLocked(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (km *KeyManager) PromoteKey(kid string) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if err := km.reloadLocked(); err != nil {
+		return err
+	}
+
+	key, ok := km.keys[kid]
+	if !ok {
+		return errors.New("key not found")
+	}
+	if key.Status == KeyStatusRetired {
+		return errors.New("retired keys cannot be promoted")
+	}
+	if key.Status == KeyStatusActive {
+		return nil
+	}
+
+	if current, ok := km.keys[km.activeID]; ok {
+		current.Status = KeyStatusInactive
+	}
+	key.Status = KeyStatusActive
+	km.activeID = key.KID
+
+	return km.writeManifestLocked()
+}
+
+func (km *KeyManager) RetireKey(kid string) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if err := km.reloadLocked(); err != nil {
+		return err
+	}
+
+	key, ok := km.keys[kid]
+	if !ok {
+		return errors.New("key not found")
+	}
+	if key.Status == KeyStatusActive {
+		return errors.New("the active key cannot be retired, promote another key first")
+	}
+
+	key.Status = KeyStatusRetired
+	key.privateKey = nil
+	if err := km.writeManifestLocked(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(km.privateKeyPath(kid)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove retired key: %w", err)
+	}
+	return nil
+}
+
+func (km *KeyManager) JWKS() JSONWebKeySet {
+	keys := km.ListKeys()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range keys {
+		if key.Status == KeyStatusRetired {
+			continue
+		}
+		set.Keys = append(set.Keys, toJSONWebKey(key))
+	}
+	return set
+}
+
+func (km *KeyManager) privateKeyPath(kid string) string {
+	return filepath.Join(km.dir, kid+".pem")
+}
+
+func (km *KeyManager) readManifest() (*keyManifest, error) {
+	data, err := os.ReadFile(filepath.Join(km.dir, keyManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &keyManifest{}, nil
+		}
+		return nil, fmt.Errorf("failed to read key manifest: %w", err)
+	}
+
+	var manifest keyManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse key manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+func (km *KeyManager) writeManifestLocked() error {
+	manifest := keyManifest{Keys: make([]SigningKey, 0, len(km.keys))}
+	for _, key := range km.keys {
+		manifest.Keys = append(manifest.Keys, *key)
+	}
+	sort.Slice(manifest.Keys, func(i, j int) bool {
+		return manifest.Keys[i].CreatedAt.Before(manifest.Keys[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(km.dir, keyManifestFile), data, keyManifestFileMode)
+}
+
+func (km *KeyManager) readPrivateKey(kid string) (crypto.Signer, error) {
+	data, err := os.ReadFile(km.privateKeyPath(kid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("key %s is not a PKCS#8 PEM private key", kid)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", kid, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("key %s has an unsupported type", kid)
+	}
+	return signer, nil
+}
+
+func (km *KeyManager) writePrivateKey(kid string, privateKey crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return writeFileAtomic(km.privateKeyPath(kid), data, privateKeyFileMode)
+}
+
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func generatePrivateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, errors.New("unsupported algorithm")
+	}
+}
+
+func signingMethodFor(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmES256:
+		return jwt.SigningMethodES256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
+
+func toJSONWebKey(key SigningKey) JSONWebKey {
+	jwk := JSONWebKey{
+		KID: key.KID,
+		Alg: key.Algorithm,
+		Use: "sig",
+	}
+
+	switch publicKey := key.privateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdh, _ := publicKey.ECDH()
+		raw := ecdh.Bytes()
+		size := (len(raw) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(raw[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(raw[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
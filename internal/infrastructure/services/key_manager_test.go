@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestKeyManager(t *testing.T, dir string) *KeyManager {
+	t.Helper()
+
+	km, err := NewKeyManager(dir, AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("failed to create key manager: %v", err)
+	}
+	return km
+}
+
+func TestVerificationKeyReloadsForKeysOfOtherReplicas(t *testing.T) {
+	dir := t.TempDir()
+	km := newTestKeyManager(t, dir)
+
+	key, err := newTestKeyManager(t, dir).GenerateKey("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := km.VerificationKey(key.KID); err != nil {
+		t.Errorf("got error %v for a key added by another replica", err)
+	}
+}
+
+func TestVerificationKeyLimitsReloadsForUnknownKIDs(t *testing.T) {
+	dir := t.TempDir()
+	km := newTestKeyManager(t, dir)
+
+	if _, _, err := km.VerificationKey("made-up"); err == nil {
+		t.Fatal("got no error for an unknown kid")
+	}
+
+	// The reload was spent on the made-up kid, so a real new key is only
+	// found once the interval passes.
+	key, err := newTestKeyManager(t, dir).GenerateKey("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := km.VerificationKey(key.KID); err == nil {
+		t.Error("got the key without waiting for the reload interval")
+	}
+
+	km.mu.Lock()
+	km.unknownKIDReloadAt = time.Now().Add(-keyReloadInterval)
+	km.mu.Unlock()
+
+	if _, _, err := km.VerificationKey(key.KID); err != nil {
+		t.Errorf("got error %v once the reload interval passed", err)
+	}
+}
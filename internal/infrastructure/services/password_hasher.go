@@ -0,0 +1,303 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+type PasswordHasherConfig struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Time        uint32
+	Argon2Parallelism uint8
+	PepperFile        string
+}
+
+// passwordHashScheme is a single hashing algorithm. Supports tells, from the
+// hash prefix, whether the scheme is able to verify a stored hash.
+type passwordHashScheme interface {
+	entities.PasswordHasher
+	Supports(encodedHash string) bool
+}
+
+// PasswordHasher hashes new passwords with the configured scheme but verifies
+// hashes of every supported scheme, flagging the ones that are not in the
+// preferred format for rehashing.
+type PasswordHasher struct {
+	preferred passwordHashScheme
+	schemes   []passwordHashScheme
+}
+
+func NewPasswordHasher(cfg PasswordHasherConfig) (*PasswordHasher, error) {
+	var pepper []byte
+	if cfg.PepperFile != "" {
+		raw, err := os.ReadFile(cfg.PepperFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password pepper: %w", err)
+		}
+		pepper = []byte(strings.TrimSpace(string(raw)))
+		if len(pepper) < 16 {
+			return nil, errors.New("password pepper must be at least 16 bytes")
+		}
+	}
+
+	bcryptHasher, err := NewBcryptHasher(cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2Hasher, err := NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Time, cfg.Argon2Parallelism, pepper)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := &PasswordHasher{
+		schemes: []passwordHashScheme{argon2Hasher, bcryptHasher},
+	}
+
+	switch cfg.Algorithm {
+	case PasswordHashArgon2id:
+		hasher.preferred = argon2Hasher
+	case PasswordHashBcrypt:
+		hasher.preferred = bcryptHasher
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s", cfg.Algorithm)
+	}
+
+	return hasher, nil
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *PasswordHasher) Verify(password, encodedHash string) (bool, error) {
+	for _, scheme := range h.schemes {
+		if scheme.Supports(encodedHash) {
+			return scheme.Verify(password, encodedHash)
+		}
+	}
+	return false, errors.New("unknown password hash format")
+}
+
+func (h *PasswordHasher) NeedsRehash(encodedHash string) bool {
+	return !h.preferred.Supports(encodedHash) || h.preferred.NeedsRehash(encodedHash)
+}
+
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{cost: cost}, nil
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(password, encodedHash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != h.cost
+}
+
+func (h *BcryptHasher) Supports(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") || strings.HasPrefix(encodedHash, "$2b$") || strings.HasPrefix(encodedHash, "$2y$")
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2idHasher produces PHC strings such as
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>. When a pepper is configured
+// the password is first run through HMAC-SHA256 with it, and the hash records
+// a keyid derived from the pepper. Hashes without a keyid are still verified
+// without the pepper, so one can be introduced on an existing database; a
+// hash made with another pepper is reported instead of silently failing.
+type Argon2idHasher struct {
+	memory      uint32
+	time        uint32
+	parallelism uint8
+	pepper      []byte
+	keyID       string
+}
+
+type argon2idHash struct {
+	memory      uint32
+	time        uint32
+	parallelism uint8
+	keyID       string
+	salt        []byte
+	key         []byte
+}
+
+func NewArgon2idHasher(memory, time uint32, parallelism uint8, pepper []byte) (*Argon2idHasher, error) {
+	if memory < 8*uint32(parallelism) || time < 1 || parallelism < 1 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+
+	hasher := &Argon2idHasher{
+		memory:      memory,
+		time:        time,
+		parallelism: parallelism,
+		pepper:      pepper,
+	}
+
+	if len(pepper) > 0 {
+		sum := sha256.Sum256(pepper)
+		hasher.keyID = hex.EncodeToString(sum[:4])
+	}
+
+	return hasher, nil
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey(h.prepare(password, h.keyID != ""), salt, h.time, h.memory, h.parallelism, argon2KeyLength)
+
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", h.memory, h.time, h.parallelism)
+	if h.keyID != "" {
+		params += ",keyid=" + h.keyID
+	}
+
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s",
+		argon2.Version,
+		params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encodedHash string) (bool, error) {
+	decoded, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	if decoded.keyID != "" && decoded.keyID != h.keyID {
+		return false, errors.New("password hash was created with a different pepper")
+	}
+
+	key := argon2.IDKey(h.prepare(password, decoded.keyID != ""), decoded.salt, decoded.time, decoded.memory, decoded.parallelism, uint32(len(decoded.key)))
+	return subtle.ConstantTimeCompare(key, decoded.key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	decoded, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return decoded.memory != h.memory ||
+		decoded.time != h.time ||
+		decoded.parallelism != h.parallelism ||
+		decoded.keyID != h.keyID ||
+		len(decoded.key) != argon2KeyLength
+}
+
+func (h *Argon2idHasher) Supports(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$argon2id$")
+}
+
+func (h *Argon2idHasher) prepare(password string, peppered bool) []byte {
+	if !peppered {
+		return []byte(password)
+	}
+
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+func decodeArgon2idHash(encodedHash string) (*argon2idHash, error) {
+	invalid := errors.New("invalid argon2id hash")
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, invalid
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, invalid
+	}
+
+	decoded := &argon2idHash{}
+	for _, param := range strings.Split(parts[3], ",") {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, invalid
+		}
+
+		var err error
+		switch name {
+		case "m":
+			_, err = fmt.Sscanf(value, "%d", &decoded.memory)
+		case "t":
+			_, err = fmt.Sscanf(value, "%d", &decoded.time)
+		case "p":
+			_, err = fmt.Sscanf(value, "%d", &decoded.parallelism)
+		case "keyid":
+			decoded.keyID = value
+		default:
+			err = invalid
+		}
+		if err != nil {
+			return nil, invalid
+		}
+	}
+
+	if decoded.memory == 0 || decoded.time == 0 || decoded.parallelism == 0 {
+		return nil, invalid
+	}
+
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, invalid
+	}
+	if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(decoded.key) == 0 {
+		return nil, invalid
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// testArgon2Memory keeps the argon2id hashes of the tests cheap.
+const testArgon2Memory = 64
+
+func newTestPasswordHasher(t *testing.T, algorithm string, bcryptCost int, argon2Time uint32) *PasswordHasher {
+	t.Helper()
+
+	hasher, err := NewPasswordHasher(PasswordHasherConfig{
+		Algorithm:         algorithm,
+		BcryptCost:        bcryptCost,
+		Argon2Memory:      testArgon2Memory,
+		Argon2Time:        argon2Time,
+		Argon2Parallelism: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create password hasher: %v", err)
+	}
+	return hasher
+}
+
+func TestDecodeArgon2idHash(t *testing.T) {
+	const salt = "c2FsdHNhbHRzYWx0c2FsdA"
+	const key = "a2V5a2V5a2V5a2V5"
+
+	tests := []struct {
+		name       string
+		hash       string
+		wantErr    bool
+		wantMemory uint32
+		wantKeyID  string
+	}{
+		{name: "valid", hash: "$argon2id$v=19$m=19456,t=2,p=1$" + salt + "$" + key, wantMemory: 19456},
+		{name: "with keyid", hash: "$argon2id$v=19$m=64,t=1,p=1,keyid=0a1b2c3d$" + salt + "$" + key, wantMemory: 64, wantKeyID: "0a1b2c3d"},
+		{name: "other algorithm", hash: "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "other version", hash: "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "missing parameter", hash: "$argon2id$v=19$m=64,t=1$" + salt + "$" + key, wantErr: true},
+		{name: "unknown parameter", hash: "$argon2id$v=19$m=64,t=1,p=1,x=2$" + salt + "$" + key, wantErr: true},
+		{name: "parameter without value", hash: "$argon2id$v=19$m=64,t,p=1$" + salt + "$" + key, wantErr: true},
+		{name: "salt not base64", hash: "$argon2id$v=19$m=64,t=1,p=1$not*base64$" + key, wantErr: true},
+		{name: "empty key", hash: "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$", wantErr: true},
+		{name: "missing part", hash: "$argon2id$v=19$m=64,t=1,p=1$" + salt, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := decodeArgon2idHash(tt.hash)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error for an invalid hash")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded.memory != tt.wantMemory || decoded.keyID != tt.wantKeyID {
+				t.Errorf("got memory %d and keyid %q, want %d and %q", decoded.memory, decoded.keyID, tt.wantMemory, tt.wantKeyID)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherVerifiesEverySchemeAndFlagsRehash(t *testing.T) {
+	argon2 := newTestPasswordHasher(t, PasswordHashArgon2id, 4, 1)
+
+	tests := []struct {
+		name          string
+		hasher        *PasswordHasher
+		wantRehash    bool
+		wantPHCString bool
+	}{
+		{name: "preferred argon2id", hasher: argon2, wantPHCString: true},
+		{name: "argon2id with other parameters", hasher: newTestPasswordHasher(t, PasswordHashArgon2id, 4, 2), wantRehash: true, wantPHCString: true},
+		{name: "bcrypt", hasher: newTestPasswordHasher(t, PasswordHashBcrypt, 4, 1), wantRehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("failed to hash password: %v", err)
+			}
+			if got := strings.HasPrefix(hash, "$argon2id$"); got != tt.wantPHCString {
+				t.Errorf("got hash %q, want a PHC string %v", hash, tt.wantPHCString)
+			}
+
+			if ok, err := argon2.Verify("correct horse", hash); err != nil || !ok {
+				t.Errorf("got %v, %v verifying the password, want it accepted", ok, err)
+			}
+			if ok, err := argon2.Verify("wrong horse", hash); err != nil || ok {
+				t.Errorf("got %v, %v verifying a wrong password, want it rejected", ok, err)
+			}
+			if got := argon2.NeedsRehash(hash); got != tt.wantRehash {
+				t.Errorf("got NeedsRehash %v, want %v", got, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestArgon2idHasherPepper(t *testing.T) {
+	plain, err := NewArgon2idHasher(testArgon2Memory, 1, 1, nil)
+	if err != nil {
+		t.Fatalf("failed to create hasher: %v", err)
+	}
+	peppered, err := NewArgon2idHasher(testArgon2Memory, 1, 1, []byte("a pepper of sixteen bytes"))
+	if err != nil {
+		t.Fatalf("failed to create hasher: %v", err)
+	}
+	otherPepper, err := NewArgon2idHasher(testArgon2Memory, 1, 1, []byte("another pepper of enough bytes"))
+	if err != nil {
+		t.Fatalf("failed to create hasher: %v", err)
+	}
+
+	unpepperedHash, err := plain.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	pepperedHash, err := peppered.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	// A pepper can be introduced on an existing database: older hashes are
+	// still verified, and rehashed with it.
+	if ok, err := peppered.Verify("correct horse", unpepperedHash); err != nil || !ok {
+		t.Errorf("got %v, %v verifying a hash made without the pepper, want it accepted", ok, err)
+	}
+	if !peppered.NeedsRehash(unpepperedHash) {
+		t.Error("got a hash made without the pepper kept, want it rehashed")
+	}
+	if peppered.NeedsRehash(pepperedHash) {
+		t.Error("got a hash made with the pepper rehashed")
+	}
+
+	if _, err := otherPepper.Verify("correct horse", pepperedHash); err == nil {
+		t.Error("got no error verifying a hash made with another pepper")
+	}
+}
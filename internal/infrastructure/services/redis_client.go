@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisDialTimeout    = 5 * time.Second
+	redisCommandTimeout = 2 * time.Second
+	redisMaxIdleConns   = 8
+)
+
+// RedisError is an error reply sent by the server, as opposed to a network or
+// protocol failure.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return string(e)
+}
+
+// RedisClient is a minimal client for servers speaking the Redis protocol
+// (RESP2), such as Redis, Valkey or KeyDB. It only supports plain commands and
+// pipelines, which is all the rate limiter needs.
+type RedisClient struct {
+	addr     string
+	password string
+	db       int
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewRedisClient(addr, password string, db int) *RedisClient {
+	return &RedisClient{
+		addr:     addr,
+		password: password,
+		db:       db,
+		idle:     make(chan *redisConn, redisMaxIdleConns),
+	}
+}
+
+// Do sends a single command and returns its reply. Replies are decoded as
+// string (simple and bulk strings), int64, nil or []interface{}.
+func (c *RedisClient) Do(ctx context.Context, args ...string) (interface{}, error) {
+	replies, err := c.Pipeline(ctx, [][]string{args})
+	if err != nil {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends all commands in one write and reads their replies in order.
+// An error reply to any command is returned as a RedisError.
+func (c *RedisClient) Pipeline(ctx context.Context, commands [][]string) ([]interface{}, error) {
+	conn, err := c.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisCommandTimeout)
+	}
+	if err := conn.conn.SetDeadline(deadline); err != nil {
+		conn.conn.Close()
+		return nil, err
+	}
+
+	replies, err := conn.pipeline(commands)
+	if err != nil {
+		var redisErr RedisError
+		if !errors.As(err, &redisErr) {
+			conn.conn.Close()
+			return nil, err
+		}
+	}
+
+	c.putConn(conn)
+	return replies, err
+}
+
+func (c *RedisClient) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *RedisClient) getConn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: redisDialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+
+	var setup [][]string
+	if c.password != "" {
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	if len(setup) > 0 {
+		netConn.SetDeadline(time.Now().Add(redisCommandTimeout))
+		if _, err := conn.pipeline(setup); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("failed to set up redis connection: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (c *RedisClient) putConn(conn *redisConn) {
+	conn.conn.SetDeadline(time.Time{})
+	select {
+	case c.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+func (rc *redisConn) pipeline(commands [][]string) ([]interface{}, error) {
+	var buf strings.Builder
+	for _, args := range commands {
+		fmt.Fprintf(&buf, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if _, err := io.WriteString(rc.conn, buf.String()); err != nil {
+		return nil, err
+	}
+
+	// Every reply is read even after an error reply, so the connection is
+	// left in a clean state and can be reused.
+	replies := make([]interface{}, len(commands))
+	var replyErr error
+	for i := range commands {
+		reply, err := rc.readReply()
+		if err != nil {
+			var redisErr RedisError
+			if !errors.As(err, &redisErr) {
+				return nil, err
+			}
+			if replyErr == nil {
+				replyErr = err
+			}
+		}
+		replies[i] = reply
+	}
+
+	return replies, replyErr
+}
+
+func (rc *redisConn) readReply() (interface{}, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, errors.New("invalid redis reply")
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, errors.New("invalid redis bulk string length")
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(rc.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, errors.New("invalid redis array length")
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		var itemErr error
+		for i := range items {
+			item, err := rc.readReply()
+			if err != nil {
+				var redisErr RedisError
+				if !errors.As(err, &redisErr) {
+					return nil, err
+				}
+				if itemErr == nil {
+					itemErr = err
+				}
+			}
+			items[i] = item
+		}
+		return items, itemErr
+	default:
+		return nil, fmt.Errorf("unexpected redis reply type %q", kind)
+	}
+}
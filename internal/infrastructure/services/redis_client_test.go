@@ -0,0 +1,278 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRedisReadReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    interface{}
+		wantErr error
+	}{
+		{name: "simple string", input: "+OK\r\n", want: "OK"},
+		{name: "integer", input: ":42\r\n", want: int64(42)},
+		{name: "negative integer", input: ":-1\r\n", want: int64(-1)},
+		{name: "bulk string", input: "$5\r\nhello\r\n", want: "hello"},
+		{name: "bulk string with CRLF inside", input: "$4\r\na\r\nb\r\n", want: "a\r\nb"},
+		{name: "empty bulk string", input: "$0\r\n\r\n", want: ""},
+		{name: "nil bulk string", input: "$-1\r\n", want: nil},
+		{name: "array", input: "*3\r\n:1\r\n$3\r\nfoo\r\n+bar\r\n", want: []interface{}{int64(1), "foo", "bar"}},
+		{name: "nested array", input: "*2\r\n*1\r\n:1\r\n$-1\r\n", want: []interface{}{[]interface{}{int64(1)}, nil}},
+		{name: "empty array", input: "*0\r\n", want: []interface{}{}},
+		{name: "nil array", input: "*-1\r\n", want: nil},
+		{name: "error reply", input: "-ERR wrong type\r\n", wantErr: RedisError("ERR wrong type")},
+		{name: "error inside an array", input: "*2\r\n-ERR bad\r\n:7\r\n", want: []interface{}{nil, int64(7)}, wantErr: RedisError("ERR bad")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &redisConn{reader: bufio.NewReader(strings.NewReader(tt.input))}
+
+			got, err := rc.readReply()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisReadReplyRejectsMalformedReplies(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "unknown type", input: "?1\r\n"},
+		{name: "missing CR", input: ":1\n"},
+		{name: "too short", input: "\r\n"},
+		{name: "invalid integer", input: ":abc\r\n"},
+		{name: "invalid bulk length", input: "$x\r\n"},
+		{name: "truncated bulk string", input: "$10\r\nshort\r\n"},
+		{name: "invalid array length", input: "*x\r\n"},
+		{name: "truncated array", input: "*2\r\n:1\r\n"},
+		{name: "connection closed", input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &redisConn{reader: bufio.NewReader(strings.NewReader(tt.input))}
+
+			_, err := rc.readReply()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			var redisErr RedisError
+			if errors.As(err, &redisErr) {
+				t.Errorf("got a server error reply %q, want a protocol error", redisErr)
+			}
+		})
+	}
+}
+
+// fakeRedisServer answers RESP2 commands with the reply returned by handle,
+// already encoded, and records every command and connection it receives.
+type fakeRedisServer struct {
+	listener net.Listener
+	handle   func(args []string) string
+
+	mu          sync.Mutex
+	commands    [][]string
+	connections int
+}
+
+func newFakeRedisServer(t *testing.T, handle func(args []string) string) *fakeRedisServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &fakeRedisServer{listener: listener, handle: handle}
+	t.Cleanup(func() { listener.Close() })
+
+	go server.serve()
+	return server
+}
+
+func (s *fakeRedisServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+func (s *fakeRedisServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRedisCommand(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		s.mu.Unlock()
+		if _, err := io.WriteString(conn, s.handle(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedisServer) received() ([][]string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.commands...), s.connections
+}
+
+// readRedisCommand reads a command sent as an array of bulk strings.
+func readRedisCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected command line %q", line)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestRedisClientPipeline(t *testing.T) {
+	server := newFakeRedisServer(t, func(args []string) string {
+		switch args[0] {
+		case "INCR":
+			return ":1\r\n"
+		case "GET":
+			return "$-1\r\n"
+		default:
+			return "+OK\r\n"
+		}
+	})
+	client := NewRedisClient(server.listener.Addr().String(), "", 0)
+	defer client.Close()
+
+	replies, err := client.Pipeline(context.Background(), [][]string{
+		{"INCR", "counter"},
+		{"PEXPIRE", "counter", "60000"},
+		{"GET", "previous counter"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []interface{}{int64(1), "OK", nil}
+	if !reflect.DeepEqual(replies, want) {
+		t.Errorf("got replies %#v, want %#v", replies, want)
+	}
+
+	commands, _ := server.received()
+	wantCommands := [][]string{
+		{"INCR", "counter"},
+		{"PEXPIRE", "counter", "60000"},
+		{"GET", "previous counter"},
+	}
+	if !reflect.DeepEqual(commands, wantCommands) {
+		t.Errorf("server got %q, want %q", commands, wantCommands)
+	}
+}
+
+func TestRedisClientSetsUpNewConnections(t *testing.T) {
+	server := newFakeRedisServer(t, func(args []string) string {
+		return "+OK\r\n"
+	})
+	client := NewRedisClient(server.listener.Addr().String(), "secret", 2)
+	defer client.Close()
+
+	if _, err := client.Do(context.Background(), "PING"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	commands, _ := server.received()
+	want := [][]string{{"AUTH", "secret"}, {"SELECT", "2"}, {"PING"}}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("server got %q, want %q", commands, want)
+	}
+}
+
+func TestRedisClientFailsWhenSetupIsRejected(t *testing.T) {
+	server := newFakeRedisServer(t, func(args []string) string {
+		if args[0] == "AUTH" {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		return "+OK\r\n"
+	})
+	client := NewRedisClient(server.listener.Addr().String(), "wrong", 0)
+	defer client.Close()
+
+	if _, err := client.Do(context.Background(), "PING"); err == nil {
+		t.Fatal("expected the rejected AUTH to fail the command")
+	}
+}
+
+func TestRedisClientReusesConnectionAfterErrorReply(t *testing.T) {
+	server := newFakeRedisServer(t, func(args []string) string {
+		if args[0] == "FAIL" {
+			return "-ERR failed\r\n"
+		}
+		return ":1\r\n"
+	})
+	client := NewRedisClient(server.listener.Addr().String(), "", 0)
+	defer client.Close()
+
+	replies, err := client.Pipeline(context.Background(), [][]string{{"FAIL"}, {"INCR", "counter"}})
+	var redisErr RedisError
+	if !errors.As(err, &redisErr) {
+		t.Fatalf("got error %v, want a RedisError", err)
+	}
+	if replies[1] != int64(1) {
+		t.Errorf("got %#v for the command after the error, want 1", replies[1])
+	}
+
+	if _, err := client.Do(context.Background(), "INCR", "counter"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, connections := server.received(); connections != 1 {
+		t.Errorf("got %d connections, want the first one to be reused", connections)
+	}
+}
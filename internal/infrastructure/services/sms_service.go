@@ -1,67 +1,122 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
-	"os"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"api-auth-go/internal/infrastructure/i18n"
+)
+
+// SMS providers selectable by configuration.
+const (
+	SMSProviderLog  = "log"
+	SMSProviderHTTP = "http"
 )
 
-type SMSService struct {
-	apiKey    string
-	apiSecret string
-	from      string
-	baseURL   string
+// maxSMSResponseSize bounds how much of a provider response is read.
+const maxSMSResponseSize = 64 << 10
+
+// SMSService sends the text messages of the verification flows, in the
+// locale of the user. Phones are in E.164 format.
+type SMSService interface {
+	SendPasswordResetSMS(ctx context.Context, to, locale, code string) error
+	SendPhoneVerificationSMS(ctx context.Context, to, locale, code string) error
 }
 
-func NewSMSService() *SMSService {
-	return &SMSService{
-		apiKey:    os.Getenv("SMS_API_KEY"),
-		apiSecret: os.Getenv("SMS_API_SECRET"),
-		from:      os.Getenv("SMS_FROM"),
-		baseURL:   os.Getenv("SMS_BASE_URL"),
-	}
+func passwordResetSMS(locale, code string) string {
+	return i18n.Text(locale, "sms_password_reset", map[string]string{"code": code})
 }
 
-func (ss *SMSService) SendPasswordResetSMS(to, token string) error {
-	message := fmt.Sprintf("Seu código de verificação é: %s. Expira em 15 minutos.", token)
+func phoneVerificationSMS(locale, code string) string {
+	return i18n.Text(locale, "sms_phone_verification", map[string]string{"code": code})
+}
 
-	// Simulação de envio de SMS - em produção, use uma API real como Twilio, AWS SNS, etc.
-	log.Printf("SMS enviado para %s: %s", to, message)
+// LogSMSService only logs the messages, for development. The codes end up in
+// the log, so it must not be used in production.
+type LogSMSService struct{}
 
-	// Exemplo de implementação com API HTTP (comentado para simulação)
-	/*
-		data := url.Values{}
-		data.Set("to", to)
-		data.Set("from", ss.from)
-		data.Set("message", message)
-
-		req, err := http.NewRequest("POST", ss.baseURL+"/messages", strings.NewReader(data.Encode()))
-		if err != nil {
-			return err
-		}
-
-		req.Header.Set("Authorization", "Bearer "+ss.apiKey)
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("SMS API returned status: %d", resp.StatusCode)
-		}
-	*/
+func NewLogSMSService() *LogSMSService {
+	return &LogSMSService{}
+}
+
+func (ss *LogSMSService) SendPasswordResetSMS(ctx context.Context, to, locale, code string) error {
+	return ss.send(ctx, to, passwordResetSMS(locale, code))
+}
 
+func (ss *LogSMSService) SendPhoneVerificationSMS(ctx context.Context, to, locale, code string) error {
+	return ss.send(ctx, to, phoneVerificationSMS(locale, code))
+}
+
+func (ss *LogSMSService) send(_ context.Context, to, message string) error {
+	log.Printf("SMS enviado para %s: %s", to, message)
 	return nil
 }
 
-func (ss *SMSService) SendWelcomeSMS(to, name string) error {
-	message := fmt.Sprintf("Olá %s! Bem-vindo ao nosso sistema!", name)
+// HTTPSMSService sends messages through the HTTP API of an SMS provider:
+// a form with to, from and message is posted to baseURL/messages with the
+// API key as bearer token, and any 2xx response is a success.
+type HTTPSMSService struct {
+	baseURL    string
+	apiKey     string
+	from       string
+	httpClient *http.Client
+}
+
+func NewHTTPSMSService(baseURL, apiKey, from string, timeout time.Duration) *HTTPSMSService {
+	return &HTTPSMSService{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		from:    from,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (ss *HTTPSMSService) SendPasswordResetSMS(ctx context.Context, to, locale, code string) error {
+	return ss.send(ctx, to, passwordResetSMS(locale, code))
+}
+
+func (ss *HTTPSMSService) SendPhoneVerificationSMS(ctx context.Context, to, locale, code string) error {
+	return ss.send(ctx, to, phoneVerificationSMS(locale, code))
+}
+
+func (ss *HTTPSMSService) send(ctx context.Context, to, message string) error {
+	data := url.Values{}
+	data.Set("to", to)
+	data.Set("from", ss.from)
+	data.Set("message", message)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ss.baseURL+"/messages", strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+ss.apiKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	log.Printf("SMS de boas-vindas enviado para %s: %s", to, message)
+	resp, err := ss.httpClient.Do(req)
+	if err != nil {
+		log.Printf("Erro ao enviar SMS: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxSMSResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("Erro ao enviar SMS: status %d", resp.StatusCode)
+		return fmt.Errorf("SMS API returned status: %d", resp.StatusCode)
+	}
 
+	log.Printf("SMS enviado para: %s", to)
 	return nil
 }
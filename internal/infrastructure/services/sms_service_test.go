@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// smsRequest is what the fake provider received.
+type smsRequest struct {
+	path          string
+	authorization string
+	contentType   string
+	form          url.Values
+}
+
+// newFakeSMSProvider starts a provider answering with the status and
+// forwarding every request it receives to the returned channel.
+func newFakeSMSProvider(t *testing.T, status int) (*httptest.Server, <-chan smsRequest) {
+	t.Helper()
+
+	requests := make(chan smsRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse the form: %v", err)
+		}
+		requests <- smsRequest{
+			path:          r.URL.Path,
+			authorization: r.Header.Get("Authorization"),
+			contentType:   r.Header.Get("Content-Type"),
+			form:          r.PostForm,
+		}
+		if status == http.StatusFound {
+			w.Header().Set("Location", "/elsewhere")
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestHTTPSMSServicePostsMessage(t *testing.T) {
+	server, requests := newFakeSMSProvider(t, http.StatusAccepted)
+	service := NewHTTPSMSService(server.URL+"/", "api-key", "+5511900000000", time.Second)
+
+	if err := service.SendPhoneVerificationSMS(context.Background(), "+5511999999999", entities.LocaleEnglish, "123456"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := <-requests
+	if req.path != "/messages" {
+		t.Errorf("got path %q, want /messages", req.path)
+	}
+	if req.authorization != "Bearer api-key" {
+		t.Errorf("got authorization %q, want the API key as bearer token", req.authorization)
+	}
+	if req.contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("got content type %q", req.contentType)
+	}
+	if req.form.Get("to") != "+5511999999999" || req.form.Get("from") != "+5511900000000" {
+		t.Errorf("got to %q and from %q", req.form.Get("to"), req.form.Get("from"))
+	}
+	if want := phoneVerificationSMS(entities.LocaleEnglish, "123456"); req.form.Get("message") != want {
+		t.Errorf("got message %q, want %q", req.form.Get("message"), want)
+	}
+}
+
+func TestHTTPSMSServiceMessagesAreLocalized(t *testing.T) {
+	server, requests := newFakeSMSProvider(t, http.StatusOK)
+	service := NewHTTPSMSService(server.URL, "api-key", "+5511900000000", time.Second)
+
+	messages := map[string]bool{}
+	for _, locale := range entities.SupportedLocales() {
+		if err := service.SendPasswordResetSMS(context.Background(), "+5511999999999", locale, "654321"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", locale, err)
+		}
+
+		message := (<-requests).form.Get("message")
+		if !strings.Contains(message, "654321") {
+			t.Errorf("%s: got message %q without the code", locale, message)
+		}
+		messages[message] = true
+	}
+
+	if len(messages) != len(entities.SupportedLocales()) {
+		t.Errorf("got %d different messages for %d locales", len(messages), len(entities.SupportedLocales()))
+	}
+}
+
+func TestHTTPSMSServiceFailsOnUnsuccessfulStatus(t *testing.T) {
+	for _, status := range []int{http.StatusFound, http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError} {
+		server, requests := newFakeSMSProvider(t, status)
+		service := NewHTTPSMSService(server.URL, "api-key", "+5511900000000", time.Second)
+
+		if err := service.SendPasswordResetSMS(context.Background(), "+5511999999999", entities.LocalePortuguese, "123456"); err == nil {
+			t.Errorf("got no error for status %d", status)
+		}
+		<-requests
+	}
+}
+
+func TestHTTPSMSServiceHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	service := NewHTTPSMSService(server.URL, "api-key", "+5511900000000", time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := service.SendPasswordResetSMS(ctx, "+5511999999999", entities.LocalePortuguese, "123456")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want the context deadline", err)
+	}
+}
+
+func TestHTTPSMSServiceTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	service := NewHTTPSMSService(server.URL, "api-key", "+5511900000000", 50*time.Millisecond)
+
+	if err := service.SendPasswordResetSMS(context.Background(), "+5511999999999", entities.LocalePortuguese, "123456"); err == nil {
+		t.Error("got no error from a provider that never answers")
+	}
+}
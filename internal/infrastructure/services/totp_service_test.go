@@ -0,0 +1,118 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	// The RFC lists 8 digit codes; 6 digit codes are their last 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("at %d: got %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPValidateWindow(t *testing.T) {
+	service := &TOTPService{issuer: "test"}
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		name     string
+		step     int64
+		lastUsed int64
+		wantOK   bool
+	}{
+		{name: "current step", step: current, wantOK: true},
+		{name: "previous step", step: current - 1, wantOK: true},
+		{name: "next step", step: current + 1, wantOK: true},
+		{name: "two steps behind", step: current - 2},
+		{name: "two steps ahead", step: current + 2},
+		{name: "step already used", step: current, lastUsed: current},
+		{name: "earlier step than the one used", step: current - 1, lastUsed: current},
+		{name: "later step than the one used", step: current + 1, lastUsed: current, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := service.validateAt(rfc6238Secret, totpCode(key, tt.step), tt.lastUsed, now)
+			if ok != tt.wantOK {
+				t.Fatalf("got ok %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != tt.step {
+				t.Errorf("got step %d, want %d", step, tt.step)
+			}
+		})
+	}
+}
+
+func TestTOTPValidateRejectsMalformedInput(t *testing.T) {
+	service := &TOTPService{issuer: "test"}
+	now := time.Unix(1234567890, 0)
+	code := totpCode([]byte("12345678901234567890"), now.Unix()/totpPeriod)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		wantOK bool
+	}{
+		{name: "surrounding spaces", secret: rfc6238Secret, code: " " + code + " ", wantOK: true},
+		{name: "lowercase secret", secret: strings.ToLower(rfc6238Secret), code: code, wantOK: true},
+		{name: "short code", secret: rfc6238Secret, code: code[:5]},
+		{name: "long code", secret: rfc6238Secret, code: code + "0"},
+		{name: "empty code", secret: rfc6238Secret},
+		{name: "invalid secret", secret: "not base32!", code: code},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := service.validateAt(tt.secret, tt.code, 0, now); ok != tt.wantOK {
+				t.Errorf("got ok %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTOTPGenerateSecret(t *testing.T) {
+	service := &TOTPService{issuer: "test"}
+
+	first, err := service.GenerateSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := service.GenerateSecret()
+
+	key, err := totpEncoding.DecodeString(first)
+	if err != nil {
+		t.Fatalf("secret is not base32: %v", err)
+	}
+	if len(key) != totpSecretSize {
+		t.Errorf("got a %d byte secret, want %d", len(key), totpSecretSize)
+	}
+	if first == second {
+		t.Error("two secrets are the same")
+	}
+}
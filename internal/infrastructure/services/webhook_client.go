@@ -0,0 +1,61 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+// maxWebhookResponseSize bounds how much of a response is read, only to let
+// the connection be reused.
+const maxWebhookResponseSize = 64 << 10
+
+// WebhookClient sends signed webhook deliveries over HTTP.
+type WebhookClient struct {
+	httpClient *http.Client
+}
+
+func NewWebhookClient(timeout time.Duration) *WebhookClient {
+	return &WebhookClient{
+		httpClient: &http.Client{
+			Timeout: timeout,
+			// Redirects are not followed, so a delivery only reaches the
+			// configured URL.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send posts the payload of a delivery and returns the response status code.
+// An error means no response was received.
+func (c *WebhookClient) Send(ctx context.Context, subscription *entities.WebhookSubscription, delivery *entities.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "api-auth-go-webhooks/1.0")
+	req.Header.Set(entities.WebhookIDHeader, delivery.ID.String())
+	req.Header.Set(entities.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(entities.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(entities.WebhookSignatureHeader, entities.SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseSize))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+type AuditHandler struct {
+	auditUseCase *usecases.AuditUseCase
+}
+
+func NewAuditHandler(auditUseCase *usecases.AuditUseCase) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+	}
+}
+
+// ListEvents lists audit events, filtered by actor_id, target_id, action and
+// the from/to time range. Pages are followed with the cursor query parameter.
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	var input usecases.ListAuditEventsInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidQueryParameters))
+		return
+	}
+
+	output, err := h.auditUseCase.ListAuditEvents(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	output, err := h.auditUseCase.VerifyAuditChain(c.Request.Context())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecases.ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/i18n"
+)
+
+var (
+	errInvalidRequest         = entities.NewCodedError("invalid_request", "Invalid request")
+	errInvalidRequestBody     = entities.NewCodedError("invalid_request_body", "Invalid request body")
+	errInvalidQueryParameters = entities.NewCodedError("invalid_query_parameters", "Invalid query parameters")
+	errTokenRefreshFailed     = entities.NewCodedError("token_refresh_failed", "Failed to refresh token")
+)
+
+// errorResponse builds the usual error body in the locale of the request,
+// with the code of the error when it has one, adding the list of violations
+// when a password was rejected by the password policy.
+func errorResponse(c *gin.Context, err error) gin.H {
+	locale := entities.LocaleFromContext(c.Request.Context())
+	code, message := i18n.Error(locale, err)
+
+	body := gin.H{
+		"error": message,
+	}
+	if code != "" {
+		body["code"] = code
+	}
+
+	var policyErr *entities.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		violations := make([]entities.PasswordPolicyViolation, 0, len(policyErr.Violations))
+		for _, violation := range policyErr.Violations {
+			if message, ok := i18n.Lookup(locale, "password_"+violation.Code, violation.Params); ok {
+				violation.Message = message
+			}
+			violations = append(violations, violation)
+		}
+		body["violations"] = violations
+	}
+
+	return body
+}
+
+// setRetryAfter adds the Retry-After header when the login was refused by
+// the login throttling, reporting whether it did.
+func setRetryAfter(c *gin.Context, err error) bool {
+	var throttledErr *usecases.LoginThrottledError
+	if !errors.As(err, &throttledErr) {
+		return false
+	}
+
+	seconds := int(math.Ceil(throttledErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	return true
+}
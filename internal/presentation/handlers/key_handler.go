@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+type KeyHandler struct {
+	keyUseCase *usecases.KeyUseCase
+}
+
+func NewKeyHandler(keyUseCase *usecases.KeyUseCase) *KeyHandler {
+	return &KeyHandler{
+		keyUseCase: keyUseCase,
+	}
+}
+
+func (h *KeyHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keyUseCase.GetJWKS())
+}
+
+func (h *KeyHandler) ListKeys(c *gin.Context) {
+	output, err := h.keyUseCase.ListKeys()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *KeyHandler) GenerateKey(c *gin.Context) {
+	var input usecases.GenerateKeyInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+			return
+		}
+	}
+
+	var output *usecases.KeyOutput
+	var err error
+	if c.Query("promote") == "true" {
+		output, err = h.keyUseCase.RotateKey(input)
+	} else {
+		output, err = h.keyUseCase.GenerateKey(input)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *KeyHandler) PromoteKey(c *gin.Context) {
+	output, err := h.keyUseCase.PromoteKey(c.Param("kid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *KeyHandler) RetireKey(c *gin.Context) {
+	output, err := h.keyUseCase.RetireKey(c.Param("kid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
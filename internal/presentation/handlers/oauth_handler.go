@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/i18n"
+)
+
+var authorizePageTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{.T "oauth_page_title"}}</title>
+	<style>
+		body { font-family: sans-serif; max-width: 420px; margin: 40px auto; padding: 0 16px; color: #222; }
+		label { display: block; margin-top: 12px; }
+		input[type=email], input[type=password], input[type=text] { width: 100%; padding: 8px; box-sizing: border-box; }
+		.error { color: #b00020; }
+		.actions { margin-top: 20px; display: flex; gap: 8px; }
+		button { padding: 8px 16px; }
+	</style>
+</head>
+<body>
+	{{if .Fatal}}
+	<h2>{{.T "oauth_page_cannot_continue"}}</h2>
+	<p class="error">{{.Error}}</p>
+	{{else}}
+	<h2>{{.T "oauth_page_heading" "client" .ClientName}}</h2>
+	{{if .Scopes}}
+	<p>{{.T "oauth_page_scopes"}}</p>
+	<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+	{{end}}
+	{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+	<form method="POST" action="/oauth/authorize">
+		{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+		{{end}}
+		<label>{{.T "oauth_page_email"}} <input type="email" name="email" value="{{.Email}}" required autofocus></label>
+		<label>{{.T "oauth_page_password"}} <input type="password" name="password" required></label>
+		<label>{{.T "oauth_page_mfa_code"}} <input type="text" name="mfa_code" autocomplete="one-time-code"></label>
+		<div class="actions">
+			<button type="submit" name="decision" value="approve">{{.T "oauth_page_approve"}}</button>
+			<button type="submit" name="decision" value="deny" formnovalidate>{{.T "oauth_page_deny"}}</button>
+		</div>
+	</form>
+	{{end}}
+</body>
+</html>`))
+
+// pageText translates the texts of the HTML pages to the locale of the
+// request, set in Lang.
+type pageText struct {
+	Lang string
+}
+
+func newPageText(c *gin.Context) pageText {
+	return pageText{Lang: entities.LocaleFromContext(c.Request.Context())}
+}
+
+// T returns the message with the code, filling its placeholders from the
+// name and value pairs that follow it.
+func (p pageText) T(code string, params ...string) string {
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+	return i18n.Text(p.Lang, code, values)
+}
+
+// pageError returns the message of err to show on an HTML page, in the
+// locale of the request. Errors without a code may carry internal details,
+// so they are logged and replaced by a generic message.
+func pageError(c *gin.Context, err error) string {
+	locale := entities.LocaleFromContext(c.Request.Context())
+	code, message := i18n.Error(locale, err)
+	if code == "" {
+		log.Printf("Page error: %v", err)
+		return i18n.Text(locale, "page_unexpected_error", nil)
+	}
+	return message
+}
+
+type authorizePageData struct {
+	pageText
+	Fatal      bool
+	Error      string
+	ClientName string
+	Scopes     []string
+	Email      string
+	Params     map[string]string
+}
+
+type OAuthHandler struct {
+	oauthUseCase *usecases.OAuthUseCase
+}
+
+func NewOAuthHandler(oauthUseCase *usecases.OAuthUseCase) *OAuthHandler {
+	return &OAuthHandler{
+		oauthUseCase: oauthUseCase,
+	}
+}
+
+func (h *OAuthHandler) AuthorizePage(c *gin.Context) {
+	var input usecases.AuthorizeInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		h.renderAuthorizePage(c, http.StatusBadRequest, authorizePageData{Fatal: true, Error: pageError(c, errInvalidRequest)})
+		return
+	}
+
+	prompt, err := h.oauthUseCase.PrepareAuthorization(c.Request.Context(), input)
+	if err != nil {
+		h.handleAuthorizeError(c, input, err)
+		return
+	}
+
+	h.renderAuthorizePage(c, http.StatusOK, authorizePageData{
+		ClientName: prompt.ClientName,
+		Scopes:     prompt.Scopes,
+		Params:     authorizeParams(input),
+	})
+}
+
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	var input usecases.AuthorizeDecisionInput
+	if err := c.ShouldBind(&input); err != nil {
+		h.renderAuthorizePage(c, http.StatusBadRequest, authorizePageData{Fatal: true, Error: pageError(c, errInvalidRequest)})
+		return
+	}
+
+	output, err := h.oauthUseCase.Authorize(c.Request.Context(), input)
+	if err == nil {
+		c.Redirect(http.StatusFound, output.RedirectURL)
+		return
+	}
+
+	var oauthErr *usecases.OAuthError
+	if errors.As(err, &oauthErr) {
+		h.handleAuthorizeError(c, input.AuthorizeInput, err)
+		return
+	}
+
+	prompt, promptErr := h.oauthUseCase.PrepareAuthorization(c.Request.Context(), input.AuthorizeInput)
+	if promptErr != nil {
+		h.handleAuthorizeError(c, input.AuthorizeInput, promptErr)
+		return
+	}
+
+	status := http.StatusUnauthorized
+	if setRetryAfter(c, err) {
+		status = http.StatusTooManyRequests
+	}
+
+	h.renderAuthorizePage(c, status, authorizePageData{
+		Error:      pageError(c, err),
+		ClientName: prompt.ClientName,
+		Scopes:     prompt.Scopes,
+		Email:      input.Email,
+		Params:     authorizeParams(input.AuthorizeInput),
+	})
+}
+
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var input usecases.TokenInput
+	if err := c.ShouldBind(&input); err != nil {
+		c.JSON(http.StatusBadRequest, usecases.OAuthError{Code: "invalid_request", Description: "invalid request body"})
+		return
+	}
+
+	usedBasicAuth := false
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		usedBasicAuth = true
+		input.ClientID, _ = url.QueryUnescape(clientID)
+		input.ClientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+
+	output, err := h.oauthUseCase.Token(c.Request.Context(), input)
+	if err != nil {
+		var oauthErr *usecases.OAuthError
+		if !errors.As(err, &oauthErr) {
+			log.Printf("OAuth token error: %v", err)
+			c.JSON(http.StatusInternalServerError, usecases.OAuthError{Code: "server_error"})
+			return
+		}
+
+		status := http.StatusBadRequest
+		if oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+			if usedBasicAuth {
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+		}
+		c.JSON(status, oauthErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OAuthHandler) RegisterClient(c *gin.Context) {
+	var input usecases.RegisterOAuthClientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.oauthUseCase.RegisterClient(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	output, err := h.oauthUseCase.ListClients(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	output, err := h.oauthUseCase.DeleteClient(c.Request.Context(), c.Param("client_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// handleAuthorizeError redirects OAuth errors back to the client. Anything
+// else means the client or redirect URI could not be trusted, so the error is
+// shown on the page instead.
+func (h *OAuthHandler) handleAuthorizeError(c *gin.Context, input usecases.AuthorizeInput, err error) {
+	var oauthErr *usecases.OAuthError
+	if errors.As(err, &oauthErr) {
+		params := url.Values{"error": {oauthErr.Code}}
+		if oauthErr.Description != "" {
+			params.Set("error_description", oauthErr.Description)
+		}
+		c.Redirect(http.StatusFound, usecases.BuildAuthorizationRedirect(input.RedirectURI, params, input.State))
+		return
+	}
+
+	h.renderAuthorizePage(c, http.StatusBadRequest, authorizePageData{Fatal: true, Error: pageError(c, err)})
+}
+
+func (h *OAuthHandler) renderAuthorizePage(c *gin.Context, status int, data authorizePageData) {
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "frame-ancestors 'none'")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	data.pageText = newPageText(c)
+	if err := authorizePageTemplate.Execute(c.Writer, data); err != nil {
+		log.Printf("Error rendering authorize page: %v", err)
+	}
+}
+
+func authorizeParams(input usecases.AuthorizeInput) map[string]string {
+	params := map[string]string{
+		"response_type":         input.ResponseType,
+		"client_id":             input.ClientID,
+		"redirect_uri":          input.RedirectURI,
+		"scope":                 input.Scope,
+		"state":                 input.State,
+		"code_challenge":        input.CodeChallenge,
+		"code_challenge_method": input.CodeChallengeMethod,
+		"nonce":                 input.Nonce,
+	}
+	for name, value := range params {
+		if value == "" {
+			delete(params, name)
+		}
+	}
+	return params
+}
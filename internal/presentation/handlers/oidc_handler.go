@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+var logoutPageTemplate = template.Must(template.New("logout").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{.T "logout_page_title"}}</title>
+	<style>
+		body { font-family: sans-serif; max-width: 420px; margin: 40px auto; padding: 0 16px; color: #222; }
+		.error { color: #b00020; }
+	</style>
+</head>
+<body>
+	{{if .Error}}
+	<h2>{{.T "logout_page_failed"}}</h2>
+	<p class="error">{{.Error}}</p>
+	{{else}}
+	<h2>{{.T "logout_page_title"}}</h2>
+	<p>{{.T "logout_page_done"}}</p>
+	{{end}}
+</body>
+</html>`))
+
+type logoutPageData struct {
+	pageText
+	Error string
+}
+
+func (h *OAuthHandler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.oauthUseCase.Discovery())
+}
+
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	output, err := h.oauthUseCase.UserInfo(c.Request.Context(), usecases.UserInfoInput{
+		UserID:   c.GetString("user_id"),
+		ClientID: c.GetString("token_client_id"),
+		Scope:    c.GetString("token_scope"),
+	})
+	if err != nil {
+		var oauthErr *usecases.OAuthError
+		if !errors.As(err, &oauthErr) {
+			log.Printf("OIDC userinfo error: %v", err)
+			c.JSON(http.StatusInternalServerError, usecases.OAuthError{Code: "server_error"})
+			return
+		}
+
+		status := http.StatusUnauthorized
+		if oauthErr.Code == "insufficient_scope" {
+			status = http.StatusForbidden
+		}
+		c.Header("WWW-Authenticate", `Bearer error="`+oauthErr.Code+`"`)
+		c.JSON(status, oauthErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OAuthHandler) EndSession(c *gin.Context) {
+	var input usecases.EndSessionInput
+	if err := c.ShouldBind(&input); err != nil {
+		h.renderLogoutPage(c, http.StatusBadRequest, pageError(c, errInvalidRequest))
+		return
+	}
+
+	output, err := h.oauthUseCase.EndSession(c.Request.Context(), input)
+	if err != nil {
+		h.renderLogoutPage(c, http.StatusBadRequest, pageError(c, err))
+		return
+	}
+
+	if output.RedirectURL != "" {
+		c.Redirect(http.StatusFound, output.RedirectURL)
+		return
+	}
+
+	h.renderLogoutPage(c, http.StatusOK, "")
+}
+
+func (h *OAuthHandler) renderLogoutPage(c *gin.Context, status int, errorMessage string) {
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	data := logoutPageData{pageText: newPageText(c), Error: errorMessage}
+	if err := logoutPageTemplate.Execute(c.Writer, data); err != nil {
+		log.Printf("Error rendering logout page: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+type OrganizationHandler struct {
+	organizationUseCase *usecases.OrganizationUseCase
+}
+
+func NewOrganizationHandler(organizationUseCase *usecases.OrganizationUseCase) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationUseCase: organizationUseCase,
+	}
+}
+
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	output, err := h.organizationUseCase.ListOrganizations(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	output, err := h.organizationUseCase.GetOrganization(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var input usecases.CreateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.organizationUseCase.CreateOrganization(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	var input usecases.UpdateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.organizationUseCase.UpdateOrganization(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	output, err := h.organizationUseCase.DeleteOrganization(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	output, err := h.organizationUseCase.ListMembers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	var input usecases.AddMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.organizationUseCase.AddMember(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	output, err := h.organizationUseCase.RemoveMember(c.Request.Context(), c.Param("id"), c.Param("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// ListMyOrganizations lists the organizations of the authenticated user.
+func (h *OrganizationHandler) ListMyOrganizations(c *gin.Context) {
+	output, err := h.organizationUseCase.ListUserOrganizations(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func organizationErrorStatus(err error) int {
+	if errors.Is(err, usecases.ErrOrganizationNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
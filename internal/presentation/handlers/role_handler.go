@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+type RoleHandler struct {
+	roleUseCase *usecases.RoleUseCase
+}
+
+func NewRoleHandler(roleUseCase *usecases.RoleUseCase) *RoleHandler {
+	return &RoleHandler{
+		roleUseCase: roleUseCase,
+	}
+}
+
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	output, err := h.roleUseCase.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	output, err := h.roleUseCase.GetRole(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var input usecases.CreateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.roleUseCase.CreateRole(c.Request.Context(), actorFromContext(c), input)
+	if err != nil {
+		c.JSON(roleErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var input usecases.UpdateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.roleUseCase.UpdateRole(c.Request.Context(), actorFromContext(c), c.Param("id"), input)
+	if err != nil {
+		c.JSON(roleErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	output, err := h.roleUseCase.DeleteRole(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(roleErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	output, err := h.roleUseCase.ListPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecases.ErrForbidden), errors.Is(err, usecases.ErrSystemRole):
+		return http.StatusForbidden
+	case errors.Is(err, usecases.ErrRoleInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
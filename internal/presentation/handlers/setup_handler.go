@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+type SetupHandler struct {
+	setupUseCase *usecases.SetupUseCase
+}
+
+func NewSetupHandler(setupUseCase *usecases.SetupUseCase) *SetupHandler {
+	return &SetupHandler{
+		setupUseCase: setupUseCase,
+	}
+}
+
+// Setup creates the first admin with the setup token printed at startup.
+// Once an admin exists the endpoint answers 410 Gone for good.
+func (h *SetupHandler) Setup(c *gin.Context) {
+	var input usecases.SetupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.setupUseCase.Setup(c.Request.Context(), input)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecases.ErrSetupComplete) {
+			status = http.StatusGone
+		} else if errors.Is(err, usecases.ErrInvalidSetupToken) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
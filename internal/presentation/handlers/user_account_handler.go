@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var input usecases.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.ChangePassword(c.Request.Context(), c.GetString("user_id"), c.GetString("organization_id"), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	var input usecases.ChangeEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.RequestEmailChange(c.Request.Context(), c.GetString("user_id"), input)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecases.ErrVerificationEmailCooldown) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, output)
+}
+
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	var input usecases.ConfirmEmailChangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.ConfirmEmailChange(c.Request.Context(), c.GetString("user_id"), c.GetString("organization_id"), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
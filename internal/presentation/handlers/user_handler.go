@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -8,6 +10,7 @@ import (
 
 	"api-auth-go/internal/domain/entities"
 	"api-auth-go/internal/domain/usecases"
+	"api-auth-go/internal/infrastructure/i18n"
 )
 
 type UserHandler struct {
@@ -23,17 +26,13 @@ func NewUserHandler(userUseCase *usecases.UserUseCase) *UserHandler {
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var input usecases.CreateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
 		return
 	}
 
 	output, err := h.userUseCase.CreateUser(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
 		return
 	}
 
@@ -43,17 +42,84 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 func (h *UserHandler) Login(c *gin.Context) {
 	var input usecases.LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
 		return
 	}
 
+	if input.Organization == "" {
+		input.Organization = c.GetString("organization_hint")
+	}
+
 	output, err := h.userUseCase.Login(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		status := http.StatusBadRequest
+		if errors.Is(err, usecases.ErrEmailNotVerified) || errors.Is(err, usecases.ErrNotMember) || errors.Is(err, usecases.ErrAccountDisabled) {
+			status = http.StatusForbidden
+		} else if setRetryAfter(c, err) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var input usecases.RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.RefreshToken(c.Request.Context(), input)
+	if err != nil {
+		// Only the errors of the use case are shown; failures of the
+		// repositories and of signing stay in the log.
+		var codedErr *entities.CodedError
+		switch {
+		case errors.Is(err, usecases.ErrPasswordChangeRequired):
+			c.JSON(http.StatusForbidden, errorResponse(c, err))
+		case errors.As(err, &codedErr):
+			c.JSON(http.StatusUnauthorized, errorResponse(c, err))
+		default:
+			log.Printf("Refresh token error: %v", err)
+			c.JSON(http.StatusInternalServerError, errorResponse(c, errTokenRefreshFailed))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	var input usecases.LogoutInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+			return
+		}
+	}
+
+	input.UserID = c.GetString("user_id")
+	input.TokenID = c.GetString("token_id")
+	input.TokenExpiresAt = c.GetTime("token_expires_at")
+
+	output, err := h.userUseCase.Logout(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	output, err := h.userUseCase.LogoutAll(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
 		return
 	}
 
@@ -65,26 +131,33 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	userEmail := c.GetString("user_email")
 	userName := c.GetString("user_name")
 	userRole := c.GetString("user_role")
+	userPermissions := c.GetStringSlice("user_permissions")
+	if userPermissions == nil {
+		userPermissions = []string{}
+	}
+	organizationID := c.GetString("organization_id")
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":      userID,
-		"email":   userEmail,
-		"name":    userName,
-		"role":    userRole,
-		"message": "Profile retrieved successfully",
+		"id":              userID,
+		"email":           userEmail,
+		"name":            userName,
+		"role":            userRole,
+		"organization_id": organizationID,
+		"permissions":     userPermissions,
+		"message":         i18n.Text(entities.LocaleFromContext(c.Request.Context()), "profile_retrieved", nil),
 	})
 }
 
 func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
 	var input usecases.RequestPasswordResetInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
 		return
 	}
 
 	output, err := h.userUseCase.RequestPasswordReset(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err))
 		return
 	}
 
@@ -94,13 +167,13 @@ func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
 func (h *UserHandler) ResetPassword(c *gin.Context) {
 	var input usecases.ResetPasswordInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
 		return
 	}
 
 	output, err := h.userUseCase.ResetPassword(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
 		return
 	}
 
@@ -108,8 +181,6 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 }
 
 func (h *UserHandler) ListUsers(c *gin.Context) {
-	userID := c.GetString("user_id")
-
 	filters := &entities.UserFilters{
 		Name:      c.Query("name"),
 		Email:     c.Query("email"),
@@ -132,11 +203,9 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		}
 	}
 
-	output, err := h.userUseCase.ListUsers(c.Request.Context(), userID, filters)
+	output, err := h.userUseCase.ListUsers(c.Request.Context(), actorFromContext(c), filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err))
 		return
 	}
 
@@ -148,9 +217,7 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 
 	output, err := h.userUseCase.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		c.JSON(http.StatusNotFound, errorResponse(c, err))
 		return
 	}
 
@@ -162,17 +229,13 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	var input usecases.UpdateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
-		})
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
 		return
 	}
 
-	output, err := h.userUseCase.UpdateUser(c.Request.Context(), userID, input)
+	output, err := h.userUseCase.UpdateUser(c.Request.Context(), actorFromContext(c), userID, input)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		c.JSON(userErrorStatus(err, http.StatusBadRequest), errorResponse(c, err))
 		return
 	}
 
@@ -181,13 +244,56 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
-	output, err := h.userUseCase.DeleteUser(c.Request.Context(), userID)
+	output, err := h.userUseCase.DeleteUser(c.Request.Context(), actorFromContext(c), userID)
+	if err != nil {
+		c.JSON(userErrorStatus(err, http.StatusNotFound), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// SwitchOrganization exchanges the session for one in another organization.
+func (h *UserHandler) SwitchOrganization(c *gin.Context) {
+	var input usecases.SwitchOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.SwitchOrganization(c.Request.Context(), c.GetString("user_id"), input)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		status := http.StatusBadRequest
+		if errors.Is(err, usecases.ErrNotMember) {
+			status = http.StatusForbidden
+		} else if errors.Is(err, usecases.ErrOrganizationNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, errorResponse(c, err))
 		return
 	}
 
 	c.JSON(http.StatusOK, output)
 }
+
+// actorFromContext identifies the authenticated user set by AuthMiddleware.
+func actorFromContext(c *gin.Context) entities.Actor {
+	return entities.Actor{
+		UserID:      c.GetString("user_id"),
+		Role:        c.GetString("user_role"),
+		Permissions: c.GetStringSlice("user_permissions"),
+	}
+}
+
+// userErrorStatus maps authorization errors to their status codes, using
+// fallback for every other error.
+func userErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, usecases.ErrForbidden), errors.Is(err, usecases.ErrSharedAccount):
+		return http.StatusForbidden
+	case errors.Is(err, usecases.ErrLastAdmin):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	output, err := h.userUseCase.UnlockUser(c.Request.Context(), actorFromContext(c), userID)
+	if err != nil {
+		c.JSON(userErrorStatus(err, http.StatusBadRequest), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+func (h *UserHandler) VerifyMFA(c *gin.Context) {
+	var input usecases.VerifyMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.VerifyMFA(c.Request.Context(), input)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, usecases.ErrNotMember) || errors.Is(err, usecases.ErrAccountDisabled) {
+			status = http.StatusForbidden
+		} else if setRetryAfter(c, err) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) EnrollMFA(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	output, err := h.userUseCase.EnrollMFA(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) ConfirmMFA(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var input usecases.ConfirmMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.ConfirmMFA(c.Request.Context(), userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) DisableMFA(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var input usecases.DisableMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.DisableMFA(c.Request.Context(), userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var input usecases.ConfirmMFAInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.RegenerateRecoveryCodes(c.Request.Context(), userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) AdminResetMFA(c *gin.Context) {
+	userID := c.Param("id")
+
+	output, err := h.userUseCase.AdminResetMFA(c.Request.Context(), actorFromContext(c), userID)
+	if err != nil {
+		c.JSON(userErrorStatus(err, http.StatusBadRequest), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
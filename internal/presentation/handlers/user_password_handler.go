@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+func (h *UserHandler) ChangeRequiredPassword(c *gin.Context) {
+	var input usecases.ChangeRequiredPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.ChangeRequiredPassword(c.Request.Context(), input)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecases.ErrInvalidPasswordChangeToken) {
+			status = http.StatusUnauthorized
+		} else if errors.Is(err, usecases.ErrNotMember) || errors.Is(err, usecases.ErrAccountDisabled) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+func (h *UserHandler) RequestPhoneVerification(c *gin.Context) {
+	var input usecases.ChangePhoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.RequestPhoneVerification(c.Request.Context(), c.GetString("user_id"), input)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, usecases.ErrVerificationSMSCooldown) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, output)
+}
+
+func (h *UserHandler) ConfirmPhone(c *gin.Context) {
+	var input usecases.ConfirmPhoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.ConfirmPhone(c.Request.Context(), c.GetString("user_id"), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *UserHandler) RemovePhone(c *gin.Context) {
+	var input usecases.RemovePhoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.userUseCase.RemovePhone(c.Request.Context(), c.GetString("user_id"), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/usecases"
+)
+
+type WebhookHandler struct {
+	webhookUseCase *usecases.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUseCase *usecases.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUseCase: webhookUseCase,
+	}
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	output, err := h.webhookUseCase.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	output, err := h.webhookUseCase.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var input usecases.CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.webhookUseCase.CreateWebhook(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var input usecases.UpdateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidRequestBody))
+		return
+	}
+
+	output, err := h.webhookUseCase.UpdateWebhook(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	output, err := h.webhookUseCase.DeleteWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	output, err := h.webhookUseCase.RotateWebhookSecret(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// ListDeliveries lists the deliveries of a webhook, optionally filtered by
+// status (pending, succeeded or dead).
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	var input usecases.ListWebhookDeliveriesInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, errInvalidQueryParameters))
+		return
+	}
+
+	output, err := h.webhookUseCase.ListDeliveries(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	output, err := h.webhookUseCase.GetDelivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	output, err := h.webhookUseCase.RetryDelivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), errorResponse(c, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func webhookErrorStatus(err error) int {
+	if errors.Is(err, usecases.ErrWebhookNotFound) || errors.Is(err, usecases.ErrWebhookDeliveryNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -2,39 +2,61 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
 	"api-auth-go/internal/infrastructure/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(jwtService *services.JWTService) gin.HandlerFunc {
+// AuthMiddleware authenticates the first-party access token of the request.
+// Tokens issued to OAuth clients are refused, as they only grant access to
+// the userinfo endpoint.
+func AuthMiddleware(jwtService *services.JWTService, revocationRepo repositories.TokenRevocationRepository) gin.HandlerFunc {
+	return authenticate(jwtService.ValidateToken, revocationRepo)
+}
+
+// UserInfoAuthMiddleware is AuthMiddleware for the OpenID Connect userinfo
+// endpoint, which also accepts the tokens issued to OAuth clients.
+func UserInfoAuthMiddleware(jwtService *services.JWTService, revocationRepo repositories.TokenRevocationRepository) gin.HandlerFunc {
+	return authenticate(jwtService.ValidateUserInfoToken, revocationRepo)
+}
+
+func authenticate(validate func(string) (*services.Claims, error), revocationRepo repositories.TokenRevocationRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header is required",
-			})
+			c.JSON(http.StatusUnauthorized, errorBody(c, entities.NewCodedError("authorization_required", "Authorization header is required")))
 			c.Abort()
 			return
 		}
 
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid authorization header format. Use 'Bearer <token>'",
-			})
+			c.JSON(http.StatusUnauthorized, errorBody(c, entities.NewCodedError("invalid_authorization_header", "Invalid authorization header format. Use 'Bearer <token>'")))
 			c.Abort()
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims, err := jwtService.ValidateToken(tokenString)
+		claims, err := validate(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, errorBody(c, entities.NewCodedError("invalid_or_expired_token", "Invalid or expired token")))
+			c.Abort()
+			return
+		}
+
+		revoked, err := revocationRepo.IsRevoked(c.Request.Context(), claims.ID, claims.UserID, claims.IssuedAt.Time)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
+			c.JSON(http.StatusInternalServerError, errorBody(c, entities.NewCodedError("token_validation_failed", "Failed to validate token")))
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, errorBody(c, entities.NewCodedError("token_revoked", "Token has been revoked")))
 			c.Abort()
 			return
 		}
@@ -43,56 +65,55 @@ func AuthMiddleware(jwtService *services.JWTService) gin.HandlerFunc {
 		c.Set("user_email", claims.Email)
 		c.Set("user_name", claims.Name)
 		c.Set("user_role", claims.Role)
+		c.Set("user_permissions", claims.Permissions)
+		c.Set("organization_id", claims.OrganizationID)
+		c.Set("token_id", claims.ID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+		c.Set("token_client_id", claims.ClientID)
+		c.Set("token_scope", claims.Scope)
+
+		info := entities.RequestInfoFromContext(c.Request.Context())
+		info.ActorID = claims.UserID
+		c.Request = c.Request.WithContext(entities.WithRequestInfo(c.Request.Context(), info))
 
 		c.Next()
 	}
 }
 
-func AdminMiddleware() gin.HandlerFunc {
+// RequirePermission only lets through requests whose access token grants
+// every given permission. It must run after AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := c.GetString("user_role")
-		if userRole != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied. Admin role required",
-			})
-			c.Abort()
-			return
+		granted := c.GetStringSlice("user_permissions")
+		for _, permission := range permissions {
+			if !slices.Contains(granted, permission) {
+				c.JSON(http.StatusForbidden, errorBody(c, &entities.CodedError{
+					Code:    "missing_permission",
+					Message: "Access denied. Missing permission: " + permission,
+					Params:  map[string]string{"permission": permission},
+				}))
+				c.Abort()
+				return
+			}
 		}
 		c.Next()
 	}
 }
 
-func RoleBasedAccessMiddleware() gin.HandlerFunc {
+// RequireSelfOrPermission lets users access routes about their own account,
+// identified by the :id parameter, and needs the permission for any other.
+func RequireSelfOrPermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole := c.GetString("user_role")
-		userID := c.GetString("user_id")
-		requestedUserID := c.Param("id")
-
-		if requestedUserID != "" {
-			if userRole == "admin" {
-				c.Next()
-				return
-			}
-
-			if userID != requestedUserID {
-				c.JSON(http.StatusForbidden, gin.H{
-					"error": "Access denied. You can only access your own data",
-				})
-				c.Abort()
-				return
-			}
-
-			if c.Request.Method == "DELETE" {
-				if userRole == "user" {
-					c.JSON(http.StatusForbidden, gin.H{
-						"error": "Access denied. Users cannot delete themselves",
-					})
-					c.Abort()
-					return
-				}
-			}
+		if c.Param("id") == c.GetString("user_id") {
+			c.Next()
+			return
 		}
 
+		if !slices.Contains(c.GetStringSlice("user_permissions"), permission) {
+			c.JSON(http.StatusForbidden, errorBody(c, entities.NewCodedError("own_data_only", "Access denied. You can only access your own data")))
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/infrastructure/i18n"
+)
+
+// LocaleMiddleware picks the language of the responses from the
+// Accept-Language header, falling back to defaultLocale, and stores it in the
+// request context.
+func LocaleMiddleware(defaultLocale string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"), defaultLocale)
+
+		c.Header("Content-Language", locale)
+		c.Header("Vary", "Accept-Language")
+		c.Request = c.Request.WithContext(entities.WithLocale(c.Request.Context(), locale))
+
+		c.Next()
+	}
+}
+
+// errorBody builds the error response in the locale of the request.
+func errorBody(c *gin.Context, err error) gin.H {
+	code, message := i18n.Error(entities.LocaleFromContext(c.Request.Context()), err)
+
+	body := gin.H{
+		"error": message,
+	}
+	if code != "" {
+		body["code"] = code
+	}
+	return body
+}
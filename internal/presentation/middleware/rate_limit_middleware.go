@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit allows Limit requests per Window. A zero Limit disables it.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitKeyFunc decides who a request is counted against.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByUser counts authenticated requests per user, falling back to the
+// IP address when there is no user. It must run after AuthMiddleware.
+func RateLimitByUser(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return RateLimitByIP(c)
+}
+
+// RateLimitByRoute shares one budget between all clients of a route.
+func RateLimitByRoute(c *gin.Context) string {
+	return "route:" + c.Request.Method + " " + c.FullPath()
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimiter implements a sliding window counter: the hits of the previous
+// fixed window are weighted by how much of it still overlaps the sliding
+// window and added to the hits of the current one.
+type RateLimiter struct {
+	store repositories.RateLimitStore
+}
+
+func NewRateLimiter(store repositories.RateLimitStore) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, rate RateLimit) (*RateLimitResult, error) {
+	return l.allow(ctx, key, rate, time.Now())
+}
+
+func (l *RateLimiter) allow(ctx context.Context, key string, rate RateLimit, now time.Time) (*RateLimitResult, error) {
+	current, previous, err := l.store.Increment(ctx, key, rate.Window, now)
+	if err != nil {
+		return nil, err
+	}
+
+	window := float64(rate.Window)
+	elapsed := float64(now.UnixNano() % int64(rate.Window))
+	limit := float64(rate.Limit)
+	count := float64(previous)*(1-elapsed/window) + float64(current)
+
+	result := &RateLimitResult{
+		Allowed:   count <= limit,
+		Limit:     rate.Limit,
+		Remaining: int(math.Max(0, math.Floor(limit-count))),
+		Reset:     time.Duration(window - elapsed),
+	}
+
+	if !result.Allowed {
+		// Find when one more request would fit again: within this window if
+		// only the previous window's weight is in the way, otherwise after
+		// enough of the current window has slid out.
+		var wait float64
+		if float64(current)+1 <= limit {
+			wait = (1-(limit-float64(current)-1)/float64(previous))*window - elapsed
+		} else {
+			wait = window - elapsed + (1-(limit-1)/float64(current))*window
+		}
+		result.RetryAfter = time.Duration(math.Max(wait, 0))
+	}
+
+	return result, nil
+}
+
+// RateLimitMiddleware limits the requests of each key to the given rate,
+// reporting the quota in the RateLimit-* headers and answering 429 with a
+// Retry-After header once it is exceeded. Store failures are logged and the
+// request is let through, so an unavailable store does not take the API down.
+func RateLimitMiddleware(limiter *RateLimiter, name string, rate RateLimit, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil || rate.Limit <= 0 {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), name+":"+keyFunc(c), rate)
+		if err != nil {
+			log.Printf("Error checking rate limit: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rate.Limit, int(rate.Window.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			c.JSON(http.StatusTooManyRequests, errorBody(c, entities.NewCodedError("rate_limited", "Too many requests, please try again later")))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"api-auth-go/internal/infrastructure/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fixedRateLimitStore returns the same counts for every hit.
+type fixedRateLimitStore struct {
+	current, previous int64
+	err               error
+}
+
+func (s *fixedRateLimitStore) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int64, int64, error) {
+	return s.current, s.previous, s.err
+}
+
+func TestRateLimiterSlidingWindow(t *testing.T) {
+	rate := RateLimit{Limit: 10, Window: time.Minute}
+	windowStart := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		current        int64
+		previous       int64
+		elapsed        time.Duration
+		wantAllowed    bool
+		wantRemaining  int
+		wantReset      time.Duration
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:          "first hit",
+			current:       1,
+			wantAllowed:   true,
+			wantRemaining: 9,
+			wantReset:     time.Minute,
+		},
+		{
+			name:          "previous window weighted by its overlap",
+			current:       5,
+			previous:      10,
+			elapsed:       30 * time.Second,
+			wantAllowed:   true,
+			wantRemaining: 0,
+			wantReset:     30 * time.Second,
+		},
+		{
+			name:          "previous window no longer weighs at the end",
+			current:       3,
+			previous:      100,
+			elapsed:       time.Minute - time.Second,
+			wantAllowed:   true,
+			wantRemaining: 5,
+			wantReset:     time.Second,
+		},
+		{
+			name:           "over the limit until the previous window slides out",
+			current:        6,
+			previous:       10,
+			elapsed:        30 * time.Second,
+			wantAllowed:    false,
+			wantRemaining:  0,
+			wantReset:      30 * time.Second,
+			wantRetryAfter: 12 * time.Second,
+		},
+		{
+			name:           "over the limit at the start of the window",
+			current:        1,
+			previous:       20,
+			wantAllowed:    false,
+			wantRemaining:  0,
+			wantReset:      time.Minute,
+			wantRetryAfter: 36 * time.Second,
+		},
+		{
+			name:           "current window alone over the limit",
+			current:        11,
+			elapsed:        15 * time.Second,
+			wantAllowed:    false,
+			wantRemaining:  0,
+			wantReset:      45 * time.Second,
+			wantRetryAfter: 45*time.Second + 60*time.Second*2/11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(&fixedRateLimitStore{current: tt.current, previous: tt.previous})
+
+			result, err := limiter.allow(context.Background(), "key", rate, windowStart.Add(tt.elapsed))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("got allowed %v, want %v", result.Allowed, tt.wantAllowed)
+			}
+			if result.Limit != rate.Limit {
+				t.Errorf("got limit %d, want %d", result.Limit, rate.Limit)
+			}
+			if result.Remaining != tt.wantRemaining {
+				t.Errorf("got remaining %d, want %d", result.Remaining, tt.wantRemaining)
+			}
+			assertDurationNear(t, "reset", result.Reset, tt.wantReset)
+			assertDurationNear(t, "retry after", result.RetryAfter, tt.wantRetryAfter)
+		})
+	}
+}
+
+func assertDurationNear(t *testing.T, name string, got, want time.Duration) {
+	t.Helper()
+	if diff := got - want; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("got %s %v, want %v", name, got, want)
+	}
+}
+
+func newRateLimitedRouter(limiter *RateLimiter, rate RateLimit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.SetTrustedProxies(nil)
+	router.GET("/", RateLimitMiddleware(limiter, "test", rate, RateLimitByIP), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func serveFrom(router *gin.Engine, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(repositories.NewInMemoryRateLimitStore())
+	router := newRateLimitedRouter(limiter, RateLimit{Limit: 2, Window: time.Minute})
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		recorder := serveFrom(router, "192.0.2.1:1234", "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %d, want 200", i+1, recorder.Code)
+		}
+		if got := recorder.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+			t.Errorf("request %d: got RateLimit-Policy %q, want 2;w=60", i+1, got)
+		}
+		if got := recorder.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: got RateLimit-Limit %q, want 2", i+1, got)
+		}
+		if got := recorder.Header().Get("RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("request %d: got RateLimit-Remaining %q, want %s", i+1, got, wantRemaining)
+		}
+		if reset, err := strconv.Atoi(recorder.Header().Get("RateLimit-Reset")); err != nil || reset < 1 || reset > 60 {
+			t.Errorf("request %d: got RateLimit-Reset %q, want 1 to 60 seconds", i+1, recorder.Header().Get("RateLimit-Reset"))
+		}
+		if recorder.Header().Get("Retry-After") != "" {
+			t.Errorf("request %d: unexpected Retry-After on an allowed request", i+1)
+		}
+	}
+
+	recorder := serveFrom(router, "192.0.2.1:1234", "")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %d, want 429", recorder.Code)
+	}
+	if retryAfter, err := strconv.Atoi(recorder.Header().Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 120 {
+		t.Errorf("got Retry-After %q, want 1 to 120 seconds", recorder.Header().Get("Retry-After"))
+	}
+	if got := recorder.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("got RateLimit-Remaining %q, want 0", got)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body["code"] != "rate_limited" {
+		t.Errorf("got body %s, want the rate_limited code", recorder.Body.String())
+	}
+
+	if recorder := serveFrom(router, "192.0.2.2:1234", ""); recorder.Code != http.StatusOK {
+		t.Errorf("got status %d for another IP, want 200", recorder.Code)
+	}
+}
+
+func TestRateLimitMiddlewareIgnoresForwardedForFromUntrustedPeers(t *testing.T) {
+	limiter := NewRateLimiter(repositories.NewInMemoryRateLimitStore())
+	router := newRateLimitedRouter(limiter, RateLimit{Limit: 1, Window: time.Minute})
+
+	serveFrom(router, "192.0.2.1:1234", "198.51.100.1")
+	recorder := serveFrom(router, "192.0.2.1:1234", "198.51.100.2")
+
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("got status %d, want a spoofed X-Forwarded-For to share the peer's budget", recorder.Code)
+	}
+}
+
+func TestRateLimitMiddlewareLetsRequestsThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *RateLimiter
+		rate    RateLimit
+	}{
+		{name: "without limiter", rate: RateLimit{Limit: 1, Window: time.Minute}},
+		{name: "with the limit disabled", limiter: NewRateLimiter(&fixedRateLimitStore{current: 100}), rate: RateLimit{Window: time.Minute}},
+		{name: "when the store fails", limiter: NewRateLimiter(&fixedRateLimitStore{err: errors.New("store down")}), rate: RateLimit{Limit: 1, Window: time.Minute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRateLimitedRouter(tt.limiter, tt.rate)
+
+			recorder := serveFrom(router, "192.0.2.1:1234", "")
+			if recorder.Code != http.StatusOK {
+				t.Errorf("got status %d, want 200", recorder.Code)
+			}
+			if recorder.Header().Get("RateLimit-Limit") != "" {
+				t.Error("unexpected RateLimit headers")
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"api-auth-go/internal/domain/entities"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestInfoMiddleware identifies each request and stores where it comes
+// from in its context, for the audit log. A request id sent by a proxy is
+// kept, otherwise one is generated, and it is echoed in the response.
+func RequestInfoMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(entities.WithRequestInfo(c.Request.Context(), entities.RequestInfo{
+			RequestID: requestID,
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}))
+
+		c.Next()
+	}
+}
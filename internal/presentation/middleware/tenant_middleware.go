@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+const OrganizationHeader = "X-Organization-ID"
+
+// TenantHintMiddleware records the organization a request asks for: the
+// X-Organization-ID header, holding an id or a slug, or else the subdomain of
+// baseDomain the request was sent to, like acme in acme.auth.example.com. It
+// only reads the hint; TenantMiddleware checks it against the access token and
+// login uses it when the body names no organization.
+func TenantHintMiddleware(baseDomain string) gin.HandlerFunc {
+	baseDomain = strings.ToLower(strings.TrimPrefix(baseDomain, "."))
+
+	return func(c *gin.Context) {
+		hint := strings.TrimSpace(c.GetHeader(OrganizationHeader))
+		if hint == "" && baseDomain != "" {
+			hint = subdomain(c.Request.Host, baseDomain)
+		}
+
+		c.Set("organization_hint", hint)
+		c.Next()
+	}
+}
+
+func subdomain(host, baseDomain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	sub, found := strings.CutSuffix(host, "."+baseDomain)
+	if !found || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// TenantMiddleware scopes the request to an organization, so repositories
+// only reach its members. Tokens issued for an organization are bound to it,
+// and asking for another one is refused. Tokens without organization act on
+// the whole platform, and can target a single organization only with the
+// organizations:read permission. It must run after AuthMiddleware and
+// TenantHintMiddleware.
+func TenantMiddleware(orgRepo repositories.OrganizationRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID := c.GetString("organization_id")
+
+		if hint := c.GetString("organization_hint"); hint != "" {
+			var organization *entities.Organization
+			var err error
+			if entities.ValidateUUID(hint) == nil {
+				organization, err = orgRepo.FindByID(c.Request.Context(), hint)
+			} else {
+				organization, err = orgRepo.FindBySlug(c.Request.Context(), hint)
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, errorBody(c, entities.NewCodedError("organization_resolution_failed", "Failed to resolve organization")))
+				c.Abort()
+				return
+			}
+			if organization == nil {
+				c.JSON(http.StatusNotFound, errorBody(c, entities.NewCodedError("organization_not_found", "Organization not found")))
+				c.Abort()
+				return
+			}
+
+			switch {
+			case organizationID != "" && organizationID != organization.ID.String():
+				c.JSON(http.StatusForbidden, errorBody(c, entities.NewCodedError("token_wrong_organization", "Access denied. The token was issued for another organization")))
+				c.Abort()
+				return
+			case organizationID == "" && !slices.Contains(c.GetStringSlice("user_permissions"), entities.PermissionOrganizationsRead):
+				c.JSON(http.StatusForbidden, errorBody(c, entities.NewCodedError("organization_switch_required", "Access denied. Switch to the organization to access it")))
+				c.Abort()
+				return
+			}
+
+			organizationID = organization.ID.String()
+		}
+
+		if organizationID != "" {
+			c.Set("organization_id", organizationID)
+			c.Request = c.Request.WithContext(entities.WithOrganizationID(c.Request.Context(), organizationID))
+		}
+
+		c.Next()
+	}
+}
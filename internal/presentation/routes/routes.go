@@ -3,18 +3,32 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"api-auth-go/internal/domain/entities"
+	"api-auth-go/internal/domain/repositories"
 	"api-auth-go/internal/infrastructure/services"
 	"api-auth-go/internal/presentation/handlers"
 	"api-auth-go/internal/presentation/middleware"
 )
 
-func SetupRoutes(userHandler *handlers.UserHandler) *gin.Engine {
+// RateLimits holds the rate of each route group. Login, password reset and the
+// other public auth routes are limited per IP, the OAuth endpoints per IP and
+// the authenticated API per user.
+type RateLimits struct {
+	Login         middleware.RateLimit
+	PasswordReset middleware.RateLimit
+	Auth          middleware.RateLimit
+	OAuth         middleware.RateLimit
+	API           middleware.RateLimit
+}
+
+func SetupRoutes(userHandler *handlers.UserHandler, keyHandler *handlers.KeyHandler, oauthHandler *handlers.OAuthHandler, roleHandler *handlers.RoleHandler, organizationHandler *handlers.OrganizationHandler, auditHandler *handlers.AuditHandler, webhookHandler *handlers.WebhookHandler, setupHandler *handlers.SetupHandler, jwtService *services.JWTService, revocationRepo repositories.TokenRevocationRepository, organizationRepo repositories.OrganizationRepository, tenantBaseDomain, defaultLocale string, rateLimiter *middleware.RateLimiter, rateLimits RateLimits) *gin.Engine {
 	router := gin.Default()
 
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+middleware.OrganizationHeader+", "+middleware.RequestIDHeader)
+		c.Header("Access-Control-Expose-Headers", middleware.RequestIDHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -23,42 +37,147 @@ func SetupRoutes(userHandler *handlers.UserHandler) *gin.Engine {
 
 		c.Next()
 	})
+	router.Use(middleware.RequestInfoMiddleware())
+	router.Use(middleware.LocaleMiddleware(defaultLocale))
+	router.Use(middleware.TenantHintMiddleware(tenantBaseDomain))
 
 	healthHandler := handlers.NewHealthHandler()
 	router.GET("/health", healthHandler.HealthCheck)
+	router.GET("/.well-known/jwks.json", keyHandler.JWKS)
+	router.GET("/.well-known/openid-configuration", oauthHandler.Discovery)
+
+	oauthRoutes := router.Group("/oauth")
+	oauthRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "oauth", rateLimits.OAuth, middleware.RateLimitByIP))
+	{
+		oauthRoutes.GET("/authorize", oauthHandler.AuthorizePage)
+		oauthRoutes.POST("/authorize", oauthHandler.Authorize)
+		oauthRoutes.POST("/token", oauthHandler.Token)
+		oauthRoutes.GET("/logout", oauthHandler.EndSession)
+		oauthRoutes.POST("/logout", oauthHandler.EndSession)
+	}
+
+	userInfoRoutes := router.Group("/userinfo")
+	userInfoRoutes.Use(middleware.UserInfoAuthMiddleware(jwtService, revocationRepo))
+	userInfoRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "api", rateLimits.API, middleware.RateLimitByUser))
+	{
+		userInfoRoutes.GET("", oauthHandler.UserInfo)
+		userInfoRoutes.POST("", oauthHandler.UserInfo)
+	}
 
 	// Rotas públicas
 	userRoutes := router.Group("/api/v1/users")
+	userRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "login", rateLimits.Login, middleware.RateLimitByIP))
 	{
 		userRoutes.POST("/login", userHandler.Login)
 	}
 
+	authRoutes := router.Group("/api/v1/auth")
+	authRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "auth", rateLimits.Auth, middleware.RateLimitByIP))
+	{
+		authRoutes.POST("/register", userHandler.Register)
+		authRoutes.GET("/verify-email", userHandler.VerifyEmail)
+		authRoutes.POST("/verify-email", userHandler.VerifyEmail)
+		authRoutes.POST("/verify-email/resend", userHandler.ResendVerificationEmail)
+		authRoutes.POST("/refresh", userHandler.RefreshToken)
+		authRoutes.POST("/mfa/verify", userHandler.VerifyMFA)
+		authRoutes.POST("/change-password", userHandler.ChangeRequiredPassword)
+	}
+
+	setupRoutes := router.Group("/api/v1/setup")
+	setupRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "auth", rateLimits.Auth, middleware.RateLimitByIP))
+	{
+		setupRoutes.POST("", setupHandler.Setup)
+	}
+
 	passwordResetRoutes := router.Group("/api/v1/password-reset")
+	passwordResetRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "password-reset", rateLimits.PasswordReset, middleware.RateLimitByIP))
 	{
 		passwordResetRoutes.POST("/request", userHandler.RequestPasswordReset)
 		passwordResetRoutes.POST("/reset", userHandler.ResetPassword)
 	}
 
-	jwtService := services.NewJWTService()
+	sessionRoutes := router.Group("/api/v1/auth")
+	sessionRoutes.Use(middleware.AuthMiddleware(jwtService, revocationRepo))
+	sessionRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "api", rateLimits.API, middleware.RateLimitByUser))
+	{
+		sessionRoutes.POST("/logout", userHandler.Logout)
+		sessionRoutes.POST("/logout-all", userHandler.LogoutAll)
+		sessionRoutes.POST("/switch-organization", userHandler.SwitchOrganization)
+	}
 
 	// Rotas protegidas (todos os usuários autenticados)
 	protectedRoutes := router.Group("/api/v1")
-	protectedRoutes.Use(middleware.AuthMiddleware(jwtService))
-	protectedRoutes.Use(middleware.RoleBasedAccessMiddleware())
+	protectedRoutes.Use(middleware.AuthMiddleware(jwtService, revocationRepo))
+	protectedRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "api", rateLimits.API, middleware.RateLimitByUser))
 	{
 		protectedRoutes.GET("/profile", userHandler.GetProfile)
-		protectedRoutes.GET("/users", userHandler.ListUsers)
-		protectedRoutes.GET("/users/:id", userHandler.GetUserByID)
-		protectedRoutes.PUT("/users/:id", userHandler.UpdateUser)
-		protectedRoutes.DELETE("/users/:id", userHandler.DeleteUser)
+		protectedRoutes.POST("/me/password", userHandler.ChangePassword)
+		protectedRoutes.POST("/me/email", userHandler.RequestEmailChange)
+		protectedRoutes.POST("/me/email/confirm", userHandler.ConfirmEmailChange)
+		protectedRoutes.POST("/me/phone", userHandler.RequestPhoneVerification)
+		protectedRoutes.POST("/me/phone/confirm", userHandler.ConfirmPhone)
+		protectedRoutes.POST("/me/phone/remove", userHandler.RemovePhone)
+		protectedRoutes.POST("/me/locale", userHandler.UpdateLocale)
+		protectedRoutes.POST("/me/mfa/enroll", userHandler.EnrollMFA)
+		protectedRoutes.POST("/me/mfa/confirm", userHandler.ConfirmMFA)
+		protectedRoutes.POST("/me/mfa/disable", userHandler.DisableMFA)
+		protectedRoutes.POST("/me/mfa/recovery-codes", userHandler.RegenerateRecoveryCodes)
+		protectedRoutes.GET("/me/organizations", organizationHandler.ListMyOrganizations)
+	}
+
+	// Rotas de usuários (restritas à organização do token)
+	tenantRoutes := router.Group("/api/v1")
+	tenantRoutes.Use(middleware.AuthMiddleware(jwtService, revocationRepo))
+	tenantRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "api", rateLimits.API, middleware.RateLimitByUser))
+	tenantRoutes.Use(middleware.TenantMiddleware(organizationRepo))
+	{
+		tenantRoutes.GET("/users", userHandler.ListUsers)
+		tenantRoutes.GET("/users/:id", middleware.RequireSelfOrPermission(entities.PermissionUsersRead), userHandler.GetUserByID)
+		tenantRoutes.PUT("/users/:id", middleware.RequireSelfOrPermission(entities.PermissionUsersWrite), userHandler.UpdateUser)
+		tenantRoutes.DELETE("/users/:id", middleware.RequirePermission(entities.PermissionUsersDelete), userHandler.DeleteUser)
 	}
 
-	// Rotas de administração (apenas admins)
+	// Rotas de administração (por permissão, restritas à organização do token)
 	adminRoutes := router.Group("/api/v1/admin")
-	adminRoutes.Use(middleware.AuthMiddleware(jwtService))
-	adminRoutes.Use(middleware.AdminMiddleware())
+	adminRoutes.Use(middleware.AuthMiddleware(jwtService, revocationRepo))
+	adminRoutes.Use(middleware.RateLimitMiddleware(rateLimiter, "api", rateLimits.API, middleware.RateLimitByUser))
+	adminRoutes.Use(middleware.TenantMiddleware(organizationRepo))
 	{
-		adminRoutes.POST("/users", userHandler.CreateUser)
+		adminRoutes.POST("/users", middleware.RequirePermission(entities.PermissionUsersWrite), userHandler.CreateUser)
+		adminRoutes.DELETE("/users/:id/mfa", middleware.RequirePermission(entities.PermissionUsersWrite), userHandler.AdminResetMFA)
+		adminRoutes.POST("/users/:id/unlock", middleware.RequirePermission(entities.PermissionUsersWrite), userHandler.UnlockUser)
+		adminRoutes.GET("/roles", middleware.RequirePermission(entities.PermissionRolesRead), roleHandler.ListRoles)
+		adminRoutes.GET("/roles/:id", middleware.RequirePermission(entities.PermissionRolesRead), roleHandler.GetRole)
+		adminRoutes.POST("/roles", middleware.RequirePermission(entities.PermissionRolesWrite), roleHandler.CreateRole)
+		adminRoutes.PUT("/roles/:id", middleware.RequirePermission(entities.PermissionRolesWrite), roleHandler.UpdateRole)
+		adminRoutes.DELETE("/roles/:id", middleware.RequirePermission(entities.PermissionRolesWrite), roleHandler.DeleteRole)
+		adminRoutes.GET("/permissions", middleware.RequirePermission(entities.PermissionRolesRead), roleHandler.ListPermissions)
+		adminRoutes.GET("/oauth/clients", middleware.RequirePermission(entities.PermissionClientsRead), oauthHandler.ListClients)
+		adminRoutes.POST("/oauth/clients", middleware.RequirePermission(entities.PermissionClientsWrite), oauthHandler.RegisterClient)
+		adminRoutes.DELETE("/oauth/clients/:client_id", middleware.RequirePermission(entities.PermissionClientsWrite), oauthHandler.DeleteClient)
+		adminRoutes.GET("/keys", middleware.RequirePermission(entities.PermissionKeysRead), keyHandler.ListKeys)
+		adminRoutes.POST("/keys", middleware.RequirePermission(entities.PermissionKeysWrite), keyHandler.GenerateKey)
+		adminRoutes.POST("/keys/:kid/promote", middleware.RequirePermission(entities.PermissionKeysWrite), keyHandler.PromoteKey)
+		adminRoutes.POST("/keys/:kid/retire", middleware.RequirePermission(entities.PermissionKeysWrite), keyHandler.RetireKey)
+		adminRoutes.GET("/organizations", middleware.RequirePermission(entities.PermissionOrganizationsRead), organizationHandler.ListOrganizations)
+		adminRoutes.POST("/organizations", middleware.RequirePermission(entities.PermissionOrganizationsWrite), organizationHandler.CreateOrganization)
+		adminRoutes.GET("/organizations/:id", middleware.RequirePermission(entities.PermissionOrganizationsRead), organizationHandler.GetOrganization)
+		adminRoutes.PUT("/organizations/:id", middleware.RequirePermission(entities.PermissionOrganizationsWrite), organizationHandler.UpdateOrganization)
+		adminRoutes.DELETE("/organizations/:id", middleware.RequirePermission(entities.PermissionOrganizationsWrite), organizationHandler.DeleteOrganization)
+		adminRoutes.GET("/organizations/:id/members", middleware.RequirePermission(entities.PermissionOrganizationsRead), organizationHandler.ListMembers)
+		adminRoutes.POST("/organizations/:id/members", middleware.RequirePermission(entities.PermissionOrganizationsWrite), organizationHandler.AddMember)
+		adminRoutes.DELETE("/organizations/:id/members/:user_id", middleware.RequirePermission(entities.PermissionOrganizationsWrite), organizationHandler.RemoveMember)
+		adminRoutes.GET("/audit", middleware.RequirePermission(entities.PermissionAuditRead), auditHandler.ListEvents)
+		adminRoutes.GET("/audit/verify", middleware.RequirePermission(entities.PermissionAuditRead), auditHandler.VerifyChain)
+		adminRoutes.GET("/webhooks", middleware.RequirePermission(entities.PermissionWebhooksRead), webhookHandler.ListWebhooks)
+		adminRoutes.POST("/webhooks", middleware.RequirePermission(entities.PermissionWebhooksWrite), webhookHandler.CreateWebhook)
+		adminRoutes.GET("/webhooks/:id", middleware.RequirePermission(entities.PermissionWebhooksRead), webhookHandler.GetWebhook)
+		adminRoutes.PUT("/webhooks/:id", middleware.RequirePermission(entities.PermissionWebhooksWrite), webhookHandler.UpdateWebhook)
+		adminRoutes.DELETE("/webhooks/:id", middleware.RequirePermission(entities.PermissionWebhooksWrite), webhookHandler.DeleteWebhook)
+		adminRoutes.POST("/webhooks/:id/rotate-secret", middleware.RequirePermission(entities.PermissionWebhooksWrite), webhookHandler.RotateSecret)
+		adminRoutes.GET("/webhooks/:id/deliveries", middleware.RequirePermission(entities.PermissionWebhooksRead), webhookHandler.ListDeliveries)
+		adminRoutes.GET("/webhooks/:id/deliveries/:delivery_id", middleware.RequirePermission(entities.PermissionWebhooksRead), webhookHandler.GetDelivery)
+		adminRoutes.POST("/webhooks/:id/deliveries/:delivery_id/retry", middleware.RequirePermission(entities.PermissionWebhooksWrite), webhookHandler.RetryDelivery)
 	}
 
 	return router